@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/agent"
+	"github.com/petecog/vizfsulizer/internal/config"
+)
+
+// runAgent implements the "agent" command, which collects the state of
+// its host on a schedule and serves it to remote viewers. It returns the
+// process exit code.
+func runAgent(args []string) int {
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	listen := fs.String("listen", ":9723", "`address` to serve the API on")
+	interval := fs.Duration("interval", 0, "`interval` between collections (default: refresh_interval from the configuration, 5s)")
+	tokenFile := fs.String("token-file", "", "read the token viewers must present from `file` (default: $"+agent.TokenEnv+")")
+	certFile := fs.String("tls-cert", "", "serve HTTPS with the certificate in `file`")
+	keyFile := fs.String("tls-key", "", "private key `file` of the -tls-cert certificate")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer agent [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		fmt.Fprintln(os.Stderr, "Error: -tls-cert and -tls-key must be given together")
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if cfg.Source.Type == "remote" {
+		fmt.Fprintln(os.Stderr, "Error: the agent cannot read from another agent; configure the source of this host")
+		return 1
+	}
+	if isSet(fs, "interval") {
+		if err := positiveInterval("interval", *interval); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		cfg.RefreshInterval = config.Duration(*interval)
+	}
+	token, err := agent.LoadToken(*tokenFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	src, err := newSource(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	a := agent.New(src, time.Duration(cfg.RefreshInterval), token)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.Run(ctx)
+
+	// No write timeout: streams stay open for as long as viewers watch,
+	// and are ended by stopping the agent on shutdown
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           a,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	server.RegisterOnShutdown(cancel)
+	return serveTLS(server, *certFile, *keyFile)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/petecog/vizfsulizer/internal/executor"
+	"github.com/petecog/vizfsulizer/internal/source"
+)
+
+// runCapture implements the "capture" command, which records the outputs
+// of the ZFS commands of a real host into a fixture directory for tests
+// and bug reports. It returns the process exit code.
+func runCapture(args []string) int {
+	fs := flag.NewFlagSet("capture", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	dir := fs.String("dir", "", "fixture `directory` to write, created if missing (required)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer capture -dir directory [flags]\n\n"+
+			"Records the commands collecting the local host, or the ssh host given\n"+
+			"with -hosts. Replay the fixture with -fixture directory.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *dir == "" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if cfg.Source.Type == "mock" {
+		cfg.Source.Type = "local" // Nothing to record from the mock data
+	}
+	host, exec, err := newExecutor(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	recorder := &executor.Recorder{Exec: exec, Dir: *dir}
+	src := source.NewZFS(host, recorder)
+	snap, collectErr := source.NewSMART(src, recorder, 0).Collect(context.Background())
+	if collectErr == nil {
+		// Histories are read on demand; a failure is recorded like any other
+		for _, pool := range snap.Pools {
+			src.History(context.Background(), pool.Name)
+		}
+	}
+	for _, cmd := range recorder.Recorded() {
+		fmt.Printf("Recorded %s\n", cmd)
+	}
+	if collectErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", collectErr)
+		return 1
+	}
+	fmt.Printf("Captured %s into %s\n", host, *dir)
+	return 0
+}
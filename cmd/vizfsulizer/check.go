@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/check"
+	"github.com/petecog/vizfsulizer/internal/config"
+)
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runCheck implements the "check" command, a Nagios/Icinga compatible
+// health check. It prints a single status line with perfdata and returns
+// the plugin exit code.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	var thresholds, pools stringList
+	fs.Var(&thresholds, "t", "threshold `[pool:]metric=warning,critical` (repeatable); "+
+		"metrics: capacity (%), errors (count), scrub-age (e.g. 5w)")
+	fs.Var(&pools, "pool", "only check the named `pool` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer check [flags]\n\n"+
+			"Exits 0/1/2/3 for OK/WARNING/CRITICAL/UNKNOWN.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return int(check.Unknown)
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Println("ZFS UNKNOWN - " + err.Error())
+		return int(check.Unknown)
+	}
+	src, err := newSource(cfg)
+	if err != nil {
+		fmt.Println("ZFS UNKNOWN - " + err.Error())
+		return int(check.Unknown)
+	}
+
+	// Thresholds from the configuration file, overridden by -t flags
+	checker := check.NewChecker()
+	checker.Analyzer = cfg.Analyzer()
+	if err := applyThresholds(checker, cfg); err != nil {
+		fmt.Println("ZFS UNKNOWN - " + err.Error())
+		return int(check.Unknown)
+	}
+	for _, spec := range thresholds {
+		if err := checker.SetThreshold(spec); err != nil {
+			fmt.Println("ZFS UNKNOWN - " + err.Error())
+			return int(check.Unknown)
+		}
+	}
+
+	snap, err := src.Collect(context.Background())
+	if err != nil {
+		fmt.Println("ZFS UNKNOWN - " + err.Error())
+		return int(check.Unknown)
+	}
+
+	selected, err := selectPools(snap.Pools, pools)
+	if err != nil {
+		fmt.Println("ZFS UNKNOWN - " + err.Error())
+		return int(check.Unknown)
+	}
+
+	result := checker.Check(selected)
+	fmt.Println(result)
+	return int(result.State)
+}
+
+// applyThresholds sets the default and per-pool thresholds of the
+// configuration file on a checker.
+func applyThresholds(checker *check.Checker, cfg *config.Config) error {
+	for metric, t := range cfg.Thresholds {
+		level, err := check.ParseLevel(check.Metric(metric), t.Warning, t.Critical)
+		if err != nil {
+			return err
+		}
+		checker.Defaults[check.Metric(metric)] = level
+	}
+	for pool, pc := range cfg.Pools {
+		for metric, t := range pc.Thresholds {
+			level, err := check.ParseLevel(check.Metric(metric), t.Warning, t.Critical)
+			if err != nil {
+				return err
+			}
+			if checker.Pools[pool] == nil {
+				checker.Pools[pool] = make(check.Thresholds)
+			}
+			checker.Pools[pool][check.Metric(metric)] = level
+		}
+	}
+	return nil
+}
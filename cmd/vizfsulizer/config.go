@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/agent"
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/disk"
+	"github.com/petecog/vizfsulizer/internal/executor"
+	"github.com/petecog/vizfsulizer/internal/source"
+)
+
+// globalFlags are the flags shared by every command. They override the
+// corresponding settings of the configuration file.
+type globalFlags struct {
+	configPath string
+	source     string
+	hosts      string
+	remote     string
+	fixture    string
+}
+
+// addGlobalFlags registers the shared flags on a command's flag set.
+func addGlobalFlags(fs *flag.FlagSet) *globalFlags {
+	g := &globalFlags{}
+	fs.StringVar(&g.configPath, "config", "", "read configuration from `file` instead of the XDG config directories")
+	fs.StringVar(&g.source, "source", "", "data source `type`, overrides the configuration file")
+	fs.StringVar(&g.hosts, "hosts", "", "comma-separated ssh `hosts` to collect from, selects the ssh source unless -source is given")
+	fs.StringVar(&g.fixture, "fixture", "", "replay the outputs captured in `dir`, selects the fixture source unless -source is given")
+	fs.StringVar(&g.remote, "remote", "", "agent `host:port` to read from, selects the remote source unless -source is given")
+	return g
+}
+
+// load reads and validates the configuration, applying the shared flags.
+func (g *globalFlags) load() (*config.Config, error) {
+	cfg, err := config.Load(g.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if g.hosts != "" {
+		cfg.Source.Hosts = strings.Split(g.hosts, ",")
+		cfg.Source.Type = "ssh"
+	}
+	if g.remote != "" {
+		cfg.Source.Remote.Address = g.remote
+		cfg.Source.Type = "remote"
+	}
+	if g.fixture != "" {
+		cfg.Source.Fixture = g.fixture
+		cfg.Source.Type = "fixture"
+	}
+	if g.source != "" {
+		cfg.Source.Type = g.source
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration %s:\n%w", cfg, err)
+	}
+	return cfg, nil
+}
+
+// positiveInterval checks an interval flag by the rule refresh_interval
+// is validated with, since flags override the configuration after its
+// validation.
+func positiveInterval(name string, d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("-%s: must be positive", name)
+	}
+	return nil
+}
+
+// isSet reports whether a flag was given on the command line, so that only
+// explicit flags override configuration file values.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// newSource creates the data source selected by the configuration, hiding
+// the pools it marks as ignored.
+func newSource(cfg *config.Config) (source.Source, error) {
+	var src source.Source
+	switch cfg.Source.Type {
+	case "mock":
+		src = source.Mock{}
+	case "local", "ssh", "fixture":
+		host, exec, err := newExecutor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		src = newHostSource(cfg, host, exec)
+	case "remote":
+		token, err := agent.LoadToken(cfg.Source.Remote.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("source.remote: %w", err)
+		}
+		src = agent.NewClient(cfg.Source.Remote.Address, token, cfg.Source.Remote.TLS)
+	default:
+		return nil, fmt.Errorf("unknown source type %q", cfg.Source.Type)
+	}
+	return source.NewFiltered(src, cfg.IgnoredPools()), nil
+}
+
+// newHostSource creates the source collecting a host through an executor:
+// its pools, with the disk identities and SMART health of their leaves as
+// far as they are available.
+func newHostSource(cfg *config.Config, host string, exec executor.Executor) source.Source {
+	var src source.Source = source.NewZFS(host, exec)
+	if resolver := newDiskResolver(cfg); resolver != nil {
+		src = source.NewDisks(src, resolver)
+	}
+	if cfg.SMART.Enabled {
+		src = source.NewSMART(src, exec, time.Duration(cfg.SMART.Interval))
+	}
+	return src
+}
+
+// newDiskResolver returns the resolver of the disk identities of the
+// configured source, or nil if they cannot be resolved: sysfs is read
+// from the local file system, so the disks of ssh hosts are not, and
+// those of a fixture only from a configured copy of its device tree.
+func newDiskResolver(cfg *config.Config) *disk.Resolver {
+	switch {
+	case cfg.Source.Type == "local":
+		return disk.NewResolver(cfg.Disks.Root)
+	case cfg.Source.Type == "fixture" && cfg.Disks.Root != "":
+		return disk.NewResolver(cfg.Disks.Root)
+	}
+	return nil
+}
+
+// newHostLogs returns the event log and the pool histories of the
+// configured single source, hiding the events of ignored pools, or nils if
+// the source has none: remote agents do not forward them.
+func newHostLogs(cfg *config.Config) (source.EventSource, source.HistorySource) {
+	var src interface {
+		source.EventSource
+		source.HistorySource
+	}
+	switch cfg.Source.Type {
+	case "mock":
+		src = source.Mock{}
+	case "local", "ssh", "fixture":
+		host, exec, err := newExecutor(cfg)
+		if err != nil {
+			return nil, nil
+		}
+		src = source.NewZFS(host, exec)
+	default:
+		return nil, nil
+	}
+	return source.FilterEvents(src, cfg.IgnoredPools()), src
+}
+
+// newFleet creates a fleet of the configured ssh hosts, or returns nil if
+// the configuration selects a single source.
+func newFleet(cfg *config.Config) *source.Fleet {
+	if cfg.Source.Type != "ssh" || len(cfg.Source.Hosts) < 2 {
+		return nil
+	}
+	var hosts []source.Host
+	for _, host := range cfg.Source.Hosts {
+		src := source.NewFiltered(newHostSource(cfg, sshHostName(host), sshExecutor(cfg, host)), cfg.IgnoredPools())
+		hosts = append(hosts, source.Host{Name: host, Source: src})
+	}
+	return source.NewFleet(hosts, cfg.Source.SSH.Parallel, time.Duration(cfg.Source.SSH.Timeout))
+}
+
+// newExecutor returns the executor running the ZFS commands of a local,
+// ssh or fixture source, and the name of the host they describe.
+func newExecutor(cfg *config.Config) (string, executor.Executor, error) {
+	switch cfg.Source.Type {
+	case "local":
+		host, _ := os.Hostname()
+		return host, executor.Local{}, nil
+	case "ssh":
+		if len(cfg.Source.Hosts) != 1 {
+			return "", nil, fmt.Errorf("source.hosts: %d hosts configured, but only the TUI shows several; select one with -hosts",
+				len(cfg.Source.Hosts))
+		}
+		host := cfg.Source.Hosts[0]
+		return sshHostName(host), sshExecutor(cfg, host), nil
+	case "fixture":
+		return filepath.Base(filepath.Clean(cfg.Source.Fixture)), executor.Fixture{Dir: cfg.Source.Fixture}, nil
+	}
+	return "", nil, fmt.Errorf("source type %q does not run commands", cfg.Source.Type)
+}
+
+// sshExecutor returns the executor running commands on an ssh host.
+func sshExecutor(cfg *config.Config, host string) executor.SSH {
+	return executor.SSH{Host: host, Command: cfg.Source.SSH.Command, Options: cfg.Source.SSH.Options}
+}
+
+// sshHostName strips the user from an ssh destination such as "root@nas1".
+func sshHostName(host string) string {
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		return host[i+1:]
+	}
+	return host
+}
+
+// runConfig implements the "config" command. It returns the process exit code.
+func runConfig(args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: vizfsulizer config <command> [flags]\n\n"+
+			"Commands:\n"+
+			"  validate  Check the configuration file for errors\n"+
+			"  paths     List the files the configuration is looked up in\n")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+		g := addGlobalFlags(fs)
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		cfg, err := g.load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Configuration OK: %s\n", cfg)
+		return 0
+
+	case "paths":
+		for _, path := range config.Paths() {
+			fmt.Println(path)
+		}
+		return 0
+
+	default:
+		usage()
+		return 2
+	}
+}
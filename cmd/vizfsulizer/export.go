@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/petecog/vizfsulizer/internal/export"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// exporters maps export formats to their writers.
+var exporters = map[string]func(io.Writer, []*zfs.Pool) error{
+	"dot":     export.WriteDOT,
+	"mermaid": export.WriteMermaid,
+	"svg":     export.WriteSVG,
+}
+
+// runExport implements the "export" command, writing pool topology
+// diagrams. It returns the process exit code.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	format := fs.String("format", "dot", "diagram `format`: dot, mermaid or svg")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	var pools stringList
+	fs.Var(&pools, "pool", "only export the named `pool` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer export [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	write, ok := exporters[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q\n", *format)
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	src, err := newSource(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	snap, err := src.Collect(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	selected, err := selectPools(snap.Pools, pools)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	if err := write(out, selected); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// selectPools returns the pools with the given names in the order given, or
+// all pools if no names are given. Unknown names are an error.
+func selectPools(all []*zfs.Pool, names []string) ([]*zfs.Pool, error) {
+	if len(names) == 0 {
+		return all, nil
+	}
+	var selected []*zfs.Pool
+	for _, name := range names {
+		found := false
+		for _, pool := range all {
+			if pool.Name == name {
+				selected = append(selected, pool)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("pool %q not found", name)
+		}
+	}
+	return selected, nil
+}
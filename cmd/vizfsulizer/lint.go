@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// lintIndent is the indentation of the explanation below a finding.
+const lintIndent = "            "
+
+// lintWidth is the width the explanations are wrapped at.
+const lintWidth = 78
+
+// runLint implements the "lint" command, printing the risky or suboptimal
+// parts of the pools' layouts with an explanation each. It returns the
+// process exit code.
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	var pools stringList
+	fs.Var(&pools, "pool", "only lint the named `pool` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer lint [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	src, err := newSource(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	snap, err := src.Collect(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	selected, err := selectPools(snap.Pools, pools)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	analyzer := cfg.Analyzer()
+	for i, pool := range selected {
+		if i > 0 {
+			fmt.Println()
+		}
+		writeFindings(os.Stdout, pool.Name, analyzer.LintPool(pool))
+	}
+	return 0
+}
+
+// writeFindings prints the findings of a pool: a line per finding with
+// its severity and check, followed by its explanation.
+func writeFindings(w io.Writer, pool string, findings []status.Finding) {
+	switch len(findings) {
+	case 0:
+		fmt.Fprintf(w, "%s: no findings\n", pool)
+		return
+	case 1:
+		fmt.Fprintf(w, "%s: 1 finding\n", pool)
+	default:
+		fmt.Fprintf(w, "%s: %d findings\n", pool, len(findings))
+	}
+	for _, f := range findings {
+		what := f.Message
+		if f.Device != "" {
+			what = f.Device + ": " + what
+		}
+		fmt.Fprintf(w, "  %-8s  %s [%s]\n", f.Severity, what, f.Check)
+		for _, line := range wrapWords(f.Explanation, lintWidth-len(lintIndent)) {
+			fmt.Fprintln(w, lintIndent+line)
+		}
+	}
+}
+
+// wrapWords breaks a text into lines of at most width characters at
+// spaces; longer words get a line of their own.
+func wrapWords(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -1,23 +1,141 @@
-// cmd/vizfsulizer/main.go
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/source"
 	"github.com/petecog/vizfsulizer/internal/tui"
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
 )
 
 func main() {
+	// Non-interactive commands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "agent":
+			os.Exit(runAgent(os.Args[2:]))
+		case "capture":
+			os.Exit(runCapture(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "report":
+			os.Exit(runReport(os.Args[2:]))
+		case "serve-metrics":
+			os.Exit(runServeMetrics(os.Args[2:]))
+		case "serve-web":
+			os.Exit(runServeWeb(os.Args[2:]))
+		}
+	}
+
+	os.Exit(runTUI(os.Args[1:]))
+}
+
+// runTUI starts the interactive interface. It returns the process exit code.
+func runTUI(args []string) int {
+	fs := flag.NewFlagSet("vizfsulizer", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	refresh := fs.Duration("refresh", 0, "`interval` between pool data refreshes (default: refresh_interval from the configuration, 5s)")
+	color := fs.String("color", "", "status display `mode`: auto, rgb or bw (default: color from the configuration, auto)")
+	screenReader := fs.Bool("screen-reader", false, "render linear text with focus announcements for screen readers (default: screen_reader from the configuration)")
+	charset := fs.String("charset", "", "drawing `characters`: auto, unicode or ascii (default: charset from the configuration, auto)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer [flags]\n"+
+			"       vizfsulizer <agent|capture|check|config|export|lint|report|serve-metrics|serve-web> [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if isSet(fs, "refresh") {
+		cfg.RefreshInterval = config.Duration(*refresh)
+	}
+	if isSet(fs, "color") {
+		mode, err := config.ParseDisplayMode(*color)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		cfg.Color = mode
+	}
+	if isSet(fs, "charset") {
+		cs, err := config.ParseCharset(*charset)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		cfg.Charset = cs
+	}
+	if isSet(fs, "screen-reader") {
+		cfg.ScreenReader = *screenReader
+	}
+	// Several ssh hosts are shown as a fleet, anything else as one source
+	fleet := newFleet(cfg)
+	var src source.Source
+	var events source.EventSource
+	var history source.HistorySource
+	if fleet == nil {
+		if src, err = newSource(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		events, history = newHostLogs(cfg)
+	}
+	themes, err := styles.LoadThemes(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// Screen readers follow the normal screen better, and the mouse is of
+	// no use to their users
+	programOpts := []tea.ProgramOption{
+		tea.WithAltScreen(),      // Use alternate screen buffer
+		tea.WithMouseAllMotion(), // Turn on mouse support, including hover
+	}
+	if cfg.ScreenReader {
+		programOpts = nil
+	}
+
 	p := tea.NewProgram(
-		tui.NewModel(),
-		tea.WithAltScreen(),       // Use alternate screen buffer
-		tea.WithMouseCellMotion(), // Turn on mouse support
+		tui.NewModel(tui.Options{
+			Source:          src,
+			Fleet:           fleet,
+			Events:          events,
+			History:         history,
+			RefreshInterval: time.Duration(cfg.RefreshInterval),
+			Keybindings:     cfg.Keybindings,
+			DisplayMode:     cfg.Color,
+			Charset:         cfg.Charset,
+			ScreenReader:    cfg.ScreenReader,
+			Themes:          themes,
+			Theme:           cfg.Theme,
+			DiskLabel:       cfg.Disks.Label,
+			SlotsPerRow:     cfg.Disks.SlotsPerRow,
+			Analyzer:        cfg.Analyzer(),
+		}),
+		programOpts...,
 	)
 
-	if err := p.Start(); err != nil {
+	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/report"
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// reportWriters maps report formats to their writers.
+var reportWriters = map[string]func(io.Writer, *report.Report) error{
+	"md":   report.WriteMarkdown,
+	"html": report.WriteHTML,
+}
+
+// runReport implements the "report" command, writing a storage report of
+// the host. It returns the process exit code.
+func runReport(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	format := fs.String("format", "md", "report `format`: md or html")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer report [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	write, ok := reportWriters[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q\n", *format)
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	src, err := newSource(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	snap, err := src.Collect(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	history := readHistory(ctx, cfg, snap)
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	if err := write(out, report.Build(snap, history, time.Now(), cfg.Analyzer())); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readHistory reads the zpool history of each pool for the scrub history
+// of a report. It returns nil if the source provides no history; pools
+// whose history cannot be read are left out.
+func readHistory(ctx context.Context, cfg *config.Config, snap *source.Snapshot) map[string][]*zfs.HistoryEntry {
+	_, src := newHostLogs(cfg)
+	if src == nil {
+		return nil
+	}
+	history := make(map[string][]*zfs.HistoryEntry)
+	for _, pool := range snap.Pools {
+		if entries, err := src.History(ctx, pool.Name); err == nil {
+			history[pool.Name] = entries
+		}
+	}
+	return history
+}
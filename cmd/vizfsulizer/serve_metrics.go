@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/metrics"
+	"github.com/petecog/vizfsulizer/internal/source"
+)
+
+// runServeMetrics implements the "serve-metrics" command, a long-running
+// Prometheus exporter. It returns the process exit code.
+func runServeMetrics(args []string) int {
+	fs := flag.NewFlagSet("serve-metrics", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	listen := fs.String("listen", ":9933", "`address` to serve /metrics on")
+	minInterval := fs.Duration("min-interval", 15*time.Second,
+		"minimum `interval` between collections; scrapes in between get cached results")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer serve-metrics [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	base, err := newSource(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	src := source.NewCached(base, *minInterval)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(src))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1>viZFSulizer exporter</h1><a href="/metrics">Metrics</a></body></html>`)
+	})
+
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return serve(server)
+}
+
+// serve runs an HTTP server until it fails or the process is interrupted,
+// then shuts it down gracefully. It returns the process exit code.
+func serve(server *http.Server) int {
+	return serveTLS(server, "", "")
+}
+
+// serveTLS is serve with HTTPS if a certificate and key file are given.
+func serveTLS(server *http.Server, certFile, keyFile string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "Listening on %s\n", server.Addr)
+		if certFile != "" {
+			errCh <- server.ListenAndServeTLS(certFile, keyFile)
+			return
+		}
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/web"
+)
+
+// runServeWeb implements the "serve-web" command, a read-only web
+// dashboard. It returns the process exit code.
+func runServeWeb(args []string) int {
+	fs := flag.NewFlagSet("serve-web", flag.ContinueOnError)
+	g := addGlobalFlags(fs)
+	listen := fs.String("listen", ":8080", "`address` to serve the dashboard on")
+	interval := fs.Duration("interval", 0, "`interval` between updates pushed to browsers (default: refresh_interval from the configuration, 5s)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vizfsulizer serve-web [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if isSet(fs, "interval") {
+		if err := positiveInterval("interval", *interval); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		cfg.RefreshInterval = config.Duration(*interval)
+	}
+	base, err := newSource(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// All connected browsers share one collection per interval
+	refresh := time.Duration(cfg.RefreshInterval)
+	src := source.NewCached(base, refresh)
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           web.NewServer(src, refresh, cfg.Analyzer()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return serve(server)
+}
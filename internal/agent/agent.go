@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+)
+
+// heartbeatInterval is how often idle streams are sent a heartbeat.
+// Clients give up on a stream after missing a few.
+const heartbeatInterval = 15 * time.Second
+
+// Agent collects the state of its host on a schedule and serves the
+// result to every connected viewer. Viewers never trigger collections, so
+// any number of them cost no more than one.
+type Agent struct {
+	src       source.Source
+	interval  time.Duration
+	token     string
+	heartbeat time.Duration
+	mux       *http.ServeMux
+
+	mu          sync.Mutex
+	latest      *Message                   // Last collection, nil until the first one
+	seq         uint64                     // Seq of latest
+	ready       chan struct{}              // Closed after the first collection
+	subscribers map[chan *Message]struct{} // Open streams, nil once stopped
+}
+
+// New creates an agent. Call Run to start collecting.
+//
+// Parameters:
+//   - src: The source collecting the host's state
+//   - interval: Time between two collections
+//   - token: Shared secret viewers must send as a bearer token
+//
+// Returns:
+//   - *Agent: A handler ready to be passed to http.Server
+//
+// Example:
+//
+//	a := agent.New(src, 5*time.Second, token)
+//	go a.Run(ctx)
+//	http.ListenAndServe(":9723", a)
+func New(src source.Source, interval time.Duration, token string) *Agent {
+	a := &Agent{
+		src:         src,
+		interval:    interval,
+		token:       token,
+		heartbeat:   heartbeatInterval,
+		mux:         http.NewServeMux(),
+		ready:       make(chan struct{}),
+		subscribers: make(map[chan *Message]struct{}),
+	}
+	a.mux.HandleFunc(SnapshotPath, a.handleSnapshot)
+	a.mux.HandleFunc(StreamPath, a.handleStream)
+	return a
+}
+
+// Run collects immediately and then once per interval until ctx is done,
+// publishing every result to the connected streams. The streams are ended
+// when it returns, so that an http.Server shutting down need not wait for
+// them.
+//
+// Parameters:
+//   - ctx: Stops collecting when done
+func (a *Agent) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		a.collect(ctx)
+		select {
+		case <-ctx.Done():
+			a.mu.Lock()
+			for ch := range a.subscribers {
+				close(ch)
+			}
+			a.subscribers = nil
+			a.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect gathers a snapshot and publishes it, or the error, to the
+// connected streams.
+func (a *Agent) collect(ctx context.Context) {
+	msg := &Message{Version: ProtocolVersion, Type: TypeSnapshot}
+	snap, err := a.src.Collect(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return // Shutting down
+		}
+		msg.Type, msg.Error = TypeError, err.Error()
+	} else {
+		msg.Snapshot = snap
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq++
+	msg.Seq = a.seq
+	if a.latest == nil {
+		close(a.ready)
+	}
+	a.latest = msg
+	for ch := range a.subscribers {
+		// Slow viewers skip to the latest message instead of holding up
+		// collection; only this function sends, so the buffer is free
+		// once drained
+		select {
+		case ch <- msg:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- msg
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler. Every request must carry the token;
+// only GET and HEAD requests are allowed since the API is read-only.
+func (a *Agent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="vizfsulizer"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	a.mux.ServeHTTP(w, r)
+}
+
+// authorized checks the bearer token of a request in constant time.
+func (a *Agent) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// handleSnapshot serves the last collection, waiting for the first one
+// if the agent just started.
+func (a *Agent) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-a.ready:
+	case <-r.Context().Done():
+		return
+	}
+	a.mu.Lock()
+	msg := a.latest
+	a.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
+
+// handleStream streams newline-delimited messages: the last collection
+// immediately, then every new one as it is collected, with heartbeats in
+// between.
+func (a *Agent) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan *Message, 1)
+	a.mu.Lock()
+	if a.subscribers == nil {
+		a.mu.Unlock()
+		http.Error(w, "agent stopped", http.StatusServiceUnavailable)
+		return
+	}
+	if a.latest != nil {
+		ch <- a.latest
+	}
+	a.subscribers[ch] = struct{}{}
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.subscribers, ch)
+		a.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(a.heartbeat)
+	defer heartbeat.Stop()
+	enc := json.NewEncoder(w)
+	for {
+		var msg *Message
+		select {
+		case <-r.Context().Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return // Agent stopped
+			}
+			msg = m
+		case <-heartbeat.C:
+			msg = &Message{Version: ProtocolVersion, Type: TypeHeartbeat}
+		}
+		if err := enc.Encode(msg); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+)
+
+// failingSource is the mock source, failing its collections while fail
+// is set.
+type failingSource struct {
+	fail atomic.Bool
+}
+
+func (s *failingSource) Collect(ctx context.Context) (*source.Snapshot, error) {
+	if s.fail.Load() {
+		return nil, errors.New("zpool: command not found")
+	}
+	return source.Mock{}.Collect(ctx)
+}
+
+// startAgent serves a running agent until the test ends.
+func startAgent(t *testing.T, src source.Source) (*Agent, string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	a := New(src, 50*time.Millisecond, "secret")
+	go a.Run(ctx)
+	server := httptest.NewServer(a)
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+	})
+	return a, strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestAuthentication(t *testing.T) {
+	_, addr := startAgent(t, &failingSource{})
+
+	for _, header := range []string{"", "Bearer wrong", "Basic secret", "Bearer secret2"} {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+addr+SnapshotPath, nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", header, resp.StatusCode)
+		}
+	}
+
+	if _, err := NewClient(addr, "wrong", false).Collect(context.Background()); err == nil ||
+		!strings.Contains(err.Error(), "token rejected") {
+		t.Errorf("wrong token: %v", err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	_, addr := startAgent(t, &failingSource{})
+	snap, err := NewClient(addr, "secret", false).Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := source.Mock{}.Collect(context.Background())
+	if len(snap.Pools) != len(want.Pools) || snap.Pools[0].Name != want.Pools[0].Name ||
+		snap.Pools[0].RootVDev.Children[0].Name != want.Pools[0].RootVDev.Children[0].Name {
+		t.Errorf("snapshot did not survive the round trip: %+v", snap.Pools)
+	}
+}
+
+func TestStream(t *testing.T) {
+	src := &failingSource{}
+	_, addr := startAgent(t, src)
+	client := NewClient(addr, "secret", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan source.Update)
+	done := make(chan error, 1)
+	go func() { done <- client.Stream(ctx, updates) }()
+
+	next := func() source.Update {
+		t.Helper()
+		select {
+		case u := <-updates:
+			return u
+		case <-time.After(5 * time.Second):
+			t.Fatal("no update streamed")
+		}
+		return source.Update{}
+	}
+	if u := next(); u.Err != nil || u.Snapshot == nil {
+		t.Fatalf("first update %+v", u)
+	}
+	if u := next(); u.Err != nil || u.Snapshot == nil {
+		t.Fatalf("second update %+v", u)
+	}
+
+	// Collection errors on the agent are streamed as error updates
+	src.fail.Store(true)
+	for {
+		if u := next(); u.Err != nil {
+			if !strings.Contains(u.Err.Error(), "command not found") {
+				t.Errorf("error update %v", u.Err)
+			}
+			break
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stream did not return after cancel")
+	}
+}
+
+func TestStreamIdle(t *testing.T) {
+	// A server that accepts the stream but never writes
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"), "secret", false)
+	client.idle = 50 * time.Millisecond
+	err := client.stream(context.Background(), make(chan source.Update))
+	if err == nil || !strings.Contains(err.Error(), "nothing received") {
+		t.Errorf("idle stream: %v", err)
+	}
+}
+
+func TestLoadToken(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	if _, err := LoadToken(""); err == nil {
+		t.Error("missing token accepted")
+	}
+	t.Setenv(TokenEnv, " from-env\n")
+	if token, err := LoadToken(""); err != nil || token != "from-env" {
+		t.Errorf("LoadToken from environment = %q, %v", token, err)
+	}
+}
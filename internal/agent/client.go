@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+)
+
+// Timings of the client.
+const (
+	// requestTimeout limits a snapshot request
+	requestTimeout = 30 * time.Second
+
+	// retryInterval is the wait before reconnecting a failed stream
+	retryInterval = 5 * time.Second
+
+	// idleTimeout drops a stream that sent nothing, not even heartbeats
+	idleTimeout = 3 * heartbeatInterval
+)
+
+// Client is a Source reading the state of a remote host from its agent.
+// It implements source.Streamer, so viewers get each collection of the
+// agent as soon as it is made.
+type Client struct {
+	address string
+	base    string
+	token   string
+	http    *http.Client
+	retry   time.Duration
+	idle    time.Duration
+}
+
+// NewClient creates a client of an agent.
+//
+// Parameters:
+//   - address: The agent's address, "host:port"
+//   - token: The agent's token, see LoadToken
+//   - useTLS: Whether the agent serves HTTPS; its certificate is checked
+//     against the system roots, or the SSL_CERT_FILE bundle
+//
+// Returns:
+//   - *Client: A source ready for use
+//
+// Example:
+//
+//	src := agent.NewClient("nas1:9723", token, true)
+//	snap, err := src.Collect(ctx)
+func NewClient(address, token string, useTLS bool) *Client {
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	return &Client{
+		address: address,
+		base:    scheme + "://" + address,
+		token:   token,
+		http:    &http.Client{},
+		retry:   retryInterval,
+		idle:    idleTimeout,
+	}
+}
+
+// Collect implements source.Source, fetching the agent's last collection.
+func (c *Client) Collect(ctx context.Context) (*source.Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := c.get(ctx, SnapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var msg Message
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("agent %s: %w", c.address, err)
+	}
+	return c.snapshot(&msg)
+}
+
+// Stream implements source.Streamer. A broken or silent connection is
+// reported as an update and reconnected after a short wait.
+func (c *Client) Stream(ctx context.Context, updates chan<- source.Update) error {
+	for {
+		err := c.stream(ctx, updates)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		select {
+		case updates <- source.Update{Err: err}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case <-time.After(c.retry):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// stream reads one connection to the stream endpoint until it fails.
+func (c *Client) stream(parent context.Context, updates chan<- source.Update) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	resp, err := c.get(ctx, StreamPath)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Heartbeats arrive well within the idle timeout, so a silent stream
+	// is a dead connection
+	watchdog := time.AfterFunc(c.idle, cancel)
+	defer watchdog.Stop()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg Message
+		if err := dec.Decode(&msg); err != nil {
+			switch {
+			case parent.Err() != nil:
+				return parent.Err()
+			case ctx.Err() != nil:
+				return fmt.Errorf("agent %s: nothing received for %s", c.address, c.idle)
+			case errors.Is(err, io.EOF):
+				return fmt.Errorf("agent %s: stream closed", c.address)
+			}
+			return fmt.Errorf("agent %s: %w", c.address, err)
+		}
+		watchdog.Reset(c.idle)
+		if msg.Type == TypeHeartbeat {
+			continue
+		}
+
+		snap, err := c.snapshot(&msg)
+		if msg.Version != ProtocolVersion {
+			return err
+		}
+		select {
+		case updates <- source.Update{Snapshot: snap, Err: err}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// get sends an authenticated request, turning error statuses into errors.
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("agent %s: token rejected", c.address)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("agent %s: protocol version %d not supported", c.address, ProtocolVersion)
+	}
+	return nil, fmt.Errorf("agent %s: %s", c.address, resp.Status)
+}
+
+// snapshot extracts the snapshot of a message, or the collection error it
+// reports.
+func (c *Client) snapshot(msg *Message) (*source.Snapshot, error) {
+	switch {
+	case msg.Version != ProtocolVersion:
+		return nil, fmt.Errorf("agent %s: protocol version %d, want %d", c.address, msg.Version, ProtocolVersion)
+	case msg.Type == TypeError:
+		return nil, fmt.Errorf("agent %s: %s", c.address, msg.Error)
+	case msg.Type != TypeSnapshot || msg.Snapshot == nil:
+		return nil, fmt.Errorf("agent %s: unexpected %q message", c.address, msg.Type)
+	}
+	return msg.Snapshot, nil
+}
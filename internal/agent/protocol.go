@@ -0,0 +1,96 @@
+// Package agent implements the agent mode: a small server running on a
+// storage host that collects its ZFS state on a schedule and serves it to
+// remote viewers over an authenticated HTTP+JSON API, and the client that
+// the viewers use as their source.
+//
+// The protocol is versioned through the path prefix (/v1) and a version
+// field in every message. Snapshots are the JSON encoding of
+// source.Snapshot. The stream endpoint pushes one message per line
+// (newline-delimited JSON) for every collection, so viewers get live
+// updates without polling.
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+)
+
+// ProtocolVersion is the version of the protocol spoken by this build.
+// It is raised on incompatible changes to the paths or messages.
+const ProtocolVersion = 1
+
+// Paths of the API endpoints.
+const (
+	// SnapshotPath returns the last collected snapshot as one Message
+	SnapshotPath = "/v1/snapshot"
+
+	// StreamPath streams a Message per collection, plus heartbeats
+	StreamPath = "/v1/stream"
+)
+
+// Types of messages.
+const (
+	// TypeSnapshot carries a collected snapshot
+	TypeSnapshot = "snapshot"
+
+	// TypeError carries the error of a failed collection
+	TypeError = "error"
+
+	// TypeHeartbeat is sent on idle streams so that clients notice dead
+	// connections
+	TypeHeartbeat = "heartbeat"
+)
+
+// TokenEnv is the environment variable holding the token when no token
+// file is given.
+const TokenEnv = "VIZFSULIZER_AGENT_TOKEN"
+
+// Message is the unit of the protocol, sent alone by the snapshot endpoint
+// and once per line by the stream endpoint.
+type Message struct {
+	// Version is the ProtocolVersion of the sender
+	Version int `json:"version"`
+
+	// Type is TypeSnapshot, TypeError or TypeHeartbeat
+	Type string `json:"type"`
+
+	// Seq numbers the collections of the agent, increasing by one each
+	Seq uint64 `json:"seq,omitempty"`
+
+	// Snapshot is the collected state of a TypeSnapshot message
+	Snapshot *source.Snapshot `json:"snapshot,omitempty"`
+
+	// Error is the collection error of a TypeError message
+	Error string `json:"error,omitempty"`
+}
+
+// LoadToken reads the shared token authenticating viewers to the agent.
+//
+// Parameters:
+//   - path: File holding the token, or empty to read the TokenEnv variable
+//
+// Returns:
+//   - string: The token, without surrounding whitespace
+//   - error: Error if the file cannot be read or no token is set
+func LoadToken(path string) (string, error) {
+	token := os.Getenv(TokenEnv)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		token = string(data)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		if path != "" {
+			return "", fmt.Errorf("%s: empty token", path)
+		}
+		return "", errors.New("no agent token: give a token file or set " + TokenEnv)
+	}
+	return token, nil
+}
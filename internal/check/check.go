@@ -0,0 +1,431 @@
+// Package check implements a Nagios/Icinga compatible health check for ZFS
+// pools. It shares the status analyzer with the TUI so that both report the
+// same health for the same pool.
+package check
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// State is a monitoring plugin state. Its numeric value is the exit code
+// expected by Nagios, Icinga and compatible monitoring systems.
+type State int
+
+// Plugin states in exit code order.
+const (
+	// OK indicates every checked pool is healthy
+	OK State = iota
+
+	// Warning indicates a pool needs attention soon
+	Warning
+
+	// Critical indicates a pool needs immediate attention
+	Critical
+
+	// Unknown indicates the check could not determine the pool state
+	Unknown
+)
+
+// String returns the state name as printed in the plugin output.
+func (s State) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// severity orders states from best to worst. UNKNOWN ranks between OK and
+// WARNING so that a real problem on one pool is never hidden by another
+// pool that could not be evaluated.
+func (s State) severity() int {
+	switch s {
+	case OK:
+		return 0
+	case Unknown:
+		return 1
+	case Warning:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// worst returns the more severe of two states.
+func worst(a, b State) State {
+	if b.severity() > a.severity() {
+		return b
+	}
+	return a
+}
+
+// Metric names a value that can be compared against thresholds.
+type Metric string
+
+// Metrics supported by the check.
+const (
+	// MetricCapacity is the allocated share of the pool size, in percent
+	MetricCapacity Metric = "capacity"
+
+	// MetricErrors is the sum of all read, write and checksum errors
+	MetricErrors Metric = "errors"
+
+	// MetricScrubAge is the time since the last completed scrub, in seconds
+	MetricScrubAge Metric = "scrub-age"
+)
+
+// Level holds the warning and critical thresholds for a metric.
+// A value is in a state once it reaches that state's threshold.
+// A zero threshold disables that state.
+type Level struct {
+	Warning  float64
+	Critical float64
+}
+
+// evaluate compares a value against the level.
+func (l Level) evaluate(v float64) State {
+	if l.Critical > 0 && v >= l.Critical {
+		return Critical
+	}
+	if l.Warning > 0 && v >= l.Warning {
+		return Warning
+	}
+	return OK
+}
+
+// Thresholds maps metrics to their levels.
+type Thresholds map[Metric]Level
+
+// DefaultThresholds returns the thresholds used when none are configured:
+// capacity 80%/90%, any error is a warning and 10 are critical, and a scrub
+// older than 5 weeks is a warning and older than 10 weeks is critical.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MetricCapacity: {Warning: 80, Critical: 90},
+		MetricErrors:   {Warning: 1, Critical: 10},
+		MetricScrubAge: {
+			Warning:  (35 * 24 * time.Hour).Seconds(),
+			Critical: (70 * 24 * time.Hour).Seconds(),
+		},
+	}
+}
+
+// Perfdata is a single performance data item of the plugin output.
+type Perfdata struct {
+	Label string
+	Value float64
+	Unit  string
+	Level Level
+
+	// Max is the maximum possible value, or 0 if there is none
+	Max float64
+}
+
+// String formats the item as 'label'=value[unit];[warn];[crit];[min];[max].
+func (p Perfdata) String() string {
+	field := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return formatNumber(v)
+	}
+	s := fmt.Sprintf("'%s'=%s%s;%s;%s;0", p.Label, formatNumber(p.Value), p.Unit,
+		field(p.Level.Warning), field(p.Level.Critical))
+	if p.Max > 0 {
+		s += ";" + formatNumber(p.Max)
+	}
+	return s
+}
+
+// Result is the outcome of a check run.
+type Result struct {
+	State    State
+	Summary  string
+	Perfdata []Perfdata
+}
+
+// String formats the result as a single plugin output line.
+func (r Result) String() string {
+	s := "ZFS " + r.State.String() + " - " + r.Summary
+	if len(r.Perfdata) > 0 {
+		items := make([]string, len(r.Perfdata))
+		for i, p := range r.Perfdata {
+			items[i] = p.String()
+		}
+		s += " | " + strings.Join(items, " ")
+	}
+	return s
+}
+
+// Checker evaluates pools against default and per-pool thresholds.
+type Checker struct {
+	// Defaults apply to every pool unless overridden
+	Defaults Thresholds
+
+	// Pools holds per-pool overrides, keyed by pool name.
+	// Metrics missing from an override fall back to Defaults.
+	Pools map[string]Thresholds
+
+	// Now returns the current time, used to compute scrub age
+	Now func() time.Time
+
+	// Analyzer judges the health of the pools
+	Analyzer *status.Analyzer
+}
+
+// NewChecker creates a Checker using the default thresholds.
+//
+// Returns:
+//   - *Checker: A new Checker ready for use
+func NewChecker() *Checker {
+	return &Checker{
+		Defaults: DefaultThresholds(),
+		Pools:    make(map[string]Thresholds),
+		Now:      time.Now,
+		Analyzer: &status.Analyzer{},
+	}
+}
+
+// SetThreshold applies a threshold specification of the form
+// "[pool:]metric=warning,critical". Without a pool prefix the default for
+// all pools is changed. Either value may be empty to disable that state.
+//
+// Parameters:
+//   - spec: The threshold specification to apply
+//
+// Returns:
+//   - error: Error if the specification cannot be parsed
+//
+// Example:
+//
+//	checker.SetThreshold("capacity=85%,95%")
+//	checker.SetThreshold("backup:scrub-age=8w,")
+//	checker.SetThreshold("tank:a:capacity=80%,90%") // pool "tank:a"
+func (c *Checker) SetThreshold(spec string) error {
+	name, values, ok := strings.Cut(spec, "=")
+	if !ok {
+		return fmt.Errorf("invalid threshold %q: expected [pool:]metric=warning,critical", spec)
+	}
+	// Pool names may contain colons, metric names do not
+	pool := ""
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		pool, name = name[:i], name[i+1:]
+	}
+	warn, crit, ok := strings.Cut(values, ",")
+	if !ok {
+		return fmt.Errorf("invalid threshold %q: expected warning and critical values", spec)
+	}
+
+	metric := Metric(strings.TrimSpace(name))
+	level, err := ParseLevel(metric, warn, crit)
+	if err != nil {
+		return err
+	}
+
+	if pool == "" {
+		c.Defaults[metric] = level
+		return nil
+	}
+	if c.Pools[pool] == nil {
+		c.Pools[pool] = make(Thresholds)
+	}
+	c.Pools[pool][metric] = level
+	return nil
+}
+
+// ParseLevel parses the warning and critical thresholds of a metric, given
+// in the metric's unit: a percentage for capacity, a count for errors and a
+// duration such as "5w" for scrub age. Empty values disable that state.
+//
+// Parameters:
+//   - metric: The metric the thresholds apply to
+//   - warning: The warning threshold
+//   - critical: The critical threshold
+//
+// Returns:
+//   - Level: The parsed thresholds
+//   - error: Error if the metric is unknown or a value cannot be parsed
+func ParseLevel(metric Metric, warning, critical string) (Level, error) {
+	var level Level
+	var err error
+	if level.Warning, err = parseValue(metric, warning); err != nil {
+		return Level{}, err
+	}
+	if level.Critical, err = parseValue(metric, critical); err != nil {
+		return Level{}, err
+	}
+	return level, nil
+}
+
+// parseValue parses a threshold value in the unit of the given metric.
+// Empty values yield 0, which disables the threshold.
+func parseValue(metric Metric, s string) (float64, error) {
+	if metric != MetricCapacity && metric != MetricErrors && metric != MetricScrubAge {
+		return 0, fmt.Errorf("unknown metric %q", metric)
+	}
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	switch metric {
+	case MetricCapacity:
+		return utils.ParsePercent(s)
+	case MetricErrors:
+		n, err := utils.ParseCount(s)
+		return float64(n), err
+	default:
+		d, err := utils.ParseDuration(s)
+		return d.Seconds(), err
+	}
+}
+
+// Level returns the thresholds that apply to a metric of the named pool.
+//
+// Parameters:
+//   - pool: Name of the pool
+//   - metric: The metric to look up
+//
+// Returns:
+//   - Level: The pool override if present, otherwise the default
+func (c *Checker) Level(pool string, metric Metric) Level {
+	if override, ok := c.Pools[pool][metric]; ok {
+		return override
+	}
+	return c.Defaults[metric]
+}
+
+// Check evaluates all pools and combines them into a single result.
+// The result state is the worst state of any pool.
+//
+// Parameters:
+//   - pools: The pools to check
+//
+// Returns:
+//   - Result: The combined check result
+func (c *Checker) Check(pools []*zfs.Pool) Result {
+	if len(pools) == 0 {
+		return Result{State: Unknown, Summary: "no pools found"}
+	}
+
+	result := Result{State: OK}
+	var problems []string
+	for _, pool := range pools {
+		state, poolProblems, perfdata := c.checkPool(pool)
+		result.State = worst(result.State, state)
+		problems = append(problems, poolProblems...)
+		result.Perfdata = append(result.Perfdata, perfdata...)
+	}
+
+	if len(problems) == 0 {
+		result.Summary = fmt.Sprintf("%d %s healthy", len(pools), plural(len(pools), "pool", "pools"))
+	} else {
+		result.Summary = strings.Join(problems, ", ")
+	}
+	return result
+}
+
+// checkPool evaluates a single pool, returning its state, a description
+// of every problem found and its performance data.
+func (c *Checker) checkPool(pool *zfs.Pool) (State, []string, []Perfdata) {
+	state := OK
+	var problems []string
+	var perfdata []Perfdata
+
+	if pool.RootVDev == nil {
+		return Unknown, []string{pool.Name + " has no vdevs"}, nil
+	}
+
+	// Health as seen by the analyzer, which includes cache and log devices
+	switch health := c.Analyzer.GetPoolWorstStatus(pool); health {
+	case zfs.VDevStatusOnline:
+	case zfs.VDevStatusDegraded:
+		state = worst(state, Warning)
+		problems = append(problems, pool.Name+" "+string(health))
+	case zfs.VDevStatusFaulted:
+		state = worst(state, Critical)
+		problems = append(problems, pool.Name+" "+string(health))
+	default:
+		state = worst(state, Unknown)
+		problems = append(problems, fmt.Sprintf("%s status %q unknown", pool.Name, health))
+	}
+
+	if pool.Size > 0 {
+		level := c.Level(pool.Name, MetricCapacity)
+		capacity := pool.CapacityPercent()
+		if s := level.evaluate(capacity); s != OK {
+			state = worst(state, s)
+			problems = append(problems, fmt.Sprintf("%s capacity %s%%", pool.Name, formatNumber(capacity)))
+		}
+		perfdata = append(perfdata, Perfdata{
+			Label: pool.Name + "_capacity",
+			Value: capacity,
+			Unit:  "%",
+			Level: level,
+			Max:   100,
+		})
+	}
+
+	level := c.Level(pool.Name, MetricErrors)
+	errors := c.Analyzer.GetPoolErrorCount(pool)
+	if s := level.evaluate(float64(errors)); s != OK {
+		state = worst(state, s)
+		problems = append(problems, fmt.Sprintf("%s %d %s", pool.Name, errors, plural(int(errors), "error", "errors")))
+	}
+	perfdata = append(perfdata, Perfdata{
+		Label: pool.Name + "_errors",
+		Value: float64(errors),
+		Unit:  "c",
+		Level: level,
+	})
+
+	level = c.Level(pool.Name, MetricScrubAge)
+	if age, ok := c.Analyzer.GetScrubAge(pool, c.Now()); ok {
+		if s := level.evaluate(age.Seconds()); s != OK {
+			state = worst(state, s)
+			problems = append(problems, fmt.Sprintf("%s last scrub %dd ago", pool.Name, int(age.Hours()/24)))
+		}
+		perfdata = append(perfdata, Perfdata{
+			Label: pool.Name + "_scrub_age",
+			Value: age.Truncate(time.Second).Seconds(),
+			Unit:  "s",
+			Level: level,
+		})
+	} else if level.Warning > 0 && !scanning(pool) {
+		// A pool that was never scrubbed is treated as overdue, but only
+		// up to a warning since there is no age to compare.
+		state = worst(state, Warning)
+		problems = append(problems, pool.Name+" never scrubbed")
+	}
+
+	return state, problems, perfdata
+}
+
+// scanning reports whether a scrub or resilver is currently running.
+func scanning(pool *zfs.Pool) bool {
+	return pool.Scan != nil && pool.Scan.State == "scanning"
+}
+
+// formatNumber formats a value with at most one decimal place.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// plural returns singular when n is 1 and pluralForm otherwise.
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
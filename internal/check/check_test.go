@@ -0,0 +1,150 @@
+package check
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// testPool builds a healthy two-disk mirror scrubbed scrubAge before now.
+func testPool(name string, now time.Time, scrubAge time.Duration) *zfs.Pool {
+	return &zfs.Pool{
+		Name:      name,
+		Status:    zfs.VDevStatusOnline,
+		Size:      100,
+		Allocated: 50,
+		Free:      50,
+		Scan: &zfs.ScanInfo{
+			Function: "scrub",
+			State:    "finished",
+			End:      now.Add(-scrubAge),
+		},
+		RootVDev: &zfs.VDev{
+			Name:   "mirror-0",
+			Type:   "mirror",
+			Status: zfs.VDevStatusOnline,
+			Children: []*zfs.VDev{
+				{Name: "sda", Type: "disk", Status: zfs.VDevStatusOnline},
+				{Name: "sdb", Type: "disk", Status: zfs.VDevStatusOnline},
+			},
+		},
+	}
+}
+
+func newTestChecker(now time.Time) *Checker {
+	c := NewChecker()
+	c.Now = func() time.Time { return now }
+	return c
+}
+
+func TestCheckStates(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name   string
+		modify func(*zfs.Pool)
+		want   State
+	}{
+		{"healthy", func(p *zfs.Pool) {}, OK},
+		{"degraded disk", func(p *zfs.Pool) { p.RootVDev.Children[0].Status = zfs.VDevStatusDegraded }, Warning},
+		{"faulted log", func(p *zfs.Pool) {
+			p.Slog = &zfs.VDev{Name: "log", Type: "mirror", Status: zfs.VDevStatusFaulted}
+		}, Critical},
+		{"capacity warning", func(p *zfs.Pool) { p.Allocated = 85 }, Warning},
+		{"capacity critical", func(p *zfs.Pool) { p.Allocated = 95 }, Critical},
+		{"checksum errors", func(p *zfs.Pool) { p.RootVDev.Children[1].ChecksumErrors = 2 }, Warning},
+		{"many errors", func(p *zfs.Pool) { p.RootVDev.Children[1].ReadErrors = 10 }, Critical},
+		{"old scrub", func(p *zfs.Pool) { p.Scan.End = now.Add(-40 * day) }, Warning},
+		{"ancient scrub", func(p *zfs.Pool) { p.Scan.End = now.Add(-100 * day) }, Critical},
+		{"never scrubbed", func(p *zfs.Pool) { p.Scan = nil }, Warning},
+		{"scrub running", func(p *zfs.Pool) { p.Scan.State = "scanning" }, OK},
+		{"unknown status", func(p *zfs.Pool) { p.RootVDev.Status = "UNAVAIL" }, Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := testPool("tank", now, day)
+			tt.modify(pool)
+			result := newTestChecker(now).Check([]*zfs.Pool{pool})
+			if result.State != tt.want {
+				t.Errorf("state = %s, want %s (%s)", result.State, tt.want, result)
+			}
+		})
+	}
+}
+
+func TestCheckWorstPoolWins(t *testing.T) {
+	now := time.Now()
+	healthy := testPool("healthy", now, time.Hour)
+	broken := testPool("broken", now, time.Hour)
+	broken.RootVDev.Status = zfs.VDevStatusFaulted
+	unknown := testPool("unknown", now, time.Hour)
+	unknown.RootVDev = nil
+
+	result := newTestChecker(now).Check([]*zfs.Pool{healthy, unknown, broken})
+	if result.State != Critical {
+		t.Errorf("state = %s, want CRITICAL", result.State)
+	}
+}
+
+func TestPerPoolThresholds(t *testing.T) {
+	now := time.Now()
+	tank := testPool("tank", now, time.Hour)
+	backup := testPool("backup", now, time.Hour)
+	tank.Allocated, backup.Allocated = 85, 85
+
+	c := newTestChecker(now)
+	if err := c.SetThreshold("backup:capacity=90%,95%"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.Check([]*zfs.Pool{backup}).State; got != OK {
+		t.Errorf("backup state = %s, want OK with raised threshold", got)
+	}
+	if got := c.Check([]*zfs.Pool{tank}).State; got != Warning {
+		t.Errorf("tank state = %s, want WARNING with default threshold", got)
+	}
+	if got := c.Level("backup", MetricErrors); got != c.Defaults[MetricErrors] {
+		t.Errorf("backup errors level = %+v, want default %+v", got, c.Defaults[MetricErrors])
+	}
+	// Pool names may contain colons
+	if err := c.SetThreshold("tank:a:capacity=90%,95%"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Level("tank:a", MetricCapacity); got != c.Level("backup", MetricCapacity) {
+		t.Errorf("tank:a capacity level = %+v", got)
+	}
+}
+
+func TestSetThresholdErrors(t *testing.T) {
+	for _, spec := range []string{
+		"capacity",
+		"capacity=80",
+		"capacity=80,150",
+		"errors=-1,2",
+		"scrub-age=soon,later",
+		"temperature=40,50",
+	} {
+		if err := NewChecker().SetThreshold(spec); err == nil {
+			t.Errorf("SetThreshold(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestResultString(t *testing.T) {
+	now := time.Now()
+	result := newTestChecker(now).Check([]*zfs.Pool{testPool("tank", now, 24*time.Hour)})
+
+	want := "ZFS OK - 1 pool healthy | 'tank_capacity'=50%;80;90;0;100 'tank_errors'=0c;1;10;0 " +
+		"'tank_scrub_age'=86400s;3024000;6048000;0"
+	if got := result.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := newTestChecker(now).Check(nil); got.State != Unknown || !strings.Contains(got.Summary, "no pools") {
+		t.Errorf("empty check = %s, want UNKNOWN", got)
+	}
+}
@@ -0,0 +1,358 @@
+// Package config loads the viZFSulizer configuration file. The file is YAML
+// and is looked up in the XDG configuration directories; every setting has a
+// default so the file is optional, and command line flags override it.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// appName is the directory below the XDG configuration directories.
+const appName = "vizfsulizer"
+
+// fileName is the name of the configuration file.
+const fileName = "config.yaml"
+
+// Config is the complete application configuration.
+type Config struct {
+	// Source selects where ZFS state is collected from
+	Source SourceConfig `yaml:"source"`
+
+	// Disks configures how the physical disks of leaf VDevs are identified
+	Disks DisksConfig `yaml:"disks"`
+
+	// SMART configures reading the SMART health of disks
+	SMART SMARTConfig `yaml:"smart"`
+
+	// Lint configures the pool configuration linter
+	Lint LintConfig `yaml:"lint"`
+
+	// RefreshInterval is how often the TUI and web dashboard refresh
+	RefreshInterval Duration `yaml:"refresh_interval"`
+
+	// Theme is the name of the colour theme, built-in or from Themes
+	Theme string `yaml:"theme"`
+
+	// Themes holds user-defined colour themes, keyed by name
+	Themes map[string]ThemeConfig `yaml:"themes"`
+
+	// Color selects colour or black & white status display: auto, rgb or bw
+	Color DisplayMode `yaml:"color"`
+
+	// Charset selects Unicode or ASCII-only drawing: auto, unicode or ascii
+	Charset Charset `yaml:"charset"`
+
+	// ScreenReader renders the TUI as linear text with focus announcements
+	ScreenReader bool `yaml:"screen_reader"`
+
+	// Thresholds are the default health check thresholds, keyed by metric
+	Thresholds map[string]Threshold `yaml:"thresholds"`
+
+	// Keybindings maps TUI actions to the keys that trigger them
+	Keybindings map[string][]string `yaml:"keybindings"`
+
+	// Pools holds per-pool overrides, keyed by pool name
+	Pools map[string]PoolConfig `yaml:"pools"`
+
+	// Path is the file the configuration was loaded from, empty if none
+	Path string `yaml:"-"`
+}
+
+// SourceConfig selects and configures the data source.
+type SourceConfig struct {
+	// Type is the kind of source; "mock" serves built-in development data,
+	// "local" runs the ZFS commands on this host, "ssh" on Hosts, "remote"
+	// reads from an agent and "fixture" replays outputs recorded by
+	// "vizfsulizer capture"
+	Type string `yaml:"type"`
+
+	// Hosts are the ssh destinations of the ssh source, e.g. "root@nas1".
+	// The TUI shows several hosts as a fleet; other commands take one.
+	Hosts []string `yaml:"hosts"`
+
+	// SSH configures how the ssh source connects
+	SSH SSHConfig `yaml:"ssh"`
+
+	// Remote configures the agent the remote source reads from
+	Remote RemoteConfig `yaml:"remote"`
+
+	// Fixture is the directory the fixture source replays
+	Fixture string `yaml:"fixture"`
+}
+
+// RemoteConfig configures the remote source.
+type RemoteConfig struct {
+	// Address is the agent's "host:port"
+	Address string `yaml:"address"`
+
+	// TokenFile holds the agent's token; if empty, the token is read from
+	// the VIZFSULIZER_AGENT_TOKEN environment variable
+	TokenFile string `yaml:"token_file"`
+
+	// TLS connects with HTTPS, for agents serving a certificate
+	TLS bool `yaml:"tls"`
+}
+
+// DisksConfig configures the disk identities of leaf VDevs, read from
+// /dev/disk and sysfs.
+type DisksConfig struct {
+	// Root is the directory holding dev and sys. If empty, the disks of
+	// the local source are read from "/" and those of other sources are
+	// not resolved; set it to resolve the disks of a fixture from a copy
+	Root string `yaml:"root"`
+
+	// Label is the identity leaves are labelled by in the pool tree: name
+	// (as shown by zpool status), by-id, by-path, by-vdev, serial or wwn
+	Label string `yaml:"label"`
+
+	// SlotsPerRow is the number of slots per row of the enclosure map, as
+	// on the front of the chassis
+	SlotsPerRow int `yaml:"slots_per_row"`
+}
+
+// SMARTConfig configures reading the SMART health of disks with smartctl,
+// on the host of the local, ssh and fixture sources.
+type SMARTConfig struct {
+	// Enabled runs smartctl for every disk of the pools
+	Enabled bool `yaml:"enabled"`
+
+	// Interval is how often each disk is read again
+	Interval Duration `yaml:"interval"`
+
+	// Limits are the values from which a failure of the disk is predicted
+	Limits SMARTLimitsConfig `yaml:"limits"`
+}
+
+// SMARTLimitsConfig holds the SMART values from which a disk is expected
+// to fail. A limit of 0 is not checked.
+type SMARTLimitsConfig struct {
+	// Reallocated, Pending and MediaErrors are the lowest counts of
+	// reallocated sectors, pending sectors and media errors predicting a
+	// failure
+	Reallocated uint64 `yaml:"reallocated"`
+	Pending     uint64 `yaml:"pending"`
+	MediaErrors uint64 `yaml:"media_errors"`
+
+	// Temperature is the lowest temperature in °C that is too hot
+	Temperature int `yaml:"temperature"`
+}
+
+// LintConfig configures the pool configuration linter.
+type LintConfig struct {
+	// Capacity is the capacity in percent above which a pool is too full
+	Capacity float64 `yaml:"capacity"`
+}
+
+// SSHConfig configures the ssh source.
+type SSHConfig struct {
+	// Command is the ssh binary
+	Command string `yaml:"command"`
+
+	// Options are passed to ssh before the host
+	Options []string `yaml:"options"`
+
+	// Parallel is the most hosts collected at the same time
+	Parallel int `yaml:"parallel"`
+
+	// Timeout is the longest time collecting a host may take
+	Timeout Duration `yaml:"timeout"`
+}
+
+// Threshold holds the warning and critical values of a health check metric
+// in the metric's unit, e.g. "80%" for capacity or "5w" for scrub age.
+type Threshold struct {
+	Warning  string `yaml:"warning"`
+	Critical string `yaml:"critical"`
+}
+
+// PoolConfig overrides settings for a single pool.
+type PoolConfig struct {
+	// Ignore hides the pool from every front end, e.g. a known-degraded test pool
+	Ignore bool `yaml:"ignore"`
+
+	// Thresholds override the default health check thresholds for this pool
+	Thresholds map[string]Threshold `yaml:"thresholds"`
+}
+
+// Duration is a time.Duration read from strings such as "5s" or "2w".
+type Duration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := utils.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// Default returns the configuration used when no file exists. It watches
+// the pools of this host; the mock source must be selected explicitly, so
+// that checks and exporters never report made-up pools.
+//
+// Returns:
+//   - *Config: The default configuration
+func Default() *Config {
+	return &Config{
+		Source: SourceConfig{
+			Type: "local",
+			SSH: SSHConfig{
+				Command:  "ssh",
+				Options:  []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"},
+				Parallel: 8,
+				Timeout:  Duration(30 * time.Second),
+			},
+		},
+		Disks: DisksConfig{Label: "name", SlotsPerRow: 4},
+		SMART: SMARTConfig{
+			Enabled:  true,
+			Interval: Duration(30 * time.Minute),
+			Limits:   SMARTLimitsConfig(status.DefaultSMARTLimits),
+		},
+		Lint:            LintConfig{Capacity: status.DefaultLintCapacity},
+		RefreshInterval: Duration(5 * time.Second),
+		Theme:           "default",
+		Themes:          map[string]ThemeConfig{},
+		Color:           DisplayModeAuto,
+		Charset:         CharsetAuto,
+		Thresholds:      map[string]Threshold{},
+		Keybindings:     DefaultKeybindings(),
+		Pools:           map[string]PoolConfig{},
+	}
+}
+
+// Paths returns the candidate configuration files in lookup order:
+// $XDG_CONFIG_HOME (default ~/.config) followed by each of
+// $XDG_CONFIG_DIRS (default /etc/xdg).
+//
+// Returns:
+//   - []string: Absolute paths of possible configuration files
+func Paths() []string {
+	var dirs []string
+	if home := os.Getenv("XDG_CONFIG_HOME"); home != "" {
+		dirs = append(dirs, home)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+
+	system := os.Getenv("XDG_CONFIG_DIRS")
+	if system == "" {
+		system = "/etc/xdg"
+	}
+	for _, dir := range filepath.SplitList(system) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	paths := make([]string, len(dirs))
+	for i, dir := range dirs {
+		paths[i] = filepath.Join(dir, appName, fileName)
+	}
+	return paths
+}
+
+// Load reads the configuration. An explicit path must exist; with an empty
+// path the first existing file of Paths is used, and the defaults if there
+// is none.
+//
+// Parameters:
+//   - path: Configuration file to read, or empty to search the XDG paths
+//
+// Returns:
+//   - *Config: The loaded configuration, merged over the defaults
+//   - error: Error if the file cannot be read or parsed
+func Load(path string) (*Config, error) {
+	if path == "" {
+		for _, candidate := range Paths() {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+		if path == "" {
+			return Default(), nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+	return cfg, nil
+}
+
+// Parse decodes YAML configuration over the defaults. Unknown keys are
+// rejected so that typos do not silently fall back to defaults. Values are
+// not validated; call Validate for that.
+//
+// Parameters:
+//   - data: The YAML document
+//
+// Returns:
+//   - *Config: The decoded configuration
+//   - error: Error if the document is malformed or has unknown keys
+func Parse(data []byte) (*Config, error) {
+	cfg := Default()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// IgnoredPools returns the names of the pools configured to be ignored.
+//
+// Returns:
+//   - []string: Names of ignored pools
+func (c *Config) IgnoredPools() []string {
+	var names []string
+	for name, pool := range c.Pools {
+		if pool.Ignore {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Analyzer returns a status analyzer applying the configured SMART limits
+// and lint capacity, so that every front end judges pools alike.
+//
+// Returns:
+//   - *status.Analyzer: An analyzer ready for use
+func (c *Config) Analyzer() *status.Analyzer {
+	limits := status.SMARTLimits(c.SMART.Limits)
+	return &status.Analyzer{SMART: &limits, LintCapacity: c.Lint.Capacity}
+}
+
+// String describes where the configuration came from.
+func (c *Config) String() string {
+	if c.Path == "" {
+		return "built-in defaults (no file found in " + strings.Join(Paths(), ", ") + ")"
+	}
+	return c.Path
+}
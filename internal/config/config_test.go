@@ -0,0 +1,226 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `
+refresh_interval: 30s
+smart:
+  limits:
+    reallocated: 8
+    temperature: 0
+lint:
+  capacity: 90
+thresholds:
+  capacity:
+    warning: 85%
+    critical: 95%
+keybindings:
+  quit: [x]
+pools:
+  scratch:
+    ignore: true
+  backup:
+    thresholds:
+      scrub-age:
+        warning: 8w
+`
+
+func TestParseMergesOverDefaults(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("sample invalid: %v", err)
+	}
+
+	if time.Duration(cfg.RefreshInterval) != 30*time.Second {
+		t.Errorf("refresh_interval = %v", time.Duration(cfg.RefreshInterval))
+	}
+	if cfg.Source.Type != "local" || cfg.Theme != "default" {
+		t.Errorf("defaults lost: %+v", cfg)
+	}
+	if got := cfg.Keybindings[ActionQuit]; len(got) != 1 || got[0] != "x" {
+		t.Errorf("quit keys = %v, want [x]", got)
+	}
+	if got := cfg.Keybindings[ActionNextPool]; len(got) == 0 {
+		t.Error("unconfigured keybindings lost their defaults")
+	}
+	if ignored := cfg.IgnoredPools(); len(ignored) != 1 || ignored[0] != "scratch" {
+		t.Errorf("ignored pools = %v", ignored)
+	}
+
+	// The analyzer applies the configured limits and keeps the others
+	analyzer := cfg.Analyzer()
+	if got := *analyzer.SMART; got.Reallocated != 8 || got.Pending != 1 || got.Temperature != 0 {
+		t.Errorf("SMART limits = %+v", got)
+	}
+	if analyzer.LintCapacity != 90 {
+		t.Errorf("lint capacity = %v, want 90", analyzer.LintCapacity)
+	}
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	if _, err := Parse([]byte("refresh_intervall: 5s\n")); err == nil {
+		t.Error("misspelled key accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := Parse([]byte(`
+source:
+  type: carrier-pigeon
+theme: neon
+themes:
+  dark:
+    colors:
+      online: "#00ff00"
+  mine:
+    base: neon
+    colors:
+      sparkle: "1"
+      faulted: "#ff00zz"
+color: sepia
+disks:
+  label: barcode
+  slots_per_row: 0
+smart:
+  interval: 0s
+  limits:
+    temperature: -1
+lint:
+  capacity: 120
+thresholds:
+  capacity:
+    warning: 120%
+keybindings:
+  dance: [d]
+  next_pool: [q]
+  page_down: [esc]
+  page_up: [t]
+pools:
+  tank:
+    thresholds:
+      temperature:
+        warning: "40"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid configuration accepted")
+	}
+	for _, want := range []string{
+		"source.type", `theme: unknown theme "neon"`, "themes.dark: name is taken",
+		"themes.mine.base", `unknown colour "sparkle"`, "themes.mine.colors.faulted",
+		"color", `disks.label: unknown label "barcode"`, "disks.slots_per_row", "smart.interval", "smart.limits.temperature", "lint.capacity", "thresholds.capacity", `unknown action "dance"`,
+		`key "q" bound to both`, `key "esc" bound to both close and page_down`, `key "t" bound to both next_theme and page_up`, "pools.tank.thresholds.temperature",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("errors missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateSSH(t *testing.T) {
+	cfg, err := Parse([]byte("source:\n  type: ssh\n  hosts: [nas1, root@nas2]\n  ssh:\n    parallel: 4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("ssh source invalid: %v", err)
+	}
+	if cfg.Source.SSH.Command != "ssh" || time.Duration(cfg.Source.SSH.Timeout) != 30*time.Second || cfg.Source.SSH.Parallel != 4 {
+		t.Errorf("ssh settings not merged over defaults: %+v", cfg.Source.SSH)
+	}
+
+	cfg.Source.Hosts = []string{"nas1", "nas1"}
+	cfg.Source.SSH.Parallel = 0
+	err = cfg.Validate()
+	for _, want := range []string{`duplicate host "nas1"`, "source.ssh.parallel"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("errors missing %q: %v", want, err)
+		}
+	}
+
+	cfg.Source.Hosts = nil
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "at least one host") {
+		t.Errorf("ssh source without hosts: %v", err)
+	}
+}
+
+func TestValidateRemote(t *testing.T) {
+	cfg, err := Parse([]byte("source:\n  type: remote\n  remote:\n    address: nas1:9723\n    tls: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("remote source invalid: %v", err)
+	}
+	cfg.Source.Remote.Address = "nas1"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "source.remote.address") {
+		t.Errorf("address without port: %v", err)
+	}
+}
+
+func TestLoadSearchesXDGPaths(t *testing.T) {
+	home, system := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", system)
+
+	cfg, err := Load("")
+	if err != nil || cfg.Path != "" {
+		t.Fatalf("Load without files = %v, %v; want defaults", cfg.Path, err)
+	}
+
+	systemFile := filepath.Join(system, appName, fileName)
+	os.MkdirAll(filepath.Dir(systemFile), 0o755)
+	os.WriteFile(systemFile, []byte("theme: default\n"), 0o644)
+	if cfg, _ := Load(""); cfg.Path != systemFile {
+		t.Errorf("Path = %q, want system file %q", cfg.Path, systemFile)
+	}
+
+	userFile := filepath.Join(home, appName, fileName)
+	os.MkdirAll(filepath.Dir(userFile), 0o755)
+	os.WriteFile(userFile, []byte("refresh_interval: 1m\n"), 0o644)
+	if cfg, _ := Load(""); cfg.Path != userFile {
+		t.Errorf("Path = %q, want user file %q to take precedence", cfg.Path, userFile)
+	}
+
+	if _, err := Load(filepath.Join(home, "missing.yaml")); err == nil {
+		t.Error("missing explicit file accepted")
+	}
+}
+
+func TestCharsetResolve(t *testing.T) {
+	tests := []struct {
+		lcAll, lcCtype, lang string
+		want                 Charset
+	}{
+		{"", "", "en_US.UTF-8", CharsetUnicode},
+		{"", "", "de_DE.utf8", CharsetUnicode},
+		{"C", "", "en_US.UTF-8", CharsetASCII},
+		{"", "en_GB.ISO-8859-1", "en_US.UTF-8", CharsetASCII},
+		{"", "", "POSIX", CharsetASCII},
+		{"", "", "", CharsetASCII},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_CTYPE", tt.lcCtype)
+		t.Setenv("LANG", tt.lang)
+		if got := CharsetAuto.Resolve(); got != tt.want {
+			t.Errorf("LC_ALL=%q LC_CTYPE=%q LANG=%q: got %s, want %s", tt.lcAll, tt.lcCtype, tt.lang, got, tt.want)
+		}
+	}
+	if got := CharsetUnicode.Resolve(); got != CharsetUnicode {
+		t.Errorf("explicit unicode resolved to %s", got)
+	}
+}
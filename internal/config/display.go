@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DisplayMode selects how the TUI encodes status: with colour, or with
+// border styles and text markers for monochrome terminals and users who
+// cannot distinguish the status colours.
+type DisplayMode string
+
+// Display modes.
+const (
+	// DisplayModeAuto uses RGB unless the NO_COLOR environment variable is set
+	DisplayModeAuto DisplayMode = "auto"
+
+	// DisplayModeRGB encodes status with colour
+	DisplayModeRGB DisplayMode = "rgb"
+
+	// DisplayModeBW encodes status with border styles and text markers only
+	DisplayModeBW DisplayMode = "bw"
+)
+
+// ParseDisplayMode parses a display mode name.
+//
+// Parameters:
+//   - s: "auto", "rgb" or "bw"
+//
+// Returns:
+//   - DisplayMode: The parsed mode
+//   - error: Error if the name is unknown
+func ParseDisplayMode(s string) (DisplayMode, error) {
+	switch mode := DisplayMode(s); mode {
+	case DisplayModeAuto, DisplayModeRGB, DisplayModeBW:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown color mode %q (supported: auto, rgb, bw)", s)
+	}
+}
+
+// Resolve turns DisplayModeAuto into a concrete mode, honouring the
+// NO_COLOR convention (https://no-color.org): any non-empty value selects
+// black & white. Explicit modes are returned unchanged.
+//
+// Returns:
+//   - DisplayMode: DisplayModeRGB or DisplayModeBW
+func (m DisplayMode) Resolve() DisplayMode {
+	if m != DisplayModeAuto && m != "" {
+		return m
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return DisplayModeBW
+	}
+	return DisplayModeRGB
+}
+
+// Charset selects the characters used for borders, tree branches, bars and
+// sparklines.
+type Charset string
+
+// Character sets.
+const (
+	// CharsetAuto uses Unicode if the locale's character encoding is UTF-8
+	CharsetAuto Charset = "auto"
+
+	// CharsetUnicode uses box-drawing and block characters
+	CharsetUnicode Charset = "unicode"
+
+	// CharsetASCII uses printable ASCII only, for serial consoles, IPMI KVMs
+	// and other terminals without Unicode support
+	CharsetASCII Charset = "ascii"
+)
+
+// ParseCharset parses a character set name.
+//
+// Parameters:
+//   - s: "auto", "unicode" or "ascii"
+//
+// Returns:
+//   - Charset: The parsed character set
+//   - error: Error if the name is unknown
+func ParseCharset(s string) (Charset, error) {
+	switch charset := Charset(s); charset {
+	case CharsetAuto, CharsetUnicode, CharsetASCII:
+		return charset, nil
+	default:
+		return "", fmt.Errorf("unknown charset %q (supported: auto, unicode, ascii)", s)
+	}
+}
+
+// Resolve turns CharsetAuto into a concrete character set from the locale:
+// the first of LC_ALL, LC_CTYPE and LANG that is set decides, and Unicode is
+// used only if it names a UTF-8 encoding (e.g. "en_US.UTF-8"). Without any
+// locale, as with LANG=C, ASCII is used. Explicit character sets are
+// returned unchanged.
+//
+// Returns:
+//   - Charset: CharsetUnicode or CharsetASCII
+func (c Charset) Resolve() Charset {
+	if c != CharsetAuto && c != "" {
+		return c
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			if strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8") {
+				return CharsetUnicode
+			}
+			return CharsetASCII
+		}
+	}
+	return CharsetASCII
+}
@@ -0,0 +1,126 @@
+package config
+
+// TUI actions that can be bound to keys.
+const (
+	ActionQuit      = "quit"
+	ActionNextPool  = "next_pool"
+	ActionPrevPool  = "prev_pool"
+	ActionNextItem  = "next_item"
+	ActionPrevItem  = "prev_item"
+	ActionToggle    = "toggle"
+	ActionNextTheme = "next_theme"
+	ActionHelp      = "help"
+	ActionNextPane  = "next_pane"
+	ActionZoom      = "zoom"
+	ActionWider     = "wider"
+	ActionNarrower  = "narrower"
+	ActionTaller    = "taller"
+	ActionShorter   = "shorter"
+	ActionBack      = "back"
+
+	ActionEventClass = "event_class"
+	ActionEventPool  = "event_pool"
+	ActionHistory    = "history"
+	ActionDiskLabel  = "disk_label"
+	ActionEnclosures = "enclosures"
+	ActionLint       = "lint"
+	ActionPlanner    = "planner"
+
+	ActionPageUp   = "page_up"
+	ActionPageDown = "page_down"
+	ActionTop      = "top"
+	ActionBottom   = "bottom"
+	ActionClose    = "close"
+
+	ActionInternalEntries = "internal_entries"
+	ActionClearSearch     = "clear_search"
+
+	ActionIncrease      = "increase"
+	ActionDecrease      = "decrease"
+	ActionPlannerAdd    = "planner_add"
+	ActionPlannerRemove = "planner_remove"
+	ActionPlannerReset  = "planner_reset"
+	ActionPlannerNew    = "planner_new"
+)
+
+// Actions lists every action that can be bound to keys, in display order.
+var Actions = []string{
+	ActionNextPool, ActionPrevPool, ActionNextItem, ActionPrevItem, ActionToggle, ActionBack,
+	ActionNextPane, ActionZoom, ActionWider, ActionNarrower, ActionTaller, ActionShorter,
+	ActionEventClass, ActionEventPool, ActionHistory, ActionDiskLabel, ActionEnclosures, ActionLint, ActionPlanner, ActionNextTheme, ActionHelp, ActionQuit,
+	ActionPageUp, ActionPageDown, ActionTop, ActionBottom, ActionClose, ActionInternalEntries, ActionClearSearch,
+	ActionIncrease, ActionDecrease, ActionPlannerAdd, ActionPlannerRemove, ActionPlannerReset, ActionPlannerNew,
+}
+
+// OverlayActions lists the actions of the overlays covering the screen,
+// such as the help and the enclosure map. The overlays are modal, so their
+// keys may repeat those of the main view and take precedence there. In the
+// history, printable keys type into the search instead. The page keys
+// scroll the topology pane of the main view as well, so their keys must
+// differ from those of both.
+var OverlayActions = []string{
+	ActionPageUp, ActionPageDown, ActionTop, ActionBottom, ActionClose, ActionInternalEntries, ActionClearSearch,
+	ActionIncrease, ActionDecrease, ActionPlannerAdd, ActionPlannerRemove, ActionPlannerReset, ActionPlannerNew,
+}
+
+// DefaultKeybindings returns the keys bound to each action when the
+// configuration file does not override them. Key names follow Bubble Tea,
+// e.g. "ctrl+c", "shift+tab" or "left".
+//
+// Returns:
+//   - map[string][]string: Keys per action
+func DefaultKeybindings() map[string][]string {
+	return map[string][]string{
+		ActionQuit:      {"q", "ctrl+c"},
+		ActionNextPool:  {"tab", "right", "l"},
+		ActionPrevPool:  {"shift+tab", "left", "h"},
+		ActionNextItem:  {"down", "j"},
+		ActionPrevItem:  {"up", "k"},
+		ActionToggle:    {"enter"},
+		ActionNextTheme: {"t"},
+		ActionHelp:      {"?"},
+		ActionNextPane:  {"w"},
+		ActionZoom:      {"z"},
+		ActionWider:     {"]"},
+		ActionNarrower:  {"["},
+		ActionTaller:    {"}"},
+		ActionShorter:   {"{"},
+		ActionBack:      {"esc"},
+
+		ActionEventClass: {"c"},
+		ActionEventPool:  {"p"},
+		ActionHistory:    {"H"},
+		ActionDiskLabel:  {"L"},
+		ActionEnclosures: {"E"},
+		ActionLint:       {"A"},
+		ActionPlanner:    {"C"},
+
+		ActionPageUp:   {"pgup"},
+		ActionPageDown: {"pgdown"},
+		ActionTop:      {"home"},
+		ActionBottom:   {"end"},
+		ActionClose:    {"esc"},
+
+		ActionInternalEntries: {"tab"},
+		ActionClearSearch:     {"ctrl+u"},
+
+		ActionIncrease:      {"right", "l", "+", "="},
+		ActionDecrease:      {"left", "h", "-"},
+		ActionPlannerAdd:    {"a"},
+		ActionPlannerRemove: {"x"},
+		ActionPlannerReset:  {"r"},
+		ActionPlannerNew:    {"n"},
+	}
+}
+
+// keyScopes returns the scopes an action's keys must be unique in: the
+// main view, the overlays, or both for the page keys.
+func keyScopes(action string) []string {
+	switch {
+	case action == ActionPageUp || action == ActionPageDown:
+		return []string{"main view", "overlays"}
+	case contains(OverlayActions, action):
+		return []string{"overlays"}
+	}
+	return []string{"main view"}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Theme colour roles that user-defined themes can set.
+const (
+	ColorOnline      = "online"
+	ColorDegraded    = "degraded"
+	ColorFaulted     = "faulted"
+	ColorPoolName    = "pool_name"
+	ColorVDevType    = "vdev_type"
+	ColorTree        = "tree"
+	ColorPoolBorder  = "pool_border"
+	ColorVDevBorder  = "vdev_border"
+	ColorSelectedFg  = "selected_fg"
+	ColorSelectedBg  = "selected_bg"
+	ColorTitle       = "title"
+	ColorHelp        = "help"
+	ColorTabActiveFg = "tab_active_fg"
+	ColorTabActiveBg = "tab_active_bg"
+	ColorTabInactive = "tab_inactive"
+)
+
+// ThemeColors lists every colour role, in display order.
+var ThemeColors = []string{
+	ColorOnline, ColorDegraded, ColorFaulted,
+	ColorPoolName, ColorVDevType, ColorTree,
+	ColorPoolBorder, ColorVDevBorder,
+	ColorSelectedFg, ColorSelectedBg,
+	ColorTitle, ColorHelp,
+	ColorTabActiveFg, ColorTabActiveBg, ColorTabInactive,
+}
+
+// ThemeConfig defines a user theme as a built-in theme with some colours
+// replaced.
+type ThemeConfig struct {
+	// Base is the built-in theme the colours are taken from (default: "default")
+	Base string `yaml:"base"`
+
+	// Colors maps colour roles to ANSI colour numbers ("0"-"255") or
+	// true-colour hex values ("#rrggbb" or "#rgb")
+	Colors map[string]string `yaml:"colors"`
+}
+
+// hexColor matches true-colour values.
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ParseColor checks a colour value.
+//
+// Parameters:
+//   - s: An ANSI colour number from "0" to "255", or a hex value such as "#268bd2"
+//
+// Returns:
+//   - error: Error if s is not a valid colour
+func ParseColor(s string) error {
+	if hexColor.MatchString(s) {
+		return nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	return fmt.Errorf("invalid colour %q (want an ANSI number 0-255 or #rrggbb)", s)
+}
+
+// ThemeNames returns the built-in themes followed by the user-defined
+// themes, in the order the TUI cycles through them.
+//
+// Returns:
+//   - []string: Names of all selectable themes
+func (c *Config) ThemeNames() []string {
+	names := append([]string{}, Themes...)
+	for _, name := range sortedKeys(c.Themes) {
+		if !contains(Themes, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validateThemes checks the user-defined themes.
+func validateThemes(themes map[string]ThemeConfig) []error {
+	var errs []error
+	for _, name := range sortedKeys(themes) {
+		t := themes[name]
+		section := "themes." + name
+		if contains(Themes, name) {
+			errs = append(errs, fmt.Errorf("%s: name is taken by a built-in theme", section))
+		}
+		if t.Base != "" && !contains(Themes, t.Base) {
+			errs = append(errs, fmt.Errorf("%s.base: unknown theme %q (supported: %v)", section, t.Base, Themes))
+		}
+		for _, role := range sortedKeys(t.Colors) {
+			if !contains(ThemeColors, role) {
+				errs = append(errs, fmt.Errorf("%s.colors: unknown colour %q (supported: %v)", section, role, ThemeColors))
+			} else if err := ParseColor(t.Colors[role]); err != nil {
+				errs = append(errs, fmt.Errorf("%s.colors.%s: %w", section, role, err))
+			}
+		}
+	}
+	return errs
+}
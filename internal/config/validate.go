@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/petecog/vizfsulizer/internal/check"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// SourceTypes lists the supported data source types.
+var SourceTypes = []string{"mock", "local", "ssh", "remote", "fixture"}
+
+// Themes lists the built-in colour themes. More can be defined in the
+// themes section of the configuration file.
+var Themes = []string{"default", "dark", "light", "solarized", "deuteranopia", "protanopia"}
+
+// Validate checks every setting and reports all problems at once.
+//
+// Returns:
+//   - error: All problems found joined together, or nil if the
+//     configuration is valid
+func (c *Config) Validate() error {
+	var errs []error
+
+	if !contains(SourceTypes, c.Source.Type) {
+		errs = append(errs, fmt.Errorf("source.type: unknown type %q (supported: %v)", c.Source.Type, SourceTypes))
+	}
+	if c.Source.Type == "ssh" {
+		errs = append(errs, validateSSH(c.Source)...)
+	}
+	if c.Source.Type == "remote" {
+		if _, _, err := net.SplitHostPort(c.Source.Remote.Address); err != nil {
+			errs = append(errs, fmt.Errorf("source.remote.address: %w", err))
+		}
+	}
+	if c.Source.Type == "fixture" && c.Source.Fixture == "" {
+		errs = append(errs, errors.New("source.fixture: the fixture source needs a directory"))
+	}
+	if !contains(zfs.DiskLabels, c.Disks.Label) {
+		errs = append(errs, fmt.Errorf("disks.label: unknown label %q (supported: %v)", c.Disks.Label, zfs.DiskLabels))
+	}
+	if c.Disks.SlotsPerRow <= 0 {
+		errs = append(errs, errors.New("disks.slots_per_row: must be positive"))
+	}
+	if c.SMART.Enabled && c.SMART.Interval <= 0 {
+		errs = append(errs, errors.New("smart.interval: must be positive"))
+	}
+	if c.SMART.Limits.Temperature < 0 {
+		errs = append(errs, errors.New("smart.limits.temperature: must not be negative"))
+	}
+	if c.Lint.Capacity <= 0 || c.Lint.Capacity > 100 {
+		errs = append(errs, errors.New("lint.capacity: must be above 0 and at most 100"))
+	}
+	if c.RefreshInterval <= 0 {
+		errs = append(errs, errors.New("refresh_interval: must be positive"))
+	}
+	if names := c.ThemeNames(); !contains(names, c.Theme) {
+		errs = append(errs, fmt.Errorf("theme: unknown theme %q (supported: %v)", c.Theme, names))
+	}
+	errs = append(errs, validateThemes(c.Themes)...)
+
+	if _, err := ParseDisplayMode(string(c.Color)); err != nil {
+		errs = append(errs, fmt.Errorf("color: %w", err))
+	}
+	if _, err := ParseCharset(string(c.Charset)); err != nil {
+		errs = append(errs, fmt.Errorf("charset: %w", err))
+	}
+
+	errs = append(errs, validateThresholds("thresholds", c.Thresholds)...)
+
+	// Keys must be unique within the main view and within the overlays
+	bound := map[string]map[string]string{"main view": {}, "overlays": {}}
+	for _, action := range sortedKeys(c.Keybindings) {
+		if !contains(Actions, action) {
+			errs = append(errs, fmt.Errorf("keybindings: unknown action %q (supported: %v)", action, Actions))
+			continue
+		}
+		for _, key := range c.Keybindings[action] {
+			var others []string // Actions bound to the key in any scope
+			for _, scope := range keyScopes(action) {
+				if other, ok := bound[scope][key]; ok && !contains(others, other) {
+					others = append(others, other)
+				}
+				bound[scope][key] = action
+			}
+			for _, other := range others {
+				errs = append(errs, fmt.Errorf("keybindings: key %q bound to both %s and %s", key, other, action))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(c.Pools) {
+		errs = append(errs, validateThresholds("pools."+name+".thresholds", c.Pools[name].Thresholds)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateSSH checks the settings of the ssh source.
+func validateSSH(src SourceConfig) []error {
+	var errs []error
+	if len(src.Hosts) == 0 {
+		errs = append(errs, errors.New("source.hosts: the ssh source needs at least one host"))
+	}
+	seen := make(map[string]bool)
+	for _, host := range src.Hosts {
+		if host == "" || seen[host] {
+			errs = append(errs, fmt.Errorf("source.hosts: empty or duplicate host %q", host))
+		}
+		seen[host] = true
+	}
+	if src.SSH.Command == "" {
+		errs = append(errs, errors.New("source.ssh.command: must not be empty"))
+	}
+	if src.SSH.Parallel <= 0 {
+		errs = append(errs, errors.New("source.ssh.parallel: must be positive"))
+	}
+	if src.SSH.Timeout <= 0 {
+		errs = append(errs, errors.New("source.ssh.timeout: must be positive"))
+	}
+	return errs
+}
+
+// validateThresholds parses every threshold of a section.
+func validateThresholds(section string, thresholds map[string]Threshold) []error {
+	var errs []error
+	for _, metric := range sortedKeys(thresholds) {
+		t := thresholds[metric]
+		if _, err := check.ParseLevel(check.Metric(metric), t.Warning, t.Critical); err != nil {
+			errs = append(errs, fmt.Errorf("%s.%s: %w", section, metric, err))
+		}
+	}
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a map in order, for stable error messages.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+// Package disk resolves the devices of leaf VDevs to the physical disks
+// behind them: their /dev/disk aliases and the model, serial number and
+// WWN the kernel reports in sysfs. The device tree is read below a
+// configurable root, so that tests and fixtures can use a fake one.
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// ErrNotDisk is returned by Resolve for names that are not block devices,
+// such as file VDevs.
+var ErrNotDisk = errors.New("not a disk")
+
+// Resolver reads disk identities from the device tree of a host.
+type Resolver struct {
+	// Root is the directory holding dev and sys, "/" for this host
+	Root string
+}
+
+// NewResolver creates a Resolver.
+//
+// Parameters:
+//   - root: The directory holding dev and sys; empty for "/"
+//
+// Returns:
+//   - *Resolver: A resolver ready for use
+//
+// Example:
+//
+//	r := disk.NewResolver("")
+//	id, err := r.Resolve("sda")
+//	if err == nil {
+//	    fmt.Println(id.Model, id.Serial)
+//	}
+func NewResolver(root string) *Resolver {
+	if root == "" {
+		root = "/"
+	}
+	return &Resolver{Root: root}
+}
+
+// Resolve finds the disk of a VDev device.
+//
+// Parameters:
+//   - name: The VDev name or path as shown by zpool status, e.g. "sda",
+//     "/dev/sda1", "ata-WDC_WD80EFAX-68KNBN0_VAGK1234-part1" or a
+//     vdev_id.conf alias such as "A14"; partitions resolve to their disk
+//
+// Returns:
+//   - *zfs.DiskIdentity: The identity of the whole disk
+//   - error: Error wrapping ErrNotDisk if name is not a block device
+func (r *Resolver) Resolve(name string) (*zfs.DiskIdentity, error) {
+	node := r.node(name)
+	if node == "" {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotDisk)
+	}
+	dev := r.wholeDisk(filepath.Base(node))
+	if dev == "" {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotDisk)
+	}
+
+	id := &zfs.DiskIdentity{
+		Device:     dev,
+		Model:      r.attr(dev, "device/model"),
+		Serial:     r.attr(dev, "device/serial"),
+		Rotational: r.attr(dev, "queue/rotational") == "1",
+	}
+	if sectors, err := strconv.ParseUint(r.attr(dev, "size"), 10, 64); err == nil {
+		id.Size = sectors * 512 // sysfs counts 512-byte sectors whatever the disk's
+	}
+	id.ByID, id.ByPath, id.ByVDev = r.aliases(dev, "by-id"), r.aliases(dev, "by-path"), r.aliases(dev, "by-vdev")
+
+	for _, alias := range id.ByID {
+		switch wwn, isWWN := strings.CutPrefix(alias, "wwn-"); {
+		case isWWN && id.WWN == "":
+			id.WWN = wwn
+		case !isWWN && id.Serial == "" && strings.Contains(alias, "_"):
+			// ATA disks have no serial attribute; udev names them
+			// <bus>-<model>_<serial>
+			id.Serial = alias[strings.LastIndex(alias, "_")+1:]
+		}
+	}
+	if id.WWN == "" {
+		if id.WWN = r.attr(dev, "wwid"); id.WWN == "" {
+			id.WWN = r.attr(dev, "device/wwid")
+		}
+	}
+	return id, nil
+}
+
+// node returns the device node a name refers to, with links followed, or
+// empty if there is none.
+func (r *Resolver) node(name string) string {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = []string{filepath.Join("dev", name)}
+		for _, dir := range []string{"by-vdev", "by-id", "by-path"} {
+			candidates = append(candidates, filepath.Join("dev/disk", dir, name))
+		}
+	}
+	for _, candidate := range candidates {
+		if node, err := filepath.EvalSymlinks(filepath.Join(r.Root, candidate)); err == nil {
+			return node
+		}
+	}
+	return ""
+}
+
+// wholeDisk returns the kernel name of the disk holding a block device:
+// the device itself, or the disk a partition belongs to. sysfs lists
+// partitions below their disk, e.g. /sys/block/sda/sda1. It returns empty
+// if the device is not a block device.
+func (r *Resolver) wholeDisk(kname string) string {
+	block := filepath.Join(r.Root, "sys/block")
+	if _, err := os.Stat(filepath.Join(block, kname)); err == nil {
+		return kname
+	}
+	if matches, _ := filepath.Glob(filepath.Join(block, "*", kname)); len(matches) > 0 {
+		return filepath.Base(filepath.Dir(matches[0]))
+	}
+	return ""
+}
+
+// attr reads a sysfs attribute of a disk, trimmed, or returns empty if it
+// does not exist.
+func (r *Resolver) attr(dev, name string) string {
+	return readAttr(filepath.Join(r.Root, "sys/block", dev), name)
+}
+
+// aliases returns the names of the links to a whole disk in a directory
+// of /dev/disk. Names of model and serial sort before WWN and EUI names,
+// which are hard to read out.
+func (r *Resolver) aliases(dev, dir string) []string {
+	dir = filepath.Join(r.Root, "dev/disk", dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		target, err := filepath.EvalSymlinks(filepath.Join(dir, entry.Name()))
+		if err == nil && filepath.Base(target) == dev {
+			names = append(names, entry.Name())
+		}
+	}
+	opaque := func(name string) bool {
+		return strings.HasPrefix(name, "wwn-") || strings.HasPrefix(name, "nvme-eui.")
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if opaque(names[i]) != opaque(names[j]) {
+			return !opaque(names[i])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
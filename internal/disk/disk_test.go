@@ -0,0 +1,159 @@
+package disk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// fakeRoot builds a device tree with an ATA disk with a partition, an NVMe
+// disk and a vdev_id.conf alias, as udev and the kernel lay them out.
+func fakeRoot(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		"dev/sda":                               "",
+		"dev/sda1":                              "",
+		"dev/nvme0n1":                           "",
+		"dev/nvme0n1p1":                         "",
+		"sys/block/sda/size":                    "15628053168\n",
+		"sys/block/sda/queue/rotational":        "1\n",
+		"sys/block/sda/device/model":            "WDC WD80EFAX-68K\n",
+		"sys/block/sda/device/wwid":             "naa.5000cca252c8d1a2\n",
+		"sys/block/sda/sda1/partition":          "1\n",
+		"sys/block/nvme0n1/size":                "1953525168\n",
+		"sys/block/nvme0n1/queue/rotational":    "0\n",
+		"sys/block/nvme0n1/device/model":        "Samsung SSD 980 PRO 1TB                 \n",
+		"sys/block/nvme0n1/device/serial":       "S5GXNF0R123456A     \n",
+		"sys/block/nvme0n1/wwid":                "eui.002538b111b2a3c4\n",
+		"sys/block/nvme0n1/nvme0n1p1/partition": "1\n",
+
+		// Two backplanes, the second one listed first by name
+		"sys/class/enclosure/0:0:20:0/id":                           "0x5003048001a2b37f\n",
+		"sys/class/enclosure/0:0:20:0/device/vendor":                "LSI     \n",
+		"sys/class/enclosure/0:0:20:0/device/model":                 "SAS2X36         \n",
+		"sys/class/enclosure/0:0:20:0/Slot 01/type":                 "array device\n",
+		"sys/class/enclosure/0:0:20:0/Slot 01/slot":                 "1\n",
+		"sys/class/enclosure/0:0:20:0/Slot 01/status":               "Not Installed\n",
+		"sys/class/enclosure/0:0:20:0/Slot 00/type":                 "array device\n",
+		"sys/class/enclosure/0:0:20:0/Slot 00/slot":                 "0\n",
+		"sys/class/enclosure/0:0:20:0/Slot 00/status":               "OK\n",
+		"sys/class/enclosure/0:0:20:0/Slot 00/fault":                "1\n",
+		"sys/class/enclosure/0:0:20:0/Slot 00/device/block/sdq/dev": "65:0\n",
+		"sys/class/enclosure/0:0:20:0/Fan 1/type":                   "cooling\n",
+		"sys/class/enclosure/0:0:9:0/DISK07/type":                   "device\n",
+		"sys/class/enclosure/0:0:9:0/DISK07/device/block/sda/dev":   "8:0\n",
+	}
+	links := map[string]string{
+		"dev/disk/by-id/ata-WDC_WD80EFAX-68KNBN0_VAGK1234":            "../../sda",
+		"dev/disk/by-id/ata-WDC_WD80EFAX-68KNBN0_VAGK1234-part1":      "../../sda1",
+		"dev/disk/by-id/wwn-0x5000cca252c8d1a2":                       "../../sda",
+		"dev/disk/by-id/nvme-Samsung_SSD_980_PRO_1TB_S5GXNF0R123456A": "../../nvme0n1",
+		"dev/disk/by-id/nvme-eui.002538b111b2a3c4":                    "../../nvme0n1",
+		"dev/disk/by-path/pci-0000:00:17.0-ata-1":                     "../../sda",
+		"dev/disk/by-path/pci-0000:00:17.0-ata-1-part1":               "../../sda1",
+		"dev/disk/by-vdev/A14":                                        "../../sda",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, target := range links {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestResolve(t *testing.T) {
+	r := NewResolver(fakeRoot(t))
+
+	ata := &zfs.DiskIdentity{
+		Device:     "sda",
+		ByID:       []string{"ata-WDC_WD80EFAX-68KNBN0_VAGK1234", "wwn-0x5000cca252c8d1a2"},
+		ByPath:     []string{"pci-0000:00:17.0-ata-1"},
+		ByVDev:     []string{"A14"},
+		Model:      "WDC WD80EFAX-68K",
+		Serial:     "VAGK1234",
+		WWN:        "0x5000cca252c8d1a2",
+		Rotational: true,
+		Size:       15628053168 * 512,
+	}
+	// Every name zpool status may show for the disk or its partition
+	for _, name := range []string{"sda", "sda1", "/dev/sda1", "A14", "ata-WDC_WD80EFAX-68KNBN0_VAGK1234-part1", "pci-0000:00:17.0-ata-1"} {
+		id, err := r.Resolve(name)
+		if err != nil || !reflect.DeepEqual(id, ata) {
+			t.Errorf("Resolve(%q) = %+v, %v, want %+v", name, id, err, ata)
+		}
+	}
+
+	nvme, err := r.Resolve("nvme0n1p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &zfs.DiskIdentity{
+		Device: "nvme0n1",
+		ByID:   []string{"nvme-Samsung_SSD_980_PRO_1TB_S5GXNF0R123456A", "nvme-eui.002538b111b2a3c4"},
+		Model:  "Samsung SSD 980 PRO 1TB",
+		Serial: "S5GXNF0R123456A",
+		WWN:    "eui.002538b111b2a3c4",
+		Size:   1953525168 * 512,
+	}
+	if !reflect.DeepEqual(nvme, want) {
+		t.Errorf("Resolve(nvme0n1p1) = %+v, want %+v", nvme, want)
+	}
+	if got := nvme.Label("by-id"); got != want.ByID[0] {
+		t.Errorf("Label(by-id) = %q", got)
+	}
+
+	for _, name := range []string{"sdz", "/var/tmp/file-vdev"} {
+		if _, err := r.Resolve(name); !errors.Is(err, ErrNotDisk) || !strings.Contains(err.Error(), name) {
+			t.Errorf("Resolve(%q) error = %v, want ErrNotDisk", name, err)
+		}
+	}
+}
+
+func TestEnclosures(t *testing.T) {
+	encs, err := NewResolver(fakeRoot(t)).Enclosures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encs) != 2 {
+		t.Fatalf("%d enclosures, want 2", len(encs))
+	}
+	// Numbered by SCSI address: 0:0:9:0 before 0:0:20:0
+	first, second := encs[0], encs[1]
+	if first.Number != 1 || first.ID != "0:0:9:0" || len(first.Slots) != 1 || *first.Slots[0] != (zfs.EnclosureSlot{Number: 7, Name: "DISK07", Device: "sda"}) {
+		t.Errorf("first enclosure %+v, slots %+v", first, first.Slots)
+	}
+	want := []zfs.EnclosureSlot{
+		{Number: 0, Name: "Slot 00", Device: "sdq", Status: "OK", Fault: true},
+		{Number: 1, Name: "Slot 01", Status: "Not Installed"},
+	}
+	if second.Number != 2 || second.LogicalID != "0x5003048001a2b37f" || second.Vendor != "LSI" || second.Model != "SAS2X36" || len(second.Slots) != len(want) {
+		t.Fatalf("second enclosure %+v", second)
+	}
+	for i := range want {
+		if *second.Slots[i] != want[i] {
+			t.Errorf("slot %d = %+v, want %+v", i, *second.Slots[i], want[i])
+		}
+	}
+
+	if encs, err := NewResolver(t.TempDir()).Enclosures(); err != nil || encs != nil {
+		t.Errorf("host without enclosures: %v, %v", encs, err)
+	}
+}
@@ -0,0 +1,130 @@
+package disk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// slotDigits matches the number in a slot name such as "Slot 14" or
+// "DISK07".
+var slotDigits = regexp.MustCompile(`\d+`)
+
+// Enclosures reads the SES enclosures of the host and the disks in their
+// slots from /sys/class/enclosure (the ses kernel module).
+//
+// Returns:
+//   - []*zfs.Enclosure: The enclosures, numbered from 1 in the order of
+//     their SCSI addresses; none if the host has no SES enclosures
+//   - error: Error if the enclosures cannot be read
+//
+// Example:
+//
+//	encs, _ := disk.NewResolver("").Enclosures()
+//	for _, enc := range encs {
+//	    fmt.Printf("enclosure %d: %d slots\n", enc.Number, len(enc.Slots))
+//	}
+func (r *Resolver) Enclosures() ([]*zfs.Enclosure, error) {
+	dir := filepath.Join(r.Root, "sys/class/enclosure")
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Slice(names, func(i, j int) bool { return scsiLess(names[i], names[j]) })
+
+	var encs []*zfs.Enclosure
+	for _, name := range names {
+		enc, err := readEnclosure(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		enc.Number = len(encs) + 1
+		encs = append(encs, enc)
+	}
+	return encs, nil
+}
+
+// readEnclosure reads an enclosure directory of /sys/class/enclosure. Its
+// components are the subdirectories with a type attribute; only disk
+// slots ("array device" or "device") are kept.
+func readEnclosure(dir string) (*zfs.Enclosure, error) {
+	enc := &zfs.Enclosure{
+		ID:        filepath.Base(dir),
+		LogicalID: readAttr(dir, "id"),
+		Vendor:    readAttr(dir, "device/vendor"),
+		Model:     readAttr(dir, "device/model"),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		component := filepath.Join(dir, entry.Name())
+		if kind := readAttr(component, "type"); kind != "array device" && kind != "device" {
+			continue
+		}
+		slot := &zfs.EnclosureSlot{
+			Number: i,
+			Name:   entry.Name(),
+			Status: readAttr(component, "status"),
+			Fault:  readAttr(component, "fault") == "1",
+			Locate: readAttr(component, "locate") == "1",
+		}
+		if n, err := strconv.Atoi(readAttr(component, "slot")); err == nil {
+			slot.Number = n
+		} else if digits := slotDigits.FindString(entry.Name()); digits != "" {
+			slot.Number, _ = strconv.Atoi(digits)
+		}
+		// The disk's SCSI device lists its block device
+		if disks, err := os.ReadDir(filepath.Join(component, "device/block")); err == nil && len(disks) > 0 {
+			slot.Device = disks[0].Name()
+		}
+		enc.Slots = append(enc.Slots, slot)
+	}
+	sort.SliceStable(enc.Slots, func(i, j int) bool { return enc.Slots[i].Number < enc.Slots[j].Number })
+	return enc, nil
+}
+
+// readAttr reads a sysfs attribute below a directory, trimmed, or returns
+// empty if it does not exist.
+func readAttr(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// scsiLess orders SCSI addresses such as "0:0:9:0" and "0:0:20:0" by
+// their numbers.
+func scsiLess(a, b string) bool {
+	as, bs := strings.Split(a, ":"), strings.Split(b, ":")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr != nil || bErr != nil {
+			if as[i] != bs[i] {
+				return as[i] < bs[i]
+			}
+			continue
+		}
+		if an != bn {
+			return an < bn
+		}
+	}
+	return len(as) < len(bs)
+}
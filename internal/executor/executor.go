@@ -0,0 +1,201 @@
+// Package executor runs the external commands the collectors shell out
+// to, such as zpool and zfs. Collectors only see the Executor interface, so
+// the same collector runs the commands locally, on a remote host over ssh,
+// or replays outputs recorded from a real host, and tests run on machines
+// without ZFS.
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cmd is a command to run.
+type Cmd struct {
+	// Name is the program, e.g. "zpool"
+	Name string
+
+	// Args are the arguments following the program
+	Args []string
+
+	// Env holds "KEY=value" entries added to the environment, e.g. "LC_ALL=C"
+	Env []string
+
+	// Timeout is the longest time the command may run, 0 for no limit
+	Timeout time.Duration
+}
+
+// String returns the command line, e.g. "zpool status -p".
+func (c Cmd) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Result is what a command printed and how it exited.
+type Result struct {
+	// Stdout and Stderr are the command's output streams
+	Stdout []byte
+	Stderr []byte
+
+	// ExitCode is the exit status, 0 on success
+	ExitCode int
+}
+
+// Executor runs commands. Implementations must be safe for concurrent use.
+type Executor interface {
+	// Run runs a command and waits for it to finish. A command exiting
+	// with a non-zero status returns its Result together with an
+	// *ExitError; other errors mean the command could not be run.
+	Run(ctx context.Context, cmd Cmd) (*Result, error)
+}
+
+// Starter is an Executor that can also run commands that do not finish on
+// their own, such as zpool events -f, and hand out their output as it is
+// printed.
+type Starter interface {
+	Executor
+
+	// Start starts a command and returns its standard output. Reading
+	// returns io.EOF once the command exited successfully, or an
+	// *ExitError if it failed. Closing the reader stops the command;
+	// so does ctx being done. Cmd.Timeout is ignored.
+	Start(ctx context.Context, cmd Cmd) (io.ReadCloser, error)
+}
+
+// ExitError reports a command that exited with a non-zero status.
+type ExitError struct {
+	// Command is the command line that failed
+	Command string
+
+	// Code is the exit status
+	Code int
+
+	// Stderr is what the command wrote to standard error, trimmed
+	Stderr string
+}
+
+// Error implements error, e.g. "zpool status -p: exit status 1: no pools available".
+func (e *ExitError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s: exit status %d", e.Command, e.Code)
+	}
+	return fmt.Sprintf("%s: exit status %d: %s", e.Command, e.Code, e.Stderr)
+}
+
+// Local runs commands on this host.
+type Local struct{}
+
+// Run implements Executor. Cmd.Env is added to the environment of this
+// process.
+func (Local) Run(ctx context.Context, cmd Cmd) (*Result, error) {
+	if cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cmd.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if len(cmd.Env) > 0 {
+		c.Env = append(os.Environ(), cmd.Env...)
+	}
+	err := c.Run()
+	res := &Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return res, nil
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%s: %w", cmd, ctx.Err())
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+		return res, newExitError(cmd, res)
+	}
+	return nil, fmt.Errorf("%s: %w", cmd, err)
+}
+
+// Start implements Starter.
+func (Local) Start(ctx context.Context, cmd Cmd) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &process{cmd: cmd, ctx: ctx, cancel: cancel}
+	p.c = exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	p.c.Stderr = &p.stderr
+	if len(cmd.Env) > 0 {
+		p.c.Env = append(os.Environ(), cmd.Env...)
+	}
+	stdout, err := p.c.StdoutPipe()
+	if err == nil {
+		err = p.c.Start()
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%s: %w", cmd, err)
+	}
+	p.stdout = stdout
+	return p, nil
+}
+
+// process is a command started by Local.Start.
+type process struct {
+	cmd    Cmd
+	c      *exec.Cmd
+	ctx    context.Context
+	cancel context.CancelFunc
+	stdout io.Reader
+	stderr bytes.Buffer
+
+	once sync.Once
+	err  error // Result of wait
+}
+
+// Read implements io.Reader, reporting how the command exited at the end
+// of its output.
+func (p *process) Read(b []byte) (int, error) {
+	n, err := p.stdout.Read(b)
+	if err == io.EOF {
+		if waitErr := p.wait(); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// Close implements io.Closer. It stops the command if it still runs.
+func (p *process) Close() error {
+	p.cancel()
+	p.wait()
+	return nil
+}
+
+// wait waits for the command to exit, once, and describes how it exited.
+func (p *process) wait() error {
+	p.once.Do(func() {
+		err := p.c.Wait()
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+		case p.ctx.Err() != nil:
+			p.err = fmt.Errorf("%s: %w", p.cmd, p.ctx.Err())
+		case errors.As(err, &exitErr):
+			p.err = newExitError(p.cmd, &Result{Stderr: p.stderr.Bytes(), ExitCode: exitErr.ExitCode()})
+		default:
+			p.err = fmt.Errorf("%s: %w", p.cmd, err)
+		}
+		p.cancel()
+	})
+	return p.err
+}
+
+// newExitError describes the failed command of a result.
+func newExitError(cmd Cmd, res *Result) *ExitError {
+	return &ExitError{Command: cmd.String(), Code: res.ExitCode, Stderr: strings.TrimSpace(string(res.Stderr))}
+}
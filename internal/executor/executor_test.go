@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocal(t *testing.T) {
+	res, err := Local{}.Run(context.Background(), Cmd{
+		Name: "sh",
+		Args: []string{"-c", `printf "$GREETING"; printf oops >&2`},
+		Env:  []string{"GREETING=hello"},
+	})
+	if err != nil || string(res.Stdout) != "hello" || string(res.Stderr) != "oops" {
+		t.Fatalf("Run = %+v, %v", res, err)
+	}
+
+	res, err = Local{}.Run(context.Background(), Cmd{Name: "sh", Args: []string{"-c", "echo no pools >&2; exit 3"}})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || res == nil || res.ExitCode != 3 || err.Error() != "sh -c echo no pools >&2; exit 3: exit status 3: no pools" {
+		t.Errorf("failing command: %+v, %v", res, err)
+	}
+
+	if _, err := (Local{}).Run(context.Background(), Cmd{Name: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("timeout: %v", err)
+	}
+	if _, err := (Local{}).Run(context.Background(), Cmd{Name: "/nonexistent/zpool"}); err == nil || errors.As(err, &exitErr) {
+		t.Errorf("missing program: %v", err)
+	}
+}
+
+func TestSSH(t *testing.T) {
+	fake := NewFake().
+		On("ssh -o BatchMode=yes -- root@nas1 env LC_ALL=C zfs list -o 'name,used avail'", Response{Stdout: "tank\n"}).
+		On("ssh -o BatchMode=yes -- root@nas1 zpool status", Response{ExitCode: 255, Stderr: "ssh: connect to host nas1 port 22: Connection refused"})
+	ssh := SSH{Host: "root@nas1", Options: []string{"-o", "BatchMode=yes"}, Exec: fake}
+
+	res, err := ssh.Run(context.Background(), Cmd{Name: "zfs", Args: []string{"list", "-o", "name,used avail"}, Env: []string{"LC_ALL=C"}})
+	if err != nil || string(res.Stdout) != "tank\n" {
+		t.Fatalf("Run = %+v, %v (ran %v)", res, err, fake.Calls())
+	}
+
+	_, err = ssh.Run(context.Background(), Cmd{Name: "zpool", Args: []string{"status"}})
+	want := "root@nas1: zpool status: exit status 255: ssh: connect to host nas1 port 22: Connection refused"
+	if err == nil || err.Error() != want {
+		t.Errorf("error %v, want %s", err, want)
+	}
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake().On("zpool list", Response{Stdout: "first"}, Response{Stdout: "second"})
+	for _, want := range []string{"first", "second", "second"} {
+		if res, err := fake.Run(context.Background(), Cmd{Name: "zpool", Args: []string{"list"}}); err != nil || string(res.Stdout) != want {
+			t.Errorf("Run = %+v, %v; want %s", res, err, want)
+		}
+	}
+	if res, err := fake.Run(context.Background(), Cmd{Name: "smartctl"}); err == nil || res.ExitCode != 127 {
+		t.Errorf("unscripted command: %+v, %v", res, err)
+	}
+	if calls := fake.Calls(); len(calls) != 4 || calls[3].Name != "smartctl" {
+		t.Errorf("calls %v", calls)
+	}
+}
+
+func TestRecorderFixture(t *testing.T) {
+	dir := t.TempDir()
+	status := Cmd{Name: "zpool", Args: []string{"status", "-p"}, Env: []string{"LC_ALL=C"}}
+	list := Cmd{Name: "zfs", Args: []string{"list", "-o", "name,used"}}
+	recorder := &Recorder{Dir: dir, Exec: NewFake().
+		On(status.String(), Response{Stdout: "  pool: tank\n"}).
+		On(list.String(), Response{Stderr: "no datasets available\n", ExitCode: 1})}
+
+	ctx := context.Background()
+	recorder.Run(ctx, status)
+	recorder.Run(ctx, list)
+	if got := recorder.Recorded(); len(got) != 2 || got[0] != "zpool status -p" {
+		t.Errorf("recorded %v", got)
+	}
+	if FixtureKey(list) != "zfs_list_-o_name_used" {
+		t.Errorf("key %q", FixtureKey(list))
+	}
+
+	// The fixture replays both the output and the failure
+	fixture := Fixture{Dir: dir}
+	if res, err := fixture.Run(ctx, status); err != nil || string(res.Stdout) != "  pool: tank\n" {
+		t.Errorf("replayed %+v, %v", res, err)
+	}
+	var exitErr *ExitError
+	if _, err := fixture.Run(ctx, list); !errors.As(err, &exitErr) || exitErr.Code != 1 ||
+		!strings.Contains(err.Error(), "no datasets available") {
+		t.Errorf("replayed failure: %v", err)
+	}
+	if _, err := fixture.Run(ctx, Cmd{Name: "zpool", Args: []string{"events"}}); !errors.Is(err, ErrNoFixture) {
+		t.Errorf("unrecorded command: %v", err)
+	}
+	if out, err := fixture.Start(ctx, status); err != nil {
+		t.Errorf("Start: %v", err)
+	} else if data, err := io.ReadAll(out); err != nil || string(data) != "  pool: tank\n" {
+		t.Errorf("started %q, %v", data, err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	ctx := context.Background()
+	out, err := Local{}.Start(ctx, Cmd{Name: "sh", Args: []string{"-c", "echo one; echo two; echo gone >&2; exit 2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(out)
+	var exitErr *ExitError
+	if string(data) != "one\ntwo\n" || !errors.As(err, &exitErr) || exitErr.Code != 2 || exitErr.Stderr != "gone" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	out.Close()
+
+	// Closing stops a command that runs forever
+	out, err = Local{}.Start(ctx, Cmd{Name: "sh", Args: []string{"-c", "echo ready; exec sleep 60"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := make([]byte, 6)
+	if _, err := io.ReadFull(out, line); err != nil || string(line) != "ready\n" {
+		t.Errorf("read %q, %v", line, err)
+	}
+	closed := make(chan struct{})
+	go func() { out.Close(); close(closed) }()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not stop the command")
+	}
+
+	// Over ssh, errors name the host and the remote command
+	fake := NewFake().On("ssh -- nas1 zpool events -f", Response{Stdout: "event\n", ExitCode: 1, Stderr: "no such pool"})
+	out, err = SSH{Host: "nas1", Exec: fake}.Start(ctx, Cmd{Name: "zpool", Args: []string{"events", "-f"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(out)
+	if string(data) != "event\n" || err == nil || err.Error() != "nas1: zpool events -f: exit status 1: no such pool" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	if _, err := (SSH{Host: "nas1", Exec: &Recorder{Exec: fake}}).Start(ctx, Cmd{Name: "zpool"}); err == nil {
+		t.Error("started through an executor without Start")
+	}
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response is a scripted outcome of a command run by a Fake.
+type Response struct {
+	// Stdout and Stderr are the output returned
+	Stdout string
+	Stderr string
+
+	// ExitCode is the exit status; non-zero returns an *ExitError
+	ExitCode int
+
+	// Err fails the command as if it could not be run
+	Err error
+
+	// Delay is how long the command takes, cut short by the context
+	Delay time.Duration
+}
+
+// Fake is a scripted Executor for tests: it returns the responses given
+// to On and records every command it was asked to run.
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	calls     []Cmd
+}
+
+// NewFake creates a Fake without any scripted commands.
+//
+// Returns:
+//   - *Fake: A fake failing every command until scripted with On
+//
+// Example:
+//
+//	fake := executor.NewFake().
+//		On("zpool status -p", executor.Response{Stdout: status}).
+//		On("zpool list -Hp", executor.Response{ExitCode: 1, Stderr: "no pools available"})
+func NewFake() *Fake {
+	return &Fake{responses: make(map[string][]Response)}
+}
+
+// On scripts the responses to a command line, e.g. "zpool status -p".
+// Successive runs get successive responses; the last one repeats.
+//
+// Parameters:
+//   - command: The command line, as returned by Cmd.String
+//   - responses: The responses, at least one
+//
+// Returns:
+//   - *Fake: The fake, for chaining
+func (f *Fake) On(command string, responses ...Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[command] = append(f.responses[command], responses...)
+	return f
+}
+
+// Run implements Executor. Commands that were not scripted fail with exit
+// status 127, like a missing program.
+func (f *Fake) Run(ctx context.Context, cmd Cmd) (*Result, error) {
+	r, err := f.next(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Stdout: []byte(r.Stdout), Stderr: []byte(r.Stderr), ExitCode: r.ExitCode}
+	if res.ExitCode != 0 {
+		return res, newExitError(cmd, res)
+	}
+	return res, nil
+}
+
+// Start implements Starter. The command prints its scripted standard
+// output at once and exits, so reading ends with io.EOF, or with an
+// *ExitError after the output for a non-zero exit status.
+func (f *Fake) Start(ctx context.Context, cmd Cmd) (io.ReadCloser, error) {
+	r, err := f.next(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Stderr: []byte(r.Stderr), ExitCode: r.ExitCode}
+	stdout := io.Reader(strings.NewReader(r.Stdout))
+	if res.ExitCode != 0 {
+		stdout = io.MultiReader(stdout, &errReader{newExitError(cmd, res)})
+	}
+	return io.NopCloser(stdout), nil
+}
+
+// next records a command and returns its next response after the
+// response's delay. Responses failing the command return its error.
+func (f *Fake) next(ctx context.Context, cmd Cmd) (Response, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, cmd)
+	queue := f.responses[cmd.String()]
+	var r Response
+	switch len(queue) {
+	case 0:
+		r = Response{ExitCode: 127, Stderr: cmd.Name + ": command not found"}
+	case 1:
+		r = queue[0]
+	default:
+		r, f.responses[cmd.String()] = queue[0], queue[1:]
+	}
+	f.mu.Unlock()
+
+	if r.Delay > 0 {
+		select {
+		case <-time.After(r.Delay):
+		case <-ctx.Done():
+			return r, fmt.Errorf("%s: %w", cmd, ctx.Err())
+		}
+	}
+	return r, r.Err
+}
+
+// errReader is a reader failing with an error.
+type errReader struct{ err error }
+
+// Read implements io.Reader.
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// Calls returns the commands run so far, in order.
+func (f *Fake) Calls() []Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Cmd(nil), f.calls...)
+}
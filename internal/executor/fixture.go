@@ -0,0 +1,160 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNoFixture is returned by Fixture for commands that were not recorded.
+var ErrNoFixture = errors.New("no recorded output")
+
+// A fixture directory holds one set of files per recorded command, named
+// by FixtureKey:
+//
+//	<key>.stdout  standard output
+//	<key>.stderr  standard error, if any
+//	<key>.exit    exit status, if not 0
+//
+// The files are plain text so that fixtures can be reviewed and edited.
+
+// Fixture replays outputs recorded from a real host, e.g. by a Recorder,
+// so that collectors and front ends can be tested without ZFS.
+type Fixture struct {
+	// Dir is the fixture directory
+	Dir string
+}
+
+// Run implements Executor. Commands without recorded output fail with an
+// error wrapping ErrNoFixture; the environment and timeout are ignored.
+func (f Fixture) Run(ctx context.Context, cmd Cmd) (*Result, error) {
+	base := filepath.Join(f.Dir, FixtureKey(cmd))
+	stdout, err := os.ReadFile(base + ".stdout")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w in %s", cmd, ErrNoFixture, f.Dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Stdout: stdout}
+
+	if res.Stderr, err = os.ReadFile(base + ".stderr"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if code, err := os.ReadFile(base + ".exit"); err == nil {
+		if res.ExitCode, err = strconv.Atoi(strings.TrimSpace(string(code))); err != nil {
+			return nil, fmt.Errorf("%s.exit: %w", base, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if res.ExitCode != 0 {
+		return res, newExitError(cmd, res)
+	}
+	return res, nil
+}
+
+// Start implements Starter, replaying the recorded output of a command
+// such as zpool events -f as if it had exited after printing it. A
+// recorded failure is returned at once.
+func (f Fixture) Start(ctx context.Context, cmd Cmd) (io.ReadCloser, error) {
+	res, err := f.Run(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(res.Stdout)), nil
+}
+
+// Recorder runs commands through another executor and saves their
+// outputs as a fixture, capturing a real host for tests.
+type Recorder struct {
+	// Exec runs the commands
+	Exec Executor
+
+	// Dir is the fixture directory, created if missing
+	Dir string
+
+	mu       sync.Mutex
+	recorded []string
+}
+
+// Run implements Executor. Commands that ran are recorded whether they
+// succeeded or not; commands that could not be run are not.
+func (r *Recorder) Run(ctx context.Context, cmd Cmd) (*Result, error) {
+	res, err := r.Exec.Run(ctx, cmd)
+	if res == nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.save(cmd, res); err != nil {
+		return nil, fmt.Errorf("recording %s: %w", cmd, err)
+	}
+	r.recorded = append(r.recorded, cmd.String())
+	return res, err
+}
+
+// Recorded returns the command lines recorded so far, in order.
+func (r *Recorder) Recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.recorded...)
+}
+
+// save writes the files of a result.
+func (r *Recorder) save(cmd Cmd, res *Result) error {
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return err
+	}
+	base := filepath.Join(r.Dir, FixtureKey(cmd))
+	if err := os.WriteFile(base+".stdout", res.Stdout, 0o644); err != nil {
+		return err
+	}
+	var exit []byte
+	if res.ExitCode != 0 {
+		exit = []byte(strconv.Itoa(res.ExitCode) + "\n")
+	}
+	if err := writeOrRemove(base+".stderr", res.Stderr); err != nil {
+		return err
+	}
+	return writeOrRemove(base+".exit", exit)
+}
+
+// writeOrRemove writes a file, or removes it if there is nothing to
+// write, so that no leftovers of an earlier recording remain.
+func writeOrRemove(path string, data []byte) error {
+	if len(data) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// unsafeKey matches runs of characters not used in fixture file names.
+var unsafeKey = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// FixtureKey returns the base name of a command's fixture files: the
+// command line with everything but letters, digits, dots and dashes
+// replaced by underscores.
+//
+// Parameters:
+//   - cmd: The command; only the program and arguments are used
+//
+// Returns:
+//   - string: The key, e.g. "zpool_status_-p" for "zpool status -p"
+func FixtureKey(cmd Cmd) string {
+	return unsafeKey.ReplaceAllString(cmd.String(), "_")
+}
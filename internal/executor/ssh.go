@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// SSH runs commands on a remote host through the system ssh binary, so
+// that the user's ssh configuration, agent and known hosts apply.
+type SSH struct {
+	// Host is the ssh destination, e.g. "root@nas1"
+	Host string
+
+	// Command is the ssh binary (default: "ssh")
+	Command string
+
+	// Options are passed to ssh before the host, e.g. "-o", "BatchMode=yes"
+	Options []string
+
+	// Exec runs ssh itself (default: Local)
+	Exec Executor
+}
+
+// Run implements Executor. Cmd.Env is set on the remote host through
+// env(1), and the timeout applies to the whole ssh session. Errors name
+// the host and the remote command rather than the ssh command line.
+func (s SSH) Run(ctx context.Context, cmd Cmd) (*Result, error) {
+	exec, ssh := s.command(cmd)
+	res, err := exec.Run(ctx, ssh)
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return res, nil
+	case errors.As(err, &exitErr):
+		return res, fmt.Errorf("%s: %w", s.Host, newExitError(cmd, res))
+	}
+	return nil, fmt.Errorf("%s: %s: %w", s.Host, cmd, err)
+}
+
+// Start implements Starter if Exec does; the default Local does. Errors
+// name the host and the remote command like those of Run.
+func (s SSH) Start(ctx context.Context, cmd Cmd) (io.ReadCloser, error) {
+	exec, ssh := s.command(cmd)
+	starter, ok := exec.(Starter)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s: %T cannot start commands", s.Host, cmd, exec)
+	}
+	r, err := starter.Start(ctx, ssh)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", s.Host, cmd, err)
+	}
+	return &sshReader{ReadCloser: r, host: s.Host, cmd: cmd}, nil
+}
+
+// command returns the executor running ssh and the ssh command running
+// cmd on the host.
+func (s SSH) command(cmd Cmd) (Executor, Cmd) {
+	binary, exec := s.Command, s.Exec
+	if binary == "" {
+		binary = "ssh"
+	}
+	if exec == nil {
+		exec = Local{}
+	}
+
+	// ssh joins the remote command into a line run by the remote shell
+	args := append(append([]string{}, s.Options...), "--", s.Host)
+	if len(cmd.Env) > 0 {
+		args = append(args, "env")
+		for _, env := range cmd.Env {
+			args = append(args, quote(env))
+		}
+	}
+	args = append(args, quote(cmd.Name))
+	for _, arg := range cmd.Args {
+		args = append(args, quote(arg))
+	}
+	return exec, Cmd{Name: binary, Args: args, Timeout: cmd.Timeout}
+}
+
+// sshReader is the output of a command started over ssh.
+type sshReader struct {
+	io.ReadCloser
+	host string
+	cmd  Cmd
+}
+
+// Read implements io.Reader, naming the host and the remote command in
+// errors.
+func (r *sshReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	var exitErr *ExitError
+	switch {
+	case err == nil, err == io.EOF:
+		return n, err
+	case errors.As(err, &exitErr):
+		return n, fmt.Errorf("%s: %w", r.host, &ExitError{Command: r.cmd.String(), Code: exitErr.Code, Stderr: exitErr.Stderr})
+	}
+	return n, fmt.Errorf("%s: %s: %w", r.host, r.cmd, err)
+}
+
+// unquoted matches arguments the remote shell passes through unchanged.
+var unquoted = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quote quotes an argument for the remote shell.
+func quote(arg string) string {
+	if unquoted.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// WriteDOT renders pools as a Graphviz DOT digraph. Each pool becomes a
+// cluster; nodes are coloured by the worst status of their subtree.
+//
+// Parameters:
+//   - w: Destination of the diagram
+//   - pools: The pools to render
+//
+// Returns:
+//   - error: Any error writing to w
+//
+// Example:
+//
+//	export.WriteDOT(os.Stdout, pools) // pipe into: dot -Tpng -o pools.png
+func WriteDOT(w io.Writer, pools []*zfs.Pool) error {
+	analyzer := &status.Analyzer{}
+	var sb strings.Builder
+
+	sb.WriteString("digraph zfs {\n")
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	sb.WriteString("  edge [arrowhead=none];\n")
+
+	for i, pool := range pools {
+		root := buildGraph(i, pool, analyzer)
+		fmt.Fprintf(&sb, "\n  subgraph cluster_%s {\n", root.id)
+		fmt.Fprintf(&sb, "    label=%s;\n", dotString(pool.Name))
+		sb.WriteString("    style=dashed;\n")
+		writeDOTNode(&sb, root)
+		root.walk(func(parent, child *node) {
+			writeDOTNode(&sb, child)
+		})
+		root.walk(func(parent, child *node) {
+			fmt.Fprintf(&sb, "    %s -> %s;\n", parent.id, child.id)
+		})
+		sb.WriteString("  }\n")
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeDOTNode writes a single node statement.
+func writeDOTNode(sb *strings.Builder, n *node) {
+	colors := colorsFor(n.status)
+	fmt.Fprintf(sb, "    %s [label=%s, fillcolor=%s, color=%s];\n",
+		n.id, dotString(strings.Join(n.lines, "\n")), dotString(colors.fill), dotString(colors.stroke))
+}
+
+// dotString quotes s as a DOT string, escaping quotes, backslashes and newlines.
+func dotString(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+func testPools() []*zfs.Pool {
+	return []*zfs.Pool{
+		{
+			Name:      "tank",
+			Size:      4 << 40,
+			Allocated: 1 << 40,
+			RootVDev: &zfs.VDev{
+				Name:   "raidz1-0",
+				Type:   "raidz1",
+				Status: zfs.VDevStatusOnline,
+				Children: []*zfs.VDev{
+					{Name: "sda", Type: "disk", Status: zfs.VDevStatusOnline, Size: 2 << 40},
+					{Name: "sdb", Type: "disk", Status: zfs.VDevStatusFaulted, Size: 2 << 40, WriteErrors: 7},
+					{Name: `odd"name`, Type: "disk", Status: zfs.VDevStatusOnline},
+				},
+			},
+			Slog: &zfs.VDev{Name: "log", Type: "disk", Status: zfs.VDevStatusOnline},
+		},
+		{
+			Name:     "backup",
+			RootVDev: &zfs.VDev{Name: "sdz", Type: "disk", Status: zfs.VDevStatusOnline},
+		},
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteDOT(&sb, testPools()); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"digraph zfs {",
+		"subgraph cluster_p0 {",
+		"subgraph cluster_p1 {",
+		`p0 [label="tank\nFAULTED\n1.0T / 4.0T (25%)\n7 errors", fillcolor="#ffc9c9"`,
+		`p0_0_1 [label="sdb\ndisk FAULTED\n2.0T\nR:0 W:7 C:0"`,
+		`p0_0_2 [label="odd\"name\ndisk ONLINE"`,
+		"p0_0 -> p0_0_1;",
+		"p0 -> p0_2;",
+		`p1_0 [label="sdz\ndisk ONLINE", fillcolor="#d4f7d4"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "}\n") {
+		t.Error("DOT output not terminated")
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteMermaid(&sb, testPools()); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"flowchart TB\n",
+		`subgraph p0_pool["tank"]`,
+		`p0_0_1["sdb<br/>disk FAULTED<br/>2.0T<br/>R:0 W:7 C:0"]:::faulted`,
+		`p0_0_2["odd#quot;name<br/>disk ONLINE"]:::online`,
+		"p0_0 --- p0_0_1",
+		"classDef online fill:#d4f7d4",
+		"classDef faulted fill:#ffc9c9",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "classDef degraded") {
+		t.Error("unused class defined")
+	}
+}
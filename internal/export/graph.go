@@ -0,0 +1,100 @@
+// Package export converts pool topologies into diagram formats for design
+// documents and runbooks.
+package export
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// node is a format independent diagram node. Each diagram format renders the
+// same tree of nodes so that all exports show identical information.
+type node struct {
+	id       string
+	lines    []string       // label lines, the first one is the name
+	status   zfs.VDevStatus // worst status of the subtree
+	children []*node
+}
+
+// palette holds fill and stroke colours for a status.
+type palette struct {
+	fill   string
+	stroke string
+}
+
+// statusColors maps statuses to diagram colours. Light fills keep dark text
+// readable when diagrams are printed.
+var statusColors = map[zfs.VDevStatus]palette{
+	zfs.VDevStatusOnline:   {fill: "#d4f7d4", stroke: "#2e8b57"},
+	zfs.VDevStatusDegraded: {fill: "#fff3b0", stroke: "#c9a000"},
+	zfs.VDevStatusFaulted:  {fill: "#ffc9c9", stroke: "#c0392b"},
+}
+
+// unknownColors is used for statuses without an entry in statusColors.
+var unknownColors = palette{fill: "#e0e0e0", stroke: "#808080"}
+
+// colorsFor returns the palette for a status.
+func colorsFor(s zfs.VDevStatus) palette {
+	if p, ok := statusColors[s]; ok {
+		return p
+	}
+	return unknownColors
+}
+
+// buildGraph converts a pool into a node tree. Node ids are derived from the
+// pool index and the position in the tree, so they are stable and unique
+// across all pools of one export.
+func buildGraph(index int, pool *zfs.Pool, analyzer *status.Analyzer) *node {
+	id := "p" + strconv.Itoa(index)
+	root := &node{
+		id:     id,
+		lines:  []string{pool.Name, string(analyzer.GetPoolWorstStatus(pool))},
+		status: analyzer.GetPoolWorstStatus(pool),
+	}
+	if pool.Size > 0 {
+		root.lines = append(root.lines, fmt.Sprintf("%s / %s (%.0f%%)",
+			utils.FormatBytes(pool.Allocated), utils.FormatBytes(pool.Size), pool.CapacityPercent()))
+	}
+	if errors := analyzer.GetPoolErrorCount(pool); errors > 0 {
+		root.lines = append(root.lines, fmt.Sprintf("%d errors", errors))
+	}
+
+	for i, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+		if vdev != nil {
+			root.children = append(root.children, buildVDev(id+"_"+strconv.Itoa(i), vdev, analyzer))
+		}
+	}
+	return root
+}
+
+// buildVDev converts a VDev subtree.
+func buildVDev(id string, vdev *zfs.VDev, analyzer *status.Analyzer) *node {
+	worst := analyzer.GetVDevWorstStatus(vdev)
+	n := &node{
+		id:     id,
+		lines:  []string{vdev.Name, fmt.Sprintf("%s %s", vdev.Type, worst)},
+		status: worst,
+	}
+	if vdev.Size > 0 {
+		n.lines = append(n.lines, utils.FormatBytes(vdev.Size))
+	}
+	if vdev.ReadErrors+vdev.WriteErrors+vdev.ChecksumErrors > 0 {
+		n.lines = append(n.lines, fmt.Sprintf("R:%d W:%d C:%d", vdev.ReadErrors, vdev.WriteErrors, vdev.ChecksumErrors))
+	}
+	for i, child := range vdev.Children {
+		n.children = append(n.children, buildVDev(id+"_"+strconv.Itoa(i), child, analyzer))
+	}
+	return n
+}
+
+// walk calls fn for every parent/child edge of the tree, depth first.
+func (n *node) walk(fn func(parent, child *node)) {
+	for _, child := range n.children {
+		fn(n, child)
+		child.walk(fn)
+	}
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// WriteMermaid renders pools as a Mermaid flowchart, suitable for Markdown
+// documents rendered by GitHub, GitLab and most wikis. Each pool becomes a
+// subgraph; nodes are coloured by the worst status of their subtree.
+//
+// Parameters:
+//   - w: Destination of the diagram
+//   - pools: The pools to render
+//
+// Returns:
+//   - error: Any error writing to w
+func WriteMermaid(w io.Writer, pools []*zfs.Pool) error {
+	analyzer := &status.Analyzer{}
+	var sb strings.Builder
+
+	sb.WriteString("flowchart TB\n")
+	used := make(map[string]bool)
+	for i, pool := range pools {
+		root := buildGraph(i, pool, analyzer)
+		fmt.Fprintf(&sb, "  subgraph %s_pool[%s]\n", root.id, mermaidString(pool.Name))
+		writeMermaidNode(&sb, root)
+		used[mermaidClass(root.status)] = true
+		root.walk(func(parent, child *node) {
+			writeMermaidNode(&sb, child)
+			used[mermaidClass(child.status)] = true
+		})
+		root.walk(func(parent, child *node) {
+			fmt.Fprintf(&sb, "    %s --- %s\n", parent.id, child.id)
+		})
+		sb.WriteString("  end\n")
+	}
+
+	// Class definitions for the statuses in use, in a fixed order for stable output
+	for _, s := range []zfs.VDevStatus{zfs.VDevStatusOnline, zfs.VDevStatusDegraded, zfs.VDevStatusFaulted, ""} {
+		if used[mermaidClass(s)] {
+			colors := colorsFor(s)
+			fmt.Fprintf(&sb, "  classDef %s fill:%s,stroke:%s,color:#000\n", mermaidClass(s), colors.fill, colors.stroke)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMermaidNode writes a node declaration with its status class.
+func writeMermaidNode(sb *strings.Builder, n *node) {
+	lines := make([]string, len(n.lines))
+	for i, line := range n.lines {
+		lines[i] = strings.Trim(mermaidString(line), `"`)
+	}
+	fmt.Fprintf(sb, "    %s[\"%s\"]:::%s\n", n.id, strings.Join(lines, "<br/>"), mermaidClass(n.status))
+}
+
+// mermaidClass returns the class name used for a status.
+func mermaidClass(s zfs.VDevStatus) string {
+	if _, ok := statusColors[s]; ok {
+		return strings.ToLower(string(s))
+	}
+	return "unknown"
+}
+
+// mermaidString quotes s for use as a Mermaid label, replacing characters
+// that would end the label with their entity codes.
+func mermaidString(s string) string {
+	s = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// SVG layout constants, in pixels.
+const (
+	svgMargin     = 16 // space around the drawing
+	svgPoolHeader = 44 // height of the pool title and summary
+	svgBoxLabel   = 22 // height of a vdev box label
+	svgBoxPadding = 10 // space between a vdev box border and its tiles
+	svgTileWidth  = 84
+	svgTileHeight = 64
+	svgTileGap    = 8
+	svgMaxColumns = 12 // tiles per row before wrapping
+	svgMinWidth   = 360
+	svgNameChars  = 12 // longer disk names are shortened on tiles
+)
+
+// svgGroup is a top-level VDev drawn as a box of disk tiles.
+type svgGroup struct {
+	label string
+	vdev  *zfs.VDev
+	disks []*zfs.VDev
+	ratio float64 // allocated share used to fill the tiles
+}
+
+// WriteSVG renders pools as a self-contained SVG image. Every top-level VDev
+// is drawn as a box containing one tile per disk. Tiles are outlined in the
+// colour of the disk's status and filled from the bottom by the allocated
+// share of their top-level VDev.
+//
+// Parameters:
+//   - w: Destination of the image
+//   - pools: The pools to render, stacked vertically
+//
+// Returns:
+//   - error: Any error writing to w
+func WriteSVG(w io.Writer, pools []*zfs.Pool) error {
+	analyzer := &status.Analyzer{}
+
+	// Lay out all groups first to know the image size
+	var body strings.Builder
+	width, y := svgMinWidth, svgMargin
+	for _, pool := range pools {
+		poolWidth, poolHeight := writeSVGPool(&body, pool, analyzer, y)
+		width = max(width, poolWidth+2*svgMargin)
+		y += poolHeight + svgMargin
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		width, y, width, y)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, y)
+	sb.WriteString(body.String())
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeSVGPool draws one pool starting at y and returns its width and height.
+func writeSVGPool(sb *strings.Builder, pool *zfs.Pool, analyzer *status.Analyzer, y int) (int, int) {
+	worst := analyzer.GetPoolWorstStatus(pool)
+	summary := string(worst)
+	if pool.Size > 0 {
+		summary += fmt.Sprintf(" · %s / %s (%.0f%%)",
+			utils.FormatBytes(pool.Allocated), utils.FormatBytes(pool.Size), pool.CapacityPercent())
+	}
+	if errors := analyzer.GetPoolErrorCount(pool); errors > 0 {
+		summary += fmt.Sprintf(" · %d errors", errors)
+	}
+
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="18" font-weight="bold" fill="#1f3f7f">%s</text>`+"\n",
+		svgMargin, y+18, svgEscape(pool.Name))
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="13" fill="%s">%s</text>`+"\n",
+		svgMargin, y+36, colorsFor(worst).stroke, svgEscape(summary))
+
+	width := 0
+	top := y + svgPoolHeader
+	for _, group := range svgGroups(pool) {
+		w, h := writeSVGGroup(sb, group, analyzer, svgMargin, top)
+		width = max(width, w)
+		top += h + svgTileGap
+	}
+	return width, top - y
+}
+
+// svgGroups returns the boxes to draw for a pool: its data top-level VDevs
+// followed by cache and log devices.
+func svgGroups(pool *zfs.Pool) []svgGroup {
+	var groups []svgGroup
+	for _, vdev := range zfs.TopLevel(pool.RootVDev) {
+		ratio := pool.CapacityPercent() / 100
+		if vdev.Size > 0 && vdev.Allocated > 0 {
+			ratio = float64(vdev.Allocated) / float64(vdev.Size)
+		}
+		groups = append(groups, svgGroup{label: vdev.Name, vdev: vdev, disks: leaves(vdev), ratio: ratio})
+	}
+	for _, special := range []struct {
+		role string
+		vdev *zfs.VDev
+	}{{"cache", pool.Cache}, {"log", pool.Slog}} {
+		if special.vdev != nil {
+			label := special.role
+			if special.vdev.Name != special.role {
+				label += " " + special.vdev.Name
+			}
+			groups = append(groups, svgGroup{label: label, vdev: special.vdev, disks: leaves(special.vdev)})
+		}
+	}
+	return groups
+}
+
+// writeSVGGroup draws a top-level VDev box at x, y and returns its size.
+func writeSVGGroup(sb *strings.Builder, g svgGroup, analyzer *status.Analyzer, x, y int) (int, int) {
+	columns := min(len(g.disks), svgMaxColumns)
+	rows := (len(g.disks) + svgMaxColumns - 1) / svgMaxColumns
+	width := 2*svgBoxPadding + columns*svgTileWidth + (columns-1)*svgTileGap
+	height := svgBoxLabel + svgBoxPadding + rows*svgTileHeight + (rows-1)*svgTileGap
+	width = max(width, svgMinWidth-2*svgMargin)
+
+	worst := analyzer.GetVDevWorstStatus(g.vdev)
+	colors := colorsFor(worst)
+	fmt.Fprintf(sb, `<g class="vdev">`+"\n")
+	fmt.Fprintf(sb, `<rect x="%d" y="%d" width="%d" height="%d" rx="8" fill="#fafafa" stroke="%s" stroke-width="2"/>`+"\n",
+		x, y, width, height, colors.stroke)
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="13"><tspan font-weight="bold">%s</tspan> (%s) <tspan fill="%s">%s</tspan></text>`+"\n",
+		x+svgBoxPadding, y+16, svgEscape(g.label), svgEscape(g.vdev.Type), colors.stroke, worst)
+
+	for i, disk := range g.disks {
+		tx := x + svgBoxPadding + (i%svgMaxColumns)*(svgTileWidth+svgTileGap)
+		ty := y + svgBoxLabel + (i/svgMaxColumns)*(svgTileHeight+svgTileGap)
+		writeSVGTile(sb, disk, g.ratio, tx, ty)
+	}
+	sb.WriteString("</g>\n")
+	return width, height
+}
+
+// writeSVGTile draws a single disk tile.
+func writeSVGTile(sb *strings.Builder, disk *zfs.VDev, ratio float64, x, y int) {
+	colors := colorsFor(disk.Status)
+	fill := int(float64(svgTileHeight-4) * min(max(ratio, 0), 1))
+
+	title := fmt.Sprintf("%s %s", disk.Name, disk.Status)
+	if errors := disk.ReadErrors + disk.WriteErrors + disk.ChecksumErrors; errors > 0 {
+		title += fmt.Sprintf(" R:%d W:%d C:%d", disk.ReadErrors, disk.WriteErrors, disk.ChecksumErrors)
+	}
+
+	fmt.Fprintf(sb, `<g class="disk"><title>%s</title>`+"\n", svgEscape(title))
+	fmt.Fprintf(sb, `<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="#ffffff" stroke="%s" stroke-width="2"/>`+"\n",
+		x, y, svgTileWidth, svgTileHeight, colors.stroke)
+	if fill > 0 {
+		fmt.Fprintf(sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+			x+2, y+svgTileHeight-2-fill, svgTileWidth-4, fill, colors.fill)
+	}
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="11" text-anchor="middle">%s</text>`+"\n",
+		x+svgTileWidth/2, y+16, svgEscape(shorten(disk.Name, svgNameChars)))
+	if disk.Size > 0 {
+		fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="10" text-anchor="middle" fill="#555555">%s</text>`+"\n",
+			x+svgTileWidth/2, y+32, utils.FormatBytes(disk.Size))
+	}
+	if disk.Status != zfs.VDevStatusOnline {
+		fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="9" font-weight="bold" text-anchor="middle" fill="%s">%s</text>`+"\n",
+			x+svgTileWidth/2, y+svgTileHeight-8, colors.stroke, svgEscape(string(disk.Status)))
+	}
+	sb.WriteString("</g>\n")
+}
+
+// leaves returns the leaf VDevs (disks) below vdev, or vdev itself if it
+// has no children.
+func leaves(vdev *zfs.VDev) []*zfs.VDev {
+	if len(vdev.Children) == 0 {
+		return []*zfs.VDev{vdev}
+	}
+	var result []*zfs.VDev
+	for _, child := range vdev.Children {
+		result = append(result, leaves(child)...)
+	}
+	return result
+}
+
+// shorten truncates s to n runes, marking the cut with an ellipsis.
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// svgEscape escapes text for use in SVG content and attribute values.
+func svgEscape(s string) string {
+	return html.EscapeString(s)
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// widePool builds a pool of four 12-disk raidz2 vdevs with one faulted disk.
+func widePool() *zfs.Pool {
+	root := &zfs.VDev{Name: "wide", Type: zfs.VDevTypeRoot, Status: zfs.VDevStatusOnline}
+	for v := 0; v < 4; v++ {
+		vdev := &zfs.VDev{
+			Name:      fmt.Sprintf("raidz2-%d", v),
+			Type:      "raidz2",
+			Status:    zfs.VDevStatusOnline,
+			Size:      120 << 40,
+			Allocated: uint64(30*(v+1)) << 40,
+		}
+		for d := 0; d < 12; d++ {
+			vdev.Children = append(vdev.Children, &zfs.VDev{
+				Name:   fmt.Sprintf("scsi-35000c500a1b2%02d%02d", v, d),
+				Type:   "disk",
+				Status: zfs.VDevStatusOnline,
+				Size:   10 << 40,
+			})
+		}
+		root.Children = append(root.Children, vdev)
+	}
+	root.Children[2].Status = zfs.VDevStatusDegraded
+	root.Children[2].Children[5].Status = zfs.VDevStatusFaulted
+	root.Children[2].Children[5].ChecksumErrors = 31
+
+	return &zfs.Pool{
+		Name:      "wide",
+		Status:    zfs.VDevStatusDegraded,
+		Size:      480 << 40,
+		Allocated: 300 << 40,
+		RootVDev:  root,
+	}
+}
+
+func TestWriteSVGGolden(t *testing.T) {
+	tests := map[string][]*zfs.Pool{
+		"small.svg": testPools(),
+		"wide.svg":  {widePool()},
+	}
+
+	for name, pools := range tests {
+		t.Run(name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := WriteSVG(&sb, pools); err != nil {
+				t.Fatal(err)
+			}
+			got := sb.String()
+
+			// The output must be well-formed XML to embed in reports
+			decoder := xml.NewDecoder(strings.NewReader(got))
+			for {
+				if _, err := decoder.Token(); err != nil {
+					if err.Error() != "EOF" {
+						t.Fatalf("invalid XML: %v", err)
+					}
+					break
+				}
+			}
+
+			golden := filepath.Join("testdata", name)
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s; run go test ./internal/export -update and review the diff", golden)
+			}
+		})
+	}
+}
+
+func TestWriteSVGSelfContained(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteSVG(&sb, []*zfs.Pool{widePool()}); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	if strings.Contains(out, "href") || strings.Contains(out, "<image") || strings.Contains(out, "<script") {
+		t.Error("SVG references external resources")
+	}
+	if n := strings.Count(out, `<g class="disk">`); n != 48 {
+		t.Errorf("rendered %d disk tiles, want 48", n)
+	}
+}
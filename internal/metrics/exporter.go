@@ -0,0 +1,260 @@
+// Package metrics exposes collected ZFS state in the Prometheus text
+// exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// namespace prefixes every metric name.
+const namespace = "vizfsulizer_"
+
+// contentType is the media type of the Prometheus text format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// healthStates are the states exported for the pool health enum gauge.
+// Exactly one of them is set to 1 for every pool.
+var healthStates = []zfs.VDevStatus{
+	zfs.VDevStatusOnline,
+	zfs.VDevStatusDegraded,
+	zfs.VDevStatusFaulted,
+}
+
+// Handler returns an http.Handler serving /metrics for the given source.
+// Wrap the source in source.Cached to bound how often scrapes collect.
+//
+// Parameters:
+//   - src: The source to collect snapshots from on each scrape
+//
+// Returns:
+//   - http.Handler: A handler writing the Prometheus text format
+func Handler(src source.Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+
+		snap, err := src.Collect(r.Context())
+		mw := &writer{w: w}
+		mw.family("collect_success", "gauge", "Whether the last collection of ZFS state succeeded.")
+		if err != nil {
+			mw.sample("collect_success", nil, 0)
+			return
+		}
+		mw.sample("collect_success", nil, 1)
+		Write(w, snap)
+	})
+}
+
+// Write renders a snapshot in the Prometheus text format.
+//
+// Parameters:
+//   - w: Destination of the rendered metrics
+//   - snap: The snapshot to render
+func Write(w io.Writer, snap *source.Snapshot) {
+	mw := &writer{w: w}
+	analyzer := &status.Analyzer{}
+
+	mw.family("collect_timestamp_seconds", "gauge", "Unix time the exported state was collected.")
+	mw.sample("collect_timestamp_seconds", nil, float64(snap.CollectedAt.Unix()))
+
+	writePools(mw, snap.Pools, analyzer)
+	writeVDevs(mw, snap.Pools)
+	if snap.ARC != nil {
+		writeARC(mw, snap.ARC)
+	}
+}
+
+// writePools renders pool level metrics.
+func writePools(mw *writer, pools []*zfs.Pool, analyzer *status.Analyzer) {
+	mw.family("pool_health", "gauge", "Pool health including cache and log devices, 1 for the current state.")
+	for _, pool := range pools {
+		health := analyzer.GetPoolWorstStatus(pool)
+		states := healthStates
+		if !containsStatus(states, health) {
+			states = append(append([]zfs.VDevStatus{}, states...), health)
+		}
+		for _, state := range states {
+			mw.sample("pool_health", labels{"pool", pool.Name, "state", string(state)}, boolValue(state == health))
+		}
+	}
+
+	gauges := []struct {
+		name, help string
+		value      func(*zfs.Pool) float64
+	}{
+		{"pool_size_bytes", "Raw size of the pool.", func(p *zfs.Pool) float64 { return float64(p.Size) }},
+		{"pool_allocated_bytes", "Allocated space of the pool.", func(p *zfs.Pool) float64 { return float64(p.Allocated) }},
+		{"pool_free_bytes", "Free space of the pool.", func(p *zfs.Pool) float64 { return float64(p.Free) }},
+		{"pool_capacity_ratio", "Allocated share of the pool size.", func(p *zfs.Pool) float64 { return p.CapacityPercent() / 100 }},
+		{"pool_fragmentation_ratio", "Free space fragmentation of the pool.", func(p *zfs.Pool) float64 { return float64(p.Fragmentation) / 100 }},
+	}
+	for _, g := range gauges {
+		mw.family(g.name, "gauge", g.help)
+		for _, pool := range pools {
+			mw.sample(g.name, labels{"pool", pool.Name}, g.value(pool))
+		}
+	}
+
+	mw.family("pool_scan_active", "gauge", "Whether a scrub or resilver is running.")
+	for _, pool := range pools {
+		if pool.Scan != nil {
+			mw.sample("pool_scan_active", labels{"pool", pool.Name, "function", pool.Scan.Function},
+				boolValue(pool.Scan.State == "scanning"))
+		}
+	}
+
+	mw.family("pool_scan_progress_ratio", "gauge", "Progress of the current or last scrub or resilver.")
+	for _, pool := range pools {
+		if pool.Scan != nil && pool.Scan.ToExamine > 0 {
+			mw.sample("pool_scan_progress_ratio", labels{"pool", pool.Name, "function", pool.Scan.Function},
+				float64(pool.Scan.Examined)/float64(pool.Scan.ToExamine))
+		}
+	}
+
+	mw.family("pool_scan_end_timestamp_seconds", "gauge", "Unix time the last scrub or resilver finished.")
+	for _, pool := range pools {
+		if pool.Scan != nil && !pool.Scan.End.IsZero() {
+			mw.sample("pool_scan_end_timestamp_seconds", labels{"pool", pool.Name, "function", pool.Scan.Function},
+				float64(pool.Scan.End.Unix()))
+		}
+	}
+}
+
+// vdevEntry is a VDev together with the pool and role it belongs to.
+type vdevEntry struct {
+	pool string
+	role string
+	vdev *zfs.VDev
+}
+
+// writeVDevs renders per-VDev error counters and I/O rates.
+func writeVDevs(mw *writer, pools []*zfs.Pool) {
+	var entries []vdevEntry
+	for _, pool := range pools {
+		roots := []vdevEntry{{pool.Name, "data", pool.RootVDev}, {pool.Name, "cache", pool.Cache}, {pool.Name, "log", pool.Slog}}
+		for _, root := range roots {
+			role := root.role
+			walkVDev(root.vdev, func(v *zfs.VDev) {
+				entries = append(entries, vdevEntry{pool.Name, role, v})
+			})
+		}
+	}
+
+	vdevLabels := func(e vdevEntry, extra ...string) labels {
+		return append(labels{"pool", e.pool, "role", e.role, "vdev", e.vdev.Name, "type", e.vdev.Type}, extra...)
+	}
+
+	mw.family("vdev_errors_total", "counter", "Errors reported for the vdev since import or the last clear.")
+	for _, e := range entries {
+		mw.sample("vdev_errors_total", vdevLabels(e, "kind", "read"), float64(e.vdev.ReadErrors))
+		mw.sample("vdev_errors_total", vdevLabels(e, "kind", "write"), float64(e.vdev.WriteErrors))
+		mw.sample("vdev_errors_total", vdevLabels(e, "kind", "checksum"), float64(e.vdev.ChecksumErrors))
+	}
+
+	// Rates are only exported where they were sampled: zero would pass
+	// for an idle vdev
+	var sampled []vdevEntry
+	for _, e := range entries {
+		if e.vdev.IO.Sampled {
+			sampled = append(sampled, e)
+		}
+	}
+	if len(sampled) == 0 {
+		return
+	}
+	mw.family("vdev_operations_per_second", "gauge", "I/O operations per second.")
+	for _, e := range sampled {
+		mw.sample("vdev_operations_per_second", vdevLabels(e, "op", "read"), e.vdev.IO.ReadOps)
+		mw.sample("vdev_operations_per_second", vdevLabels(e, "op", "write"), e.vdev.IO.WriteOps)
+	}
+
+	mw.family("vdev_bandwidth_bytes_per_second", "gauge", "I/O bandwidth in bytes per second.")
+	for _, e := range sampled {
+		mw.sample("vdev_bandwidth_bytes_per_second", vdevLabels(e, "op", "read"), e.vdev.IO.ReadBytes)
+		mw.sample("vdev_bandwidth_bytes_per_second", vdevLabels(e, "op", "write"), e.vdev.IO.WriteBytes)
+	}
+}
+
+// writeARC renders ARC statistics.
+func writeARC(mw *writer, arc *zfs.ARCStats) {
+	mw.family("arc_size_bytes", "gauge", "Current size of the ARC.")
+	mw.sample("arc_size_bytes", nil, float64(arc.Size))
+	mw.family("arc_target_size_bytes", "gauge", "Target size of the ARC.")
+	mw.sample("arc_target_size_bytes", nil, float64(arc.TargetSize))
+	mw.family("arc_max_size_bytes", "gauge", "Maximum size of the ARC.")
+	mw.sample("arc_max_size_bytes", nil, float64(arc.MaxSize))
+	mw.family("arc_hits_total", "counter", "ARC lookups that hit.")
+	mw.sample("arc_hits_total", nil, float64(arc.Hits))
+	mw.family("arc_misses_total", "counter", "ARC lookups that missed.")
+	mw.sample("arc_misses_total", nil, float64(arc.Misses))
+}
+
+// walkVDev calls fn for vdev and all of its descendants. A nil vdev is skipped.
+func walkVDev(vdev *zfs.VDev, fn func(*zfs.VDev)) {
+	if vdev == nil {
+		return
+	}
+	fn(vdev)
+	for _, child := range vdev.Children {
+		walkVDev(child, fn)
+	}
+}
+
+func containsStatus(states []zfs.VDevStatus, s zfs.VDevStatus) bool {
+	for _, state := range states {
+		if state == s {
+			return true
+		}
+	}
+	return false
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// labels is a flat list of alternating label names and values.
+type labels []string
+
+// writer writes metric families and samples in the text format.
+type writer struct {
+	w io.Writer
+}
+
+// family writes the HELP and TYPE header of a metric family.
+func (mw *writer) family(name, kind, help string) {
+	fmt.Fprintf(mw.w, "# HELP %s%s %s\n# TYPE %s%s %s\n", namespace, name, help, namespace, name, kind)
+}
+
+// sample writes a single sample line.
+func (mw *writer) sample(name string, l labels, value float64) {
+	var sb strings.Builder
+	sb.WriteString(namespace + name)
+	if len(l) > 0 {
+		sb.WriteString("{")
+		for i := 0; i+1 < len(l); i += 2 {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(l[i] + `="` + escapeLabel(l[i+1]) + `"`)
+		}
+		sb.WriteString("}")
+	}
+	sb.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+	io.WriteString(mw.w, sb.String())
+}
+
+// escapeLabel escapes a label value as required by the text format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
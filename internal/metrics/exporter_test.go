@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// staticSource returns a fixed snapshot or error.
+type staticSource struct {
+	snap *source.Snapshot
+	err  error
+}
+
+func (s staticSource) Collect(ctx context.Context) (*source.Snapshot, error) {
+	return s.snap, s.err
+}
+
+func scrape(t *testing.T, src source.Source) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler(src).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("Content-Type = %q, want %q", ct, contentType)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestHandler(t *testing.T) {
+	snap := &source.Snapshot{
+		CollectedAt: time.Unix(1700000000, 0),
+		Pools: []*zfs.Pool{{
+			Name:      "tank",
+			Size:      1000,
+			Allocated: 250,
+			Free:      750,
+			Scan:      &zfs.ScanInfo{Function: "resilver", State: "scanning", Examined: 50, ToExamine: 200},
+			RootVDev: &zfs.VDev{
+				Name:   "mirror-0",
+				Type:   "mirror",
+				Status: zfs.VDevStatusOnline,
+				Children: []*zfs.VDev{
+					{Name: "sda", Type: "disk", Status: zfs.VDevStatusDegraded, ChecksumErrors: 4,
+						IO: zfs.IOStats{Sampled: true, ReadOps: 12.5}},
+					{Name: `we"ird`, Type: "disk", Status: zfs.VDevStatusOnline},
+				},
+			},
+		}, {
+			// zpool status printed no config section
+			Name:   "gone",
+			Status: zfs.VDevStatusFaulted,
+		}},
+		ARC: &zfs.ARCStats{Size: 1 << 30, Hits: 90, Misses: 10},
+	}
+
+	body := scrape(t, staticSource{snap: snap})
+	for _, want := range []string{
+		"vizfsulizer_collect_success 1\n",
+		"vizfsulizer_collect_timestamp_seconds 1.7e+09\n",
+		"# TYPE vizfsulizer_pool_health gauge\n",
+		`vizfsulizer_pool_health{pool="tank",state="ONLINE"} 0` + "\n",
+		`vizfsulizer_pool_health{pool="tank",state="DEGRADED"} 1` + "\n",
+		`vizfsulizer_pool_capacity_ratio{pool="tank"} 0.25` + "\n",
+		`vizfsulizer_pool_health{pool="gone",state="FAULTED"} 1` + "\n",
+		`vizfsulizer_pool_scan_active{pool="tank",function="resilver"} 1` + "\n",
+		`vizfsulizer_pool_scan_progress_ratio{pool="tank",function="resilver"} 0.25` + "\n",
+		`vizfsulizer_vdev_errors_total{pool="tank",role="data",vdev="sda",type="disk",kind="checksum"} 4` + "\n",
+		`vizfsulizer_vdev_operations_per_second{pool="tank",role="data",vdev="sda",type="disk",op="read"} 12.5` + "\n",
+		`vdev="we\"ird"`,
+		"vizfsulizer_arc_size_bytes 1.073741824e+09\n",
+		"vizfsulizer_arc_misses_total 10\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+	if strings.Contains(body, `operations_per_second{pool="tank",role="data",vdev="we\"ird"`) {
+		t.Error("unsampled I/O exported as idle")
+	}
+}
+
+func TestHandlerCollectFailure(t *testing.T) {
+	body := scrape(t, staticSource{err: errors.New("zpool not found")})
+	if !strings.Contains(body, "vizfsulizer_collect_success 0\n") {
+		t.Errorf("failed collection not reported:\n%s", body)
+	}
+	if strings.Contains(body, "pool_health") {
+		t.Errorf("failed collection exported pool metrics:\n%s", body)
+	}
+}
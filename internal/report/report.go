@@ -0,0 +1,335 @@
+// Package report generates storage reports of a host in Markdown or HTML,
+// for periodic storage reviews and wiki pages.
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/export"
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// topConsumers is the number of datasets listed as top space consumers.
+const topConsumers = 10
+
+// maxPastScans is the number of past scans listed per pool.
+const maxPastScans = 10
+
+// Report is the content of a storage report, independent of its format.
+type Report struct {
+	Host        string
+	GeneratedAt time.Time
+	CollectedAt time.Time
+	Health      zfs.VDevStatus
+	Pools       []Pool
+	Warnings    []status.Warning
+	Findings    []status.Finding // Layout findings of the linter
+	Consumers   []Consumer
+
+	// Scans are the past scans of all pools from their history, newest
+	// first; ScanHistory is false if no history could be read, in which
+	// case only the last scan of each pool is known
+	Scans       []Scan
+	ScanHistory bool
+}
+
+// Pool is the report section of a single pool.
+type Pool struct {
+	Name          string
+	Status        zfs.VDevStatus
+	Size          string
+	Allocated     string
+	Free          string
+	Capacity      float64
+	Fragmentation int
+	Errors        uint64
+	Scan          string
+	ScanErrors    uint64
+	Datasets      int
+	Snapshots     int
+	SnapshotsUsed string
+
+	// Tree is the topology as indented text
+	Tree string
+
+	// SVG is the topology as an inline image, only set for HTML reports
+	SVG htmltemplate.HTML
+
+	pool *zfs.Pool
+}
+
+// Scan is a past scrub or resilver of a pool.
+type Scan struct {
+	Pool     string
+	Function string
+	Started  time.Time
+	Took     string // "-" if the scan has not ended
+	Result   string // "finished", "canceled" or "scanning"
+	Errors   uint64
+}
+
+// Consumer is a dataset listed among the top space consumers.
+type Consumer struct {
+	Name      string
+	Used      string
+	Share     float64 // percentage of the pool size
+	Snapshots int
+}
+
+// Build assembles a report from a snapshot.
+//
+// Parameters:
+//   - snap: The collected state of the host
+//   - history: The zpool history of each pool by name, from which past
+//     scans are listed; nil if the source provides no history
+//   - now: The time the report is generated, used for scan ages
+//   - analyzer: Judges the health and layout of the pools
+//
+// Returns:
+//   - *Report: The report content ready for rendering
+func Build(snap *source.Snapshot, history map[string][]*zfs.HistoryEntry, now time.Time, analyzer *status.Analyzer) *Report {
+	r := &Report{
+		Host:        snap.Host,
+		GeneratedAt: now,
+		CollectedAt: snap.CollectedAt,
+		Health:      zfs.VDevStatusOnline,
+		ScanHistory: history != nil,
+	}
+
+	datasets := make(map[string]*zfs.Dataset)
+	for _, ds := range snap.Datasets {
+		datasets[ds.Name] = ds
+	}
+
+	var consumers []Consumer
+	for _, pool := range snap.Pools {
+		p := Pool{
+			Name:          pool.Name,
+			Status:        analyzer.GetPoolWorstStatus(pool),
+			Size:          utils.FormatBytes(pool.Size),
+			Allocated:     utils.FormatBytes(pool.Allocated),
+			Free:          utils.FormatBytes(pool.Free),
+			Capacity:      pool.CapacityPercent(),
+			Fragmentation: pool.Fragmentation,
+			Errors:        analyzer.GetPoolErrorCount(pool),
+			Scan:          describeScan(pool.Scan, now),
+			Tree:          treeText(pool, analyzer),
+			pool:          pool,
+		}
+		if pool.Scan != nil {
+			p.ScanErrors = pool.Scan.Errors
+		}
+
+		var snapshotsUsed uint64
+		walkDatasets(datasets[pool.Name], func(ds *zfs.Dataset, depth int) {
+			p.Datasets++
+			p.Snapshots += ds.Snapshots
+			snapshotsUsed += ds.SnapshotsUsed
+			// The pool's root dataset always uses everything
+			if depth > 0 && pool.Size > 0 {
+				consumers = append(consumers, Consumer{
+					Name:      ds.Name,
+					Used:      utils.FormatBytes(ds.Used),
+					Share:     float64(ds.Used) / float64(pool.Size) * 100,
+					Snapshots: ds.Snapshots,
+				})
+			}
+		})
+		p.SnapshotsUsed = utils.FormatBytes(snapshotsUsed)
+
+		r.Health = analyzer.GetWorseStatus(r.Health, p.Status)
+		r.Pools = append(r.Pools, p)
+		r.Warnings = append(r.Warnings, analyzer.GetPoolWarnings(pool)...)
+		r.Findings = append(r.Findings, analyzer.LintPool(pool)...)
+		r.Scans = append(r.Scans, pastScans(pool.Name, history[pool.Name])...)
+	}
+
+	sort.SliceStable(r.Warnings, func(i, j int) bool { return r.Warnings[i].Severity > r.Warnings[j].Severity })
+	sort.SliceStable(r.Findings, func(i, j int) bool { return r.Findings[i].Severity > r.Findings[j].Severity })
+	sort.SliceStable(r.Scans, func(i, j int) bool { return r.Scans[i].Started.After(r.Scans[j].Started) })
+	sort.SliceStable(consumers, func(i, j int) bool { return consumers[i].Share > consumers[j].Share })
+	if len(consumers) > topConsumers {
+		consumers = consumers[:topConsumers]
+	}
+	r.Consumers = consumers
+	return r
+}
+
+// WriteMarkdown renders the report as Markdown.
+//
+// Parameters:
+//   - w: Destination of the report
+//   - r: The report to render
+//
+// Returns:
+//   - error: Any error rendering or writing the report
+func WriteMarkdown(w io.Writer, r *Report) error {
+	tmpl, err := template.New("report.md.tmpl").Funcs(template.FuncMap{
+		"pct":  formatPercent,
+		"time": formatTime,
+		"cell": markdownCell,
+	}).ParseFS(templates, "templates/report.md.tmpl")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, r)
+}
+
+// WriteHTML renders the report as a self-contained HTML page with inline
+// styles and SVG topology images.
+//
+// Parameters:
+//   - w: Destination of the report
+//   - r: The report to render
+//
+// Returns:
+//   - error: Any error rendering or writing the report
+func WriteHTML(w io.Writer, r *Report) error {
+	for i := range r.Pools {
+		var sb strings.Builder
+		if err := export.WriteSVG(&sb, []*zfs.Pool{r.Pools[i].pool}); err != nil {
+			return err
+		}
+		// WriteSVG escapes all text it embeds
+		r.Pools[i].SVG = htmltemplate.HTML(sb.String())
+	}
+
+	tmpl, err := htmltemplate.New("report.html.tmpl").Funcs(htmltemplate.FuncMap{
+		"pct":  formatPercent,
+		"time": formatTime,
+	}).ParseFS(templates, "templates/report.html.tmpl")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, r)
+}
+
+// describeScan summarises the last scrub or resilver of a pool.
+func describeScan(scan *zfs.ScanInfo, now time.Time) string {
+	if scan == nil {
+		return "never scrubbed"
+	}
+	switch scan.State {
+	case "scanning":
+		progress := ""
+		if scan.ToExamine > 0 {
+			progress = fmt.Sprintf(", %.1f%% done", float64(scan.Examined)/float64(scan.ToExamine)*100)
+		}
+		return fmt.Sprintf("%s in progress since %s%s", scan.Function, formatTime(scan.Start), progress)
+	case "finished":
+		return fmt.Sprintf("%s finished %s (%d days ago, took %s, %d errors)", scan.Function,
+			formatTime(scan.End), int(now.Sub(scan.End).Hours()/24),
+			formatDuration(scan.End.Sub(scan.Start)), scan.Errors)
+	default:
+		return fmt.Sprintf("%s %s %s", scan.Function, scan.State, formatTime(scan.End))
+	}
+}
+
+// pastScans lists the last scans found in the history of a pool, newest
+// first.
+func pastScans(pool string, history []*zfs.HistoryEntry) []Scan {
+	records := zfs.ScanHistory(history)
+	if len(records) > maxPastScans {
+		records = records[len(records)-maxPastScans:]
+	}
+	scans := make([]Scan, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		took := "-"
+		if !rec.End.IsZero() {
+			took = formatDuration(rec.End.Sub(rec.Start))
+		}
+		scans = append(scans, Scan{
+			Pool:     pool,
+			Function: rec.Function,
+			Started:  rec.Start,
+			Took:     took,
+			Result:   rec.State,
+			Errors:   rec.Errors,
+		})
+	}
+	return scans
+}
+
+// treeText renders the topology of a pool as an indented text tree.
+func treeText(pool *zfs.Pool, analyzer *status.Analyzer) string {
+	var sb strings.Builder
+	for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+		if vdev != nil {
+			writeTree(&sb, vdev, "", "", analyzer)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// writeTree writes a VDev line and its children with box-drawing branches.
+func writeTree(sb *strings.Builder, vdev *zfs.VDev, prefix, childPrefix string, analyzer *status.Analyzer) {
+	fmt.Fprintf(sb, "%s%s (%s) %s", prefix, vdev.Name, vdev.Type, analyzer.GetVDevWorstStatus(vdev))
+	if vdev.Size > 0 {
+		fmt.Fprintf(sb, " %s", utils.FormatBytes(vdev.Size))
+	}
+	if vdev.ReadErrors+vdev.WriteErrors+vdev.ChecksumErrors > 0 {
+		fmt.Fprintf(sb, " R:%d W:%d C:%d", vdev.ReadErrors, vdev.WriteErrors, vdev.ChecksumErrors)
+	}
+	sb.WriteString("\n")
+
+	for i, child := range vdev.Children {
+		if i == len(vdev.Children)-1 {
+			writeTree(sb, child, childPrefix+"└─ ", childPrefix+"   ", analyzer)
+		} else {
+			writeTree(sb, child, childPrefix+"├─ ", childPrefix+"│  ", analyzer)
+		}
+	}
+}
+
+// walkDatasets calls fn for a dataset and all of its descendants.
+func walkDatasets(ds *zfs.Dataset, fn func(*zfs.Dataset, int)) {
+	var walk func(*zfs.Dataset, int)
+	walk = func(ds *zfs.Dataset, depth int) {
+		fn(ds, depth)
+		for _, child := range ds.Children {
+			walk(child, depth+1)
+		}
+	}
+	if ds != nil {
+		walk(ds, 0)
+	}
+}
+
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%.1f%%", v)
+}
+
+// formatDuration formats a scan duration in hours and minutes.
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// markdownCell escapes pipes so a value can be placed in a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+func testSnapshot(now time.Time) *source.Snapshot {
+	return &source.Snapshot{
+		Host:        "store01",
+		CollectedAt: now,
+		Pools: []*zfs.Pool{{
+			Name:      "tank",
+			Size:      100 << 30,
+			Allocated: 60 << 30,
+			Free:      40 << 30,
+			Scan: &zfs.ScanInfo{
+				Function: "scrub",
+				State:    "finished",
+				Start:    now.Add(-50 * time.Hour),
+				End:      now.Add(-48 * time.Hour),
+			},
+			RootVDev: &zfs.VDev{
+				Name:   "mirror-0",
+				Type:   "mirror",
+				Status: zfs.VDevStatusDegraded,
+				Children: []*zfs.VDev{
+					{Name: "sda", Type: "disk", Status: zfs.VDevStatusFaulted, ReadErrors: 5},
+					{Name: "sdb", Type: "disk", Status: zfs.VDevStatusOnline},
+				},
+			},
+		}},
+		Datasets: []*zfs.Dataset{{
+			Name: "tank",
+			Used: 60 << 30,
+			Children: []*zfs.Dataset{
+				{Name: "tank/small", Used: 10 << 30, Snapshots: 3},
+				{Name: "tank/big|pipe", Used: 45 << 30, Snapshots: 7, SnapshotsUsed: 5 << 30},
+			},
+		}},
+	}
+}
+
+// testHistory returns a history of tank with a canceled and a finished
+// scrub.
+func testHistory(now time.Time) map[string][]*zfs.HistoryEntry {
+	entry := func(ago time.Duration, text string) *zfs.HistoryEntry {
+		return &zfs.HistoryEntry{Time: now.Add(-ago), Kind: zfs.HistoryInternal, Text: text}
+	}
+	return map[string][]*zfs.HistoryEntry{"tank": {
+		entry(40*24*time.Hour, "scan setup func=1 mintxg=0 maxtxg=100"),
+		entry(40*24*time.Hour-time.Hour, "scan cancelled"),
+		{Time: now.Add(-3 * 24 * time.Hour), Kind: zfs.HistoryCommand, Text: "zpool scrub tank"},
+		entry(3*24*time.Hour, "scan setup func=1 mintxg=0 maxtxg=200"),
+		entry(2*24*time.Hour, "scan done errors=3"),
+	}}
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := Build(testSnapshot(now), testHistory(now), now, &status.Analyzer{})
+
+	if r.Health != zfs.VDevStatusFaulted {
+		t.Errorf("health = %s, want FAULTED", r.Health)
+	}
+	if len(r.Warnings) == 0 || r.Warnings[0].Device != "sda" || r.Warnings[0].Message != "device is FAULTED" {
+		t.Errorf("critical warning not first: %+v", r.Warnings)
+	}
+	if p := r.Pools[0]; p.Datasets != 3 || p.Snapshots != 10 || p.SnapshotsUsed != "5.0G" {
+		t.Errorf("snapshot counts = %+v", p)
+	}
+	if len(r.Consumers) != 2 || r.Consumers[0].Name != "tank/big|pipe" {
+		t.Errorf("consumers not sorted by usage: %+v", r.Consumers)
+	}
+	if want := "└─ sdb (disk) ONLINE"; !strings.Contains(r.Pools[0].Tree, want) {
+		t.Errorf("tree missing %q:\n%s", want, r.Pools[0].Tree)
+	}
+	if !r.ScanHistory || len(r.Scans) != 2 || r.Scans[0].Result != "finished" || r.Scans[0].Errors != 3 ||
+		r.Scans[0].Took != "24h00m" || r.Scans[1].Result != "canceled" {
+		t.Errorf("scans = %+v", r.Scans)
+	}
+
+	// The analyzer's limits apply: the pool is 60% full
+	for _, f := range r.Findings {
+		if f.Check == "capacity" {
+			t.Errorf("capacity finding below the default limit: %+v", f)
+		}
+	}
+	r = Build(testSnapshot(now), testHistory(now), now, &status.Analyzer{LintCapacity: 50})
+	if len(r.Findings) == 0 || r.Findings[0].Check != "capacity" {
+		t.Errorf("configured lint capacity not applied: %+v", r.Findings)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var sb strings.Builder
+	if err := WriteMarkdown(&sb, Build(testSnapshot(now), testHistory(now), now, &status.Analyzer{})); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# Storage report: store01",
+		"| tank | FAULTED | 100.0G | 60.0G | 40.0G | 60.0% | 0% | 5 |",
+		"| critical | tank | sda | device is FAULTED |",
+		"| info | tank | - | 2 disks referenced by sdX names: sda, sdb | Kernel names change",
+		"scrub finished 2024-02-28 12:00 (2 days ago, took 2h00m, 0 errors)",
+		"| tank | scrub | 2024-02-27 12:00 | 24h00m | finished | 3 |",
+		"| tank | scrub | 2024-01-21 12:00 | 1h00m | canceled | 0 |",
+		`| tank/big\|pipe | 45.0G | 45.0% | 7 |`,
+		"```text\nmirror-0 (mirror) FAULTED",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	now := time.Now()
+	var sb strings.Builder
+	if err := WriteHTML(&sb, Build(testSnapshot(now), nil, now, &status.Analyzer{})); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"<title>Storage report: store01</title>",
+		`<td class="FAULTED">FAULTED</td>`,
+		"Only the last scan of each pool is shown",
+		"<svg xmlns=",
+		"tank/big|pipe",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	if strings.Contains(out, "&lt;svg") {
+		t.Error("SVG was escaped instead of embedded")
+	}
+}
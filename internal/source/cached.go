@@ -0,0 +1,54 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cached wraps a Source and reuses its last result for a minimum interval.
+// Concurrent callers share a single collection, so frequent or parallel
+// readers such as metric scrapes never run the underlying commands more
+// often than once per interval.
+type Cached struct {
+	src         Source
+	minInterval time.Duration
+	now         func() time.Time
+
+	mu   sync.Mutex
+	last *Snapshot
+	err  error
+	at   time.Time
+}
+
+// NewCached creates a Cached source.
+//
+// Parameters:
+//   - src: The source to collect from
+//   - minInterval: Minimum time between two collections from src
+//
+// Returns:
+//   - *Cached: A caching source ready for use
+func NewCached(src Source, minInterval time.Duration) *Cached {
+	return &Cached{src: src, minInterval: minInterval, now: time.Now}
+}
+
+// Collect implements Source. It returns the cached snapshot (or error) if
+// the last collection happened less than the minimum interval ago. A
+// collection the caller cancelled, e.g. an aborted scrape, is not cached,
+// so that it fails no other caller.
+func (c *Cached) Collect(ctx context.Context) (*Snapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.at.IsZero() && c.now().Sub(c.at) < c.minInterval {
+		return c.last, c.err
+	}
+
+	snap, err := c.src.Collect(ctx)
+	if ctx.Err() != nil {
+		return snap, err
+	}
+	c.last, c.err, c.at = snap, err, c.now()
+	return c.last, c.err
+}
@@ -0,0 +1,98 @@
+package source
+
+import (
+	"context"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// DiskResolver finds the physical disk of a leaf VDev, such as a
+// disk.Resolver reading the host's sysfs.
+type DiskResolver interface {
+	// Resolve returns the identity of the disk a VDev name or path refers
+	// to, or an error if it is not a disk
+	Resolve(name string) (*zfs.DiskIdentity, error)
+}
+
+// EnclosureReader is implemented by DiskResolvers that can also read the
+// disk enclosures of the host, such as a disk.Resolver.
+type EnclosureReader interface {
+	// Enclosures returns the enclosures with the disks in their slots
+	Enclosures() ([]*zfs.Enclosure, error)
+}
+
+// Disks wraps a Source and fills in the disk identity of every leaf VDev
+// of the pools it collects. Leaves that do not resolve, such as file
+// VDevs, are left without one. If the resolver is an EnclosureReader, the
+// host's enclosures are added to the snapshot, and the identities tell
+// which slot each disk is in.
+type Disks struct {
+	src      Source
+	resolver DiskResolver
+}
+
+// NewDisks creates a Disks source. Disks are resolved again with every
+// collection, so that a replaced disk shows its new identity.
+//
+// Parameters:
+//   - src: The source to collect from; it must return a fresh snapshot
+//     with every collection, since the snapshot is filled in place
+//   - resolver: Resolves the leaves of the host src collects from
+//
+// Returns:
+//   - *Disks: A resolving source ready for use
+func NewDisks(src Source, resolver DiskResolver) *Disks {
+	return &Disks{src: src, resolver: resolver}
+}
+
+// Collect implements Source.
+func (d *Disks) Collect(ctx context.Context) (*Snapshot, error) {
+	snap, err := d.src.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if reader, ok := d.resolver.(EnclosureReader); ok {
+		// A host without readable enclosures is collected all the same
+		snap.Enclosures, _ = reader.Enclosures()
+	}
+	for _, pool := range snap.Pools {
+		for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+			d.resolve(vdev, snap.Enclosures)
+		}
+	}
+	return snap, nil
+}
+
+// resolve fills in the disk identities of the leaves of a VDev tree, with
+// the slots they are in.
+func (d *Disks) resolve(vdev *zfs.VDev, enclosures []*zfs.Enclosure) {
+	if vdev == nil {
+		return
+	}
+	if len(vdev.Children) == 0 {
+		name := vdev.Path
+		if name == "" {
+			name = vdev.Name
+		}
+		if id, err := d.resolver.Resolve(name); err == nil {
+			locate(id, enclosures)
+			vdev.Disk = id
+		}
+		return
+	}
+	for _, child := range vdev.Children {
+		d.resolve(child, enclosures)
+	}
+}
+
+// locate fills in the enclosure and slot of a disk.
+func locate(id *zfs.DiskIdentity, enclosures []*zfs.Enclosure) {
+	for _, enc := range enclosures {
+		for _, slot := range enc.Slots {
+			if slot.Device == id.Device {
+				id.Enclosure, id.Slot = enc.Number, slot.Number
+				return
+			}
+		}
+	}
+}
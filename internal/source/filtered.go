@@ -0,0 +1,139 @@
+package source
+
+import (
+	"context"
+	"strings"
+)
+
+// Filtered wraps a Source and hides the named pools, together with their
+// datasets, from everything that reads from it.
+type Filtered struct {
+	src    Source
+	ignore map[string]bool
+}
+
+// NewFiltered creates a Filtered source.
+//
+// Parameters:
+//   - src: The source to collect from
+//   - ignore: Names of the pools to hide
+//
+// Returns:
+//   - Source: A filtering source ready for use; a Streamer if src is one
+func NewFiltered(src Source, ignore []string) Source {
+	f := &Filtered{src: src, ignore: make(map[string]bool)}
+	for _, name := range ignore {
+		f.ignore[name] = true
+	}
+	if _, ok := src.(Streamer); ok {
+		return filteredStreamer{f}
+	}
+	return f
+}
+
+// Collect implements Source.
+func (f *Filtered) Collect(ctx context.Context) (*Snapshot, error) {
+	snap, err := f.src.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.filter(snap), nil
+}
+
+// filter hides the ignored pools of a snapshot. The snapshot is copied
+// rather than modified, since it may be shared through a Cached source.
+func (f *Filtered) filter(snap *Snapshot) *Snapshot {
+	if len(f.ignore) == 0 {
+		return snap
+	}
+
+	filtered := *snap
+	filtered.Pools = nil
+	for _, pool := range snap.Pools {
+		if !f.ignore[pool.Name] {
+			filtered.Pools = append(filtered.Pools, pool)
+		}
+	}
+	filtered.Datasets = nil
+	for _, ds := range snap.Datasets {
+		pool, _, _ := strings.Cut(ds.Name, "/")
+		if !f.ignore[pool] {
+			filtered.Datasets = append(filtered.Datasets, ds)
+		}
+	}
+	return &filtered
+}
+
+// filteredStreamer is a Filtered source of a Streamer, filtering every
+// update it pushes.
+type filteredStreamer struct {
+	*Filtered
+}
+
+// Stream implements Streamer.
+func (f filteredStreamer) Stream(ctx context.Context, updates chan<- Update) error {
+	unfiltered := make(chan Update)
+	done := make(chan error, 1)
+	go func() { done <- f.src.(Streamer).Stream(ctx, unfiltered) }()
+	for {
+		select {
+		case u := <-unfiltered:
+			if u.Snapshot != nil {
+				u.Snapshot = f.filter(u.Snapshot)
+			}
+			select {
+			case updates <- u:
+			case <-ctx.Done():
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
+
+// FilterEvents wraps an EventSource and hides the events of the named
+// pools, like NewFiltered hides the pools themselves.
+//
+// Parameters:
+//   - src: The event source to follow
+//   - ignore: Names of the pools whose events to hide
+//
+// Returns:
+//   - EventSource: A filtering event source, src itself if nothing is ignored
+func FilterEvents(src EventSource, ignore []string) EventSource {
+	if len(ignore) == 0 {
+		return src
+	}
+	f := filteredEvents{src: src, ignore: make(map[string]bool)}
+	for _, name := range ignore {
+		f.ignore[name] = true
+	}
+	return f
+}
+
+// filteredEvents is an EventSource returned by FilterEvents.
+type filteredEvents struct {
+	src    EventSource
+	ignore map[string]bool
+}
+
+// Events implements EventSource.
+func (f filteredEvents) Events(ctx context.Context, events chan<- EventUpdate) error {
+	unfiltered := make(chan EventUpdate)
+	done := make(chan error, 1)
+	go func() { done <- f.src.Events(ctx, unfiltered) }()
+	for {
+		select {
+		case u := <-unfiltered:
+			if u.Event != nil && f.ignore[u.Event.Pool] {
+				continue
+			}
+			select {
+			case events <- u:
+			case <-ctx.Done():
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
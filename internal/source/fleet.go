@@ -0,0 +1,86 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Host is a named source of a fleet.
+type Host struct {
+	// Name identifies the host in the fleet view, e.g. "nas1"
+	Name string
+
+	// Source collects the host's state
+	Source Source
+}
+
+// HostSnapshot is the result of collecting one host of a fleet.
+type HostSnapshot struct {
+	// Host is the name of the host
+	Host string
+
+	// Snapshot is the collected state, nil if Err is set
+	Snapshot *Snapshot
+
+	// Err is why the host could not be collected
+	Err error
+}
+
+// Fleet collects the state of many hosts at once. A host that fails or
+// times out does not hold up the others; its error is reported instead.
+type Fleet struct {
+	hosts    []Host
+	parallel int
+	timeout  time.Duration
+}
+
+// NewFleet creates a fleet.
+//
+// Parameters:
+//   - hosts: The hosts to collect, in display order
+//   - parallel: The most hosts collected at the same time, at least 1
+//   - timeout: The longest time a host may take, 0 for no limit
+//
+// Returns:
+//   - *Fleet: A fleet ready for use
+func NewFleet(hosts []Host, parallel int, timeout time.Duration) *Fleet {
+	return &Fleet{hosts: hosts, parallel: max(parallel, 1), timeout: timeout}
+}
+
+// Hosts returns the hosts of the fleet in display order.
+func (f *Fleet) Hosts() []Host {
+	return f.hosts
+}
+
+// Collect gathers a snapshot of every host.
+//
+// Parameters:
+//   - ctx: Cancels the collection of all hosts
+//
+// Returns:
+//   - []HostSnapshot: One result per host, in the order of Hosts
+func (f *Fleet) Collect(ctx context.Context) []HostSnapshot {
+	results := make([]HostSnapshot, len(f.hosts))
+	slots := make(chan struct{}, f.parallel)
+	var wg sync.WaitGroup
+	for i, host := range f.hosts {
+		wg.Add(1)
+		go func(i int, host Host) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			hostCtx := ctx
+			if f.timeout > 0 {
+				var cancel context.CancelFunc
+				hostCtx, cancel = context.WithTimeout(ctx, f.timeout)
+				defer cancel()
+			}
+			snap, err := host.Source.Collect(hostCtx)
+			results[i] = HostSnapshot{Host: host.Name, Snapshot: snap, Err: err}
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
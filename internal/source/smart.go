@@ -0,0 +1,136 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/executor"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// smartCommand returns the command printing the SMART health of a disk.
+// Sleeping disks are not woken up (-n standby).
+func smartCommand(device string) executor.Cmd {
+	return zfsCommand("smartctl", "-j", "-a", "-n", "standby", device)
+}
+
+// SMART wraps a Source and fills in the SMART health of every leaf VDev
+// of the pools it collects, running smartctl through the executor of the
+// host. Reading SMART takes a while and may keep disks busy, so every
+// disk is read at most once per interval; in between, the last reading is
+// filled in.
+type SMART struct {
+	src      Source
+	exec     executor.Executor
+	interval time.Duration
+
+	mu       sync.Mutex
+	readings map[string]smartReading // By device
+}
+
+// smartReading is the last SMART reading of a disk.
+type smartReading struct {
+	info *zfs.SMARTInfo // nil if the disk could not be read
+	at   time.Time
+}
+
+// NewSMART creates a SMART source.
+//
+// Parameters:
+//   - src: The source to collect from; it must return a fresh snapshot
+//     with every collection, since the snapshot is filled in place
+//   - exec: Runs smartctl on the host src collects from
+//   - interval: How often each disk is read again
+//
+// Returns:
+//   - *SMART: A source ready for use
+//
+// Example:
+//
+//	src := source.NewSMART(source.NewZFS("nas1", exec), exec, 10*time.Minute)
+func NewSMART(src Source, exec executor.Executor, interval time.Duration) *SMART {
+	return &SMART{src: src, exec: exec, interval: interval, readings: make(map[string]smartReading)}
+}
+
+// Collect implements Source. Disks that cannot be read, e.g. because
+// smartctl is missing or the disk is asleep, are left without SMART
+// health, or keep their last reading.
+func (s *SMART) Collect(ctx context.Context) (*Snapshot, error) {
+	snap, err := s.src.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range snap.Pools {
+		for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+			s.read(ctx, vdev)
+		}
+	}
+	return snap, nil
+}
+
+// read fills in the SMART health of the leaves of a VDev tree.
+func (s *SMART) read(ctx context.Context, vdev *zfs.VDev) {
+	if vdev == nil {
+		return
+	}
+	for _, child := range vdev.Children {
+		s.read(ctx, child)
+	}
+	if len(vdev.Children) > 0 {
+		return
+	}
+	device := smartDevice(vdev)
+	if device == "" {
+		return
+	}
+
+	s.mu.Lock()
+	last, ok := s.readings[device]
+	s.mu.Unlock()
+	if !ok || time.Since(last.at) >= s.interval {
+		info, err := s.readDisk(ctx, device)
+		switch {
+		case err == nil:
+			last = smartReading{info: info, at: time.Now()}
+		case ctx.Err() != nil:
+			return
+		default:
+			last.at = time.Now() // Keep the last reading, e.g. while asleep
+		}
+		s.mu.Lock()
+		s.readings[device] = last
+		s.mu.Unlock()
+	}
+	vdev.SMART = last.info
+}
+
+// readDisk runs smartctl on a disk. smartctl reports problems with the
+// disk in its exit status, so output is parsed whatever the status.
+func (s *SMART) readDisk(ctx context.Context, device string) (*zfs.SMARTInfo, error) {
+	res, err := s.exec.Run(ctx, smartCommand(device))
+	var exitErr *executor.ExitError
+	if res == nil || err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	return zfs.ParseSMART(res.Stdout)
+}
+
+// smartDevice returns the device node smartctl reads for a leaf VDev: its
+// whole disk if resolved, else its path or name below /dev. File VDevs
+// have none.
+func smartDevice(vdev *zfs.VDev) string {
+	switch {
+	case vdev.Disk != nil:
+		return "/dev/" + vdev.Disk.Device
+	case strings.HasPrefix(vdev.Path, "/dev/"):
+		return vdev.Path
+	case strings.HasPrefix(vdev.Name, "/dev/"):
+		return vdev.Name
+	case vdev.Type == "disk" && !strings.Contains(vdev.Name, "/"):
+		return "/dev/" + vdev.Name
+	}
+	return ""
+}
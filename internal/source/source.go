@@ -0,0 +1,140 @@
+// Package source provides the data sources the front ends collect ZFS state
+// from. Every front end (TUI, health check, metrics exporter) reads through
+// a Source so that they all see the same data.
+package source
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// Snapshot is the state of a host collected at a single point in time.
+type Snapshot struct {
+	// Host is the name of the host the snapshot was collected from
+	Host string
+
+	// Pools are the storage pools of the host
+	Pools []*zfs.Pool
+
+	// Datasets are the root datasets of each pool, with nested children
+	Datasets []*zfs.Dataset
+
+	// ARC holds the host's ARC statistics, or nil if unavailable
+	ARC *zfs.ARCStats
+
+	// Enclosures are the host's disk enclosures, nil if it has none or
+	// they are unknown
+	Enclosures []*zfs.Enclosure
+
+	// CollectedAt is the time the snapshot was taken
+	CollectedAt time.Time
+}
+
+// Source collects snapshots of ZFS state.
+// Implementations must be safe for concurrent use.
+type Source interface {
+	// Collect gathers a fresh snapshot
+	Collect(ctx context.Context) (*Snapshot, error)
+}
+
+// Update is a snapshot, or the error collecting it, pushed by a Streamer.
+type Update struct {
+	// Snapshot is the collected state, nil if Err is set
+	Snapshot *Snapshot
+
+	// Err is why the state could not be collected
+	Err error
+}
+
+// Streamer is a Source that pushes snapshots as they are collected, such
+// as a remote agent, so that readers get live updates without polling.
+type Streamer interface {
+	Source
+
+	// Stream sends an update for every collection until ctx is done.
+	// Connection failures are sent as updates and retried; Stream only
+	// returns once ctx is done.
+	Stream(ctx context.Context, updates chan<- Update) error
+}
+
+// EventUpdate is an event, or the error following the event log, pushed
+// by an EventSource.
+type EventUpdate struct {
+	// Event is the event, nil if Err is set
+	Event *zfs.Event
+
+	// Err is why the event log could not be followed
+	Err error
+}
+
+// EventSource is implemented by sources that can follow the ZFS event log
+// of their host (zpool events), so that front ends show events as they
+// happen.
+type EventSource interface {
+	// Events sends the events already logged, oldest first, then every
+	// new event until ctx is done. Failures are sent as updates and
+	// retried; Events only returns once ctx is done, or at once if the
+	// source cannot follow events at all.
+	Events(ctx context.Context, events chan<- EventUpdate) error
+}
+
+// HistorySource is implemented by sources that can read the command
+// history of a pool (zpool history). Histories grow long, so they are read
+// on demand rather than with every snapshot.
+type HistorySource interface {
+	// History reads the history of a pool, oldest entry first, including
+	// internal entries
+	History(ctx context.Context, pool string) ([]*zfs.HistoryEntry, error)
+}
+
+// Mock is a Source serving the built-in development data.
+type Mock struct{}
+
+// Collect implements Source using the mock data of the zfs package.
+func (Mock) Collect(ctx context.Context) (*Snapshot, error) {
+	pools, err := zfs.GetPools()
+	if err != nil {
+		return nil, err
+	}
+	datasets, err := zfs.GetDatasets()
+	if err != nil {
+		return nil, err
+	}
+	arc, err := zfs.GetARCStats()
+	if err != nil {
+		return nil, err
+	}
+	enclosures, err := zfs.GetEnclosures()
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Snapshot{Host: host, Pools: pools, Datasets: datasets, ARC: arc, Enclosures: enclosures, CollectedAt: time.Now()}, nil
+}
+
+// Events implements EventSource, sending the mock events of the zfs
+// package; no new events follow.
+func (Mock) Events(ctx context.Context, events chan<- EventUpdate) error {
+	logged, err := zfs.GetEvents()
+	if err != nil {
+		return err
+	}
+	for _, e := range logged {
+		select {
+		case events <- EventUpdate{Event: e}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// History implements HistorySource using the mock history of the zfs
+// package.
+func (Mock) History(ctx context.Context, pool string) ([]*zfs.HistoryEntry, error) {
+	return zfs.GetHistory(pool)
+}
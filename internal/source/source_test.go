@@ -0,0 +1,450 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/executor"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// countingSource counts collections and optionally fails.
+type countingSource struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (s *countingSource) Collect(ctx context.Context) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Snapshot{CollectedAt: time.Unix(int64(s.calls), 0)}, nil
+}
+
+func TestCachedReusesSnapshotWithinInterval(t *testing.T) {
+	src := &countingSource{}
+	now := time.Unix(1000, 0)
+	cached := NewCached(src, 10*time.Second)
+	cached.now = func() time.Time { return now }
+
+	first, _ := cached.Collect(context.Background())
+	now = now.Add(5 * time.Second)
+	second, _ := cached.Collect(context.Background())
+	if src.calls != 1 || first != second {
+		t.Fatalf("collected %d times within interval, want 1", src.calls)
+	}
+
+	now = now.Add(5 * time.Second)
+	third, _ := cached.Collect(context.Background())
+	if src.calls != 2 || third == first {
+		t.Fatalf("collected %d times after interval, want 2", src.calls)
+	}
+}
+
+func TestCachedCachesErrors(t *testing.T) {
+	src := &countingSource{err: errors.New("zpool failed")}
+	cached := NewCached(src, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := cached.Collect(context.Background()); err == nil {
+			t.Fatal("expected cached error")
+		}
+	}
+	if src.calls != 1 {
+		t.Errorf("failing source called %d times, want 1", src.calls)
+	}
+}
+
+func TestCachedSkipsCancelledCollections(t *testing.T) {
+	src := &countingSource{err: context.Canceled}
+	cached := NewCached(src, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cached.Collect(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled collection: %v", err)
+	}
+	src.err = nil
+	if _, err := cached.Collect(context.Background()); err != nil || src.calls != 2 {
+		t.Errorf("cancelled collection cached: %v after %d calls", err, src.calls)
+	}
+}
+
+func TestCachedConcurrentCallers(t *testing.T) {
+	src := &countingSource{}
+	cached := NewCached(src, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cached.Collect(context.Background())
+		}()
+	}
+	wg.Wait()
+	if src.calls != 1 {
+		t.Errorf("concurrent callers caused %d collections, want 1", src.calls)
+	}
+}
+
+func TestMock(t *testing.T) {
+	snap, err := Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Pools) == 0 || snap.ARC == nil {
+		t.Errorf("mock snapshot incomplete: %+v", snap)
+	}
+}
+
+func TestFiltered(t *testing.T) {
+	src := NewFiltered(Mock{}, []string{"testpool"})
+	snap, err := src.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pool := range snap.Pools {
+		if pool.Name == "testpool" {
+			t.Error("ignored pool returned")
+		}
+	}
+	for _, ds := range snap.Datasets {
+		if ds.Name == "testpool" {
+			t.Error("dataset of ignored pool returned")
+		}
+	}
+	if len(snap.Pools) == 0 {
+		t.Error("all pools filtered")
+	}
+	if _, ok := src.(Streamer); ok {
+		t.Error("filtered mock source claims to stream")
+	}
+
+	// Streamed snapshots are filtered too
+	streamed, ok := NewFiltered(streamingMock{}, []string{"testpool"}).(Streamer)
+	if !ok {
+		t.Fatal("filtered streaming source does not stream")
+	}
+	updates := make(chan Update)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go streamed.Stream(ctx, updates)
+	for _, pool := range (<-updates).Snapshot.Pools {
+		if pool.Name == "testpool" {
+			t.Error("ignored pool streamed")
+		}
+	}
+}
+
+// diskMap resolves the disks it holds by name.
+type diskMap map[string]*zfs.DiskIdentity
+
+func (m diskMap) Resolve(name string) (*zfs.DiskIdentity, error) {
+	if id, ok := m[name]; ok {
+		return id, nil
+	}
+	return nil, fmt.Errorf("%s: not a disk", name)
+}
+
+func TestDisks(t *testing.T) {
+	sda := &zfs.DiskIdentity{Device: "sda", Serial: "VAGK1234"}
+	snap, err := NewDisks(NewZFS("nas1", fakeHost("tank", "ONLINE", 0)), diskMap{"sda": sda}).Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := snap.Pools[0].RootVDev.Children[0].Children
+	if leaves[0].Disk != sda {
+		t.Errorf("sda disk = %+v, want %+v", leaves[0].Disk, sda)
+	}
+	if leaves[1].Disk != nil {
+		t.Errorf("unresolved sdb disk = %+v, want nil", leaves[1].Disk)
+	}
+
+	// Resolvers reading enclosures locate the disks
+	encs := []*zfs.Enclosure{{Number: 2, Slots: []*zfs.EnclosureSlot{{Number: 14, Device: "sda"}}}}
+	snap, err = NewDisks(NewZFS("nas1", fakeHost("tank", "ONLINE", 0)), shelf{diskMap{"sda": sda}, encs}).Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Enclosures) != 1 || sda.Location() != "enclosure 2, slot 14" {
+		t.Errorf("enclosures %+v, sda at %q", snap.Enclosures, sda.Location())
+	}
+}
+
+// shelf resolves the disks it holds and has enclosures.
+type shelf struct {
+	diskMap
+	encs []*zfs.Enclosure
+}
+
+func (s shelf) Enclosures() ([]*zfs.Enclosure, error) {
+	return s.encs, nil
+}
+
+func TestSMART(t *testing.T) {
+	ata, err := os.ReadFile("../zfs/testdata/smartctl-ata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	standby, err := os.ReadFile("../zfs/testdata/smartctl-standby.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := fakeHost("tank", "ONLINE", 0).
+		On(smartCommand("/dev/sda").String(), executor.Response{Stdout: string(ata), ExitCode: 64}).
+		On(smartCommand("/dev/sdb").String(), executor.Response{Stdout: string(standby), ExitCode: 2})
+	src := NewSMART(NewZFS("nas1", exec), exec, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		snap, err := src.Collect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaves := snap.Pools[0].RootVDev.Children[0].Children
+		if leaves[0].SMART == nil || leaves[0].SMART.Pending != 3 {
+			t.Errorf("collection %d: sda SMART = %+v", i, leaves[0].SMART)
+		}
+		if leaves[1].SMART != nil {
+			t.Errorf("collection %d: sleeping sdb SMART = %+v, want nil", i, leaves[1].SMART)
+		}
+	}
+
+	// Disks are read once per interval
+	runs := 0
+	for _, cmd := range exec.Calls() {
+		if cmd.Name == "smartctl" {
+			runs++
+		}
+	}
+	if runs != 2 {
+		t.Errorf("smartctl ran %d times, want once per disk", runs)
+	}
+}
+
+// streamingMock streams a single mock snapshot.
+type streamingMock struct{ Mock }
+
+func (s streamingMock) Stream(ctx context.Context, updates chan<- Update) error {
+	snap, _ := s.Collect(ctx)
+	updates <- Update{Snapshot: snap}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// fakeHost returns an executor serving the outputs of a host with a
+// single mirrored pool, each taking delay.
+func fakeHost(pool, state string, delay time.Duration) *executor.Fake {
+	return executor.NewFake().
+		On(poolStatusCommand.String(), executor.Response{Delay: delay, Stdout: "  pool: " + pool + "\n state: " + state + "\n" +
+			"  scan: scrub repaired 0B in 00:10:12 with 0 errors on Sun Oct 13 00:34:13 2024\nconfig:\n\n" +
+			"\tNAME        STATE     READ WRITE CKSUM\n" +
+			"\t" + pool + "        " + state + "     0     0     0\n" +
+			"\t  mirror-0  " + state + "     0     0     0\n" +
+			"\t    sda     ONLINE       0     0     0\n" +
+			"\t    sdb     " + state + "       0     0     7\n\nerrors: No known data errors\n"}).
+		On(poolListCommand.String(), executor.Response{Delay: delay, Stdout: pool + "\t1000\t800\t200\t10\t" + state + "\n"}).
+		On(ashiftCommand(pool).String(), executor.Response{Delay: delay, Stdout: "root-0\t-\nmirror-0\t12\nsda\t12\nsdb\t12\n"}).
+		On(datasetListCommand.String(), executor.Response{Delay: delay, Stdout: pool + "\tfilesystem\t800\t200\t100\t0\t/" + pool + "\toff\t1.00x\n"}).
+		On(snapshotUsageCommand.String(), executor.Response{Delay: delay, Stdout: pool + "\t300\n" + pool + "@daily\t-\n" + pool + "@weekly\t-\n"}).
+		On(ioStatCommand.String(), executor.Response{Delay: delay, Stdout: pool + "\t800\t200\t900\t90\t9000\t900\n" +
+			"mirror-0\t800\t200\t900\t90\t9000\t900\nsda\t-\t-\t900\t90\t9000\t900\nsdb\t-\t-\t0\t0\t0\t0\n" +
+			pool + "\t800\t200\t12\t3\t4096\t1024\n" +
+			"mirror-0\t800\t200\t12\t3\t4096\t1024\nsda\t-\t-\t7\t3\t2048\t1024\nsdb\t-\t-\t5\t0\t2048\t0\n"})
+}
+
+func TestZFS(t *testing.T) {
+	exec := fakeHost("tank", "DEGRADED", 0)
+	snap, err := NewZFS("nas1", exec).Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Host != "nas1" || len(snap.Pools) != 1 || len(snap.Datasets) != 1 {
+		t.Fatalf("snapshot %+v", snap)
+	}
+	pool := snap.Pools[0]
+	if pool.Status != zfs.VDevStatusDegraded || pool.Size != 1000 || pool.Allocated != 800 || pool.Scan == nil {
+		t.Errorf("pool %+v", pool)
+	}
+	if mirror := pool.RootVDev.Children[0]; mirror.Ashift != 12 {
+		t.Errorf("%s ashift = %d, want 12", mirror.Name, mirror.Ashift)
+	}
+	// The second sample of zpool iostat is the one used
+	if io := pool.RootVDev.Children[0].Children[0].IO; !io.Sampled || io.ReadOps != 7 || io.WriteBytes != 1024 {
+		t.Errorf("sda I/O %+v", io)
+	}
+	if ds := snap.Datasets[0]; ds.Snapshots != 2 || ds.SnapshotsUsed != 300 {
+		t.Errorf("%s: %d snapshots using %d", ds.Name, ds.Snapshots, ds.SnapshotsUsed)
+	}
+	if snap.ARC != nil {
+		t.Error("ARC statistics without arcstats")
+	}
+	for _, cmd := range exec.Calls() {
+		if len(cmd.Env) != 1 || cmd.Env[0] != "LC_ALL=C" {
+			t.Errorf("%s run in the host's locale", cmd)
+		}
+	}
+
+	if _, err := NewZFS("nas2", executor.NewFake()).Collect(context.Background()); err == nil {
+		t.Error("host without zpool collected")
+	}
+}
+
+func TestZFSPrefersJSON(t *testing.T) {
+	statusJSON := `{"pools": {"tank": {"name": "tank", "state": "ONLINE", "vdevs": {"tank": {"name": "tank", "vdev_type": "root", "state": "ONLINE",
+		"vdevs": {"sda": {"name": "sda", "vdev_type": "disk", "state": "ONLINE"}}}}}}}`
+	datasetsJSON := `{"datasets": {"tank": {"name": "tank", "type": "FILESYSTEM", "properties": {"used": {"value": 800}}}}}`
+
+	count := func(calls []executor.Cmd, cmd executor.Cmd) int {
+		n := 0
+		for _, call := range calls {
+			if call.String() == cmd.String() {
+				n++
+			}
+		}
+		return n
+	}
+
+	// OpenZFS 2.3 prints JSON; the version is only detected once
+	exec := fakeHost("tank", "DEGRADED", 0).
+		On(versionCommand.String(), executor.Response{Stdout: "zfs-2.3.0-1\nzfs-kmod-2.3.0-1\n"}).
+		On(poolStatusJSONCommand.String(), executor.Response{Stdout: statusJSON}).
+		On(datasetListJSONCommand.String(), executor.Response{Stdout: datasetsJSON})
+	src := NewZFS("nas1", exec)
+	for i := 0; i < 2; i++ {
+		snap, err := src.Collect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snap.Pools[0].Status != zfs.VDevStatusOnline || snap.Datasets[0].Used != 800 {
+			t.Errorf("snapshot not parsed from JSON: %+v %+v", snap.Pools[0], snap.Datasets[0])
+		}
+	}
+	calls := exec.Calls()
+	if count(calls, versionCommand) != 1 || count(calls, poolStatusCommand) != 0 || count(calls, datasetListCommand) != 0 {
+		t.Errorf("ran %v", calls)
+	}
+
+	// Output the JSON parser does not understand falls back to text
+	exec = fakeHost("tank", "DEGRADED", 0).
+		On(versionCommand.String(), executor.Response{Stdout: "zfs-2.3.0-1\n"}).
+		On(poolStatusJSONCommand.String(), executor.Response{Stdout: `{"pools": []}`})
+	snap, err := NewZFS("nas1", exec).Collect(context.Background())
+	if err != nil || snap.Pools[0].Status != zfs.VDevStatusDegraded {
+		t.Errorf("fallback to text: %+v, %v", snap, err)
+	}
+
+	// Older releases are not asked for JSON
+	exec = fakeHost("tank", "DEGRADED", 0).On(versionCommand.String(), executor.Response{Stdout: "zfs-2.2.7-1\n"})
+	if _, err := NewZFS("nas1", exec).Collect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls := exec.Calls(); count(calls, poolStatusJSONCommand) != 0 || count(calls, datasetListJSONCommand) != 0 {
+		t.Errorf("JSON requested from OpenZFS 2.2: %v", calls)
+	}
+}
+
+func TestFleet(t *testing.T) {
+	hosts := []Host{
+		{Name: "nas1", Source: NewZFS("nas1", fakeHost("tank", "ONLINE", 0))},
+		{Name: "nas2", Source: NewZFS("nas2", executor.NewFake())},
+		{Name: "nas3", Source: NewZFS("nas3", fakeHost("backup", "DEGRADED", 0))},
+	}
+
+	results := NewFleet(hosts, 2, time.Second).Collect(context.Background())
+	if len(results) != 3 {
+		t.Fatalf("%d results, want 3", len(results))
+	}
+	if results[0].Err != nil || results[0].Snapshot.Pools[0].Name != "tank" {
+		t.Errorf("nas1: %+v", results[0])
+	}
+	if results[1].Host != "nas2" || results[1].Err == nil {
+		t.Errorf("nas2 without outputs: %+v", results[1])
+	}
+	if results[2].Err != nil || results[2].Snapshot.Pools[0].Status != zfs.VDevStatusDegraded {
+		t.Errorf("nas3: %+v", results[2])
+	}
+
+	// A slow host times out without holding up the fleet
+	hosts[0].Source = NewZFS("nas1", fakeHost("tank", "ONLINE", time.Second))
+	start := time.Now()
+	results = NewFleet(hosts, 3, 50*time.Millisecond).Collect(context.Background())
+	if time.Since(start) > 500*time.Millisecond || !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("timed out collection took %s: %v", time.Since(start), results[0].Err)
+	}
+}
+
+func TestZFSEvents(t *testing.T) {
+	record := func(eid int, pool string) string {
+		return fmt.Sprintf("Oct 13 2024 00:24:01.000000000\tereport.fs.zfs.checksum\n\tpool = %q\n\teid = 0x%x\n\n", pool, eid)
+	}
+	// zpool events prints the whole log again when it is restarted
+	exec := executor.NewFake().On(eventsCommand.String(),
+		executor.Response{Stdout: record(1, "tank") + record(2, "backup")},
+		executor.Response{ExitCode: 1, Stderr: "failed to get event"},
+		executor.Response{Stdout: record(1, "tank") + record(2, "backup") + record(3, "tank")})
+	host := NewZFS("nas1", exec)
+	host.retry = time.Millisecond
+	src := FilterEvents(host, []string{"backup"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan EventUpdate)
+	done := make(chan struct{})
+	go func() {
+		src.Events(ctx, events)
+		close(done)
+	}()
+
+	var got []string
+	for len(got) < 3 {
+		u := <-events
+		if u.Err != nil {
+			got = append(got, "error "+u.Err.Error())
+			continue
+		}
+		got = append(got, fmt.Sprintf("%d %s", u.Event.EID, u.Event.Pool))
+	}
+	want := []string{"1 tank", "error zpool events -fvH: exit status 1: failed to get event", "3 tank"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("events %q, want %q", got, want)
+	}
+	cancel()
+	<-done
+
+	if err := NewZFS("nas1", runOnlyExecutor{}).Events(context.Background(), events); err == nil {
+		t.Error("executor without Start accepted")
+	}
+}
+
+// runOnlyExecutor is an Executor that cannot start commands.
+type runOnlyExecutor struct{}
+
+// Run implements executor.Executor.
+func (runOnlyExecutor) Run(context.Context, executor.Cmd) (*executor.Result, error) {
+	return &executor.Result{}, nil
+}
+
+func TestZFSHistory(t *testing.T) {
+	exec := executor.NewFake().
+		On("zpool history -il tank", executor.Response{Stdout: "History for 'tank':\n" +
+			"2024-10-01.10:13:10 zfs set compression=lz4 tank [user 0 (root) on nas1:linux]\n"}).
+		On("zpool history -il gone", executor.Response{ExitCode: 1, Stderr: "cannot open 'gone': no such pool"})
+	src := NewZFS("nas1", exec)
+
+	entries, err := src.History(context.Background(), "tank")
+	if err != nil || len(entries) != 1 || entries[0].User != "root" || entries[0].Pool != "tank" {
+		t.Errorf("History = %+v, %v", entries, err)
+	}
+	if _, err := src.History(context.Background(), "gone"); err == nil {
+		t.Error("missing pool has a history")
+	}
+}
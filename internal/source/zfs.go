@@ -0,0 +1,336 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/executor"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// Commands run on the host; see the Parse functions of the zfs package
+// for the output they produce. They run with LC_ALL=C, so that their output
+// can be parsed regardless of the host's locale. The JSON forms are used
+// from zfs.JSONVersion on.
+var (
+	versionCommand         = zfsCommand("zfs", "version")
+	poolStatusCommand      = zfsCommand("zpool", "status", "-p")
+	poolStatusJSONCommand  = zfsCommand("zpool", "status", "-j", "--json-int")
+	poolListCommand        = zfsCommand("zpool", "list", "-Hp", "-o", strings.Join(zfs.PoolListFields, ","))
+	datasetListCommand     = zfsCommand("zfs", "list", "-Hp", "-t", "filesystem,volume", "-o", strings.Join(zfs.DatasetListFields, ","))
+	datasetListJSONCommand = zfsCommand("zfs", "list", "-jp", "--json-int", "-t", "filesystem,volume", "-o", strings.Join(zfs.DatasetListFields, ","))
+	snapshotUsageCommand   = zfsCommand("zfs", "list", "-Hp", "-t", "filesystem,volume,snapshot", "-o", strings.Join(zfs.SnapshotUsageFields, ","))
+	ioStatCommand          = zfsCommand("zpool", "iostat", "-Hpv", "1", "2")
+	arcStatsCommand        = zfsCommand("cat", "/proc/spl/kstat/zfs/arcstats")
+	eventsCommand          = zfsCommand("zpool", "events", "-fvH")
+)
+
+// ashiftCommand returns the command printing the ashift of every VDev of a
+// pool, which OpenZFS 2.2 added as a vdev property.
+func ashiftCommand(pool string) executor.Cmd {
+	return zfsCommand("zpool", "get", "-Hp", "-o", "name,value", "ashift", pool, "all-vdevs")
+}
+
+// historyCommand returns the command printing the history of a pool.
+func historyCommand(pool string) executor.Cmd {
+	return zfsCommand("zpool", "history", "-il", pool)
+}
+
+// eventsRetryInterval is how long Events waits by default before following
+// the event log again after zpool events exited.
+const eventsRetryInterval = 5 * time.Second
+
+// zfsCommand returns a command run in the C locale.
+func zfsCommand(name string, args ...string) executor.Cmd {
+	return executor.Cmd{Name: name, Args: args, Env: []string{"LC_ALL=C"}}
+}
+
+// ZFS is a Source collecting the state of a host by running the zpool and
+// zfs commands through an executor: locally, over ssh, or replayed from a
+// fixture.
+type ZFS struct {
+	host  string
+	exec  executor.Executor
+	retry time.Duration // Wait before following the event log again
+
+	mu       sync.Mutex
+	detected bool // Whether the OpenZFS version was detected
+	json     bool // Whether the host prints JSON
+}
+
+// NewZFS creates a ZFS source.
+//
+// Parameters:
+//   - host: The host name reported in snapshots, e.g. "nas1"
+//   - exec: Runs the commands on the host, e.g. an executor.SSH
+//
+// Returns:
+//   - *ZFS: A source ready for use
+//
+// Example:
+//
+//	src := source.NewZFS("nas1", executor.SSH{Host: "root@nas1", Options: []string{"-o", "BatchMode=yes"}})
+//	snap, err := src.Collect(ctx)
+func NewZFS(host string, exec executor.Executor) *ZFS {
+	return &ZFS{host: host, exec: exec, retry: eventsRetryInterval}
+}
+
+// run runs a command and returns its standard output.
+func (s *ZFS) run(ctx context.Context, cmd executor.Cmd) ([]byte, error) {
+	res, err := s.exec.Run(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return res.Stdout, nil
+}
+
+// Collect implements Source. Pool status, capacity and datasets are
+// required; the ARC statistics and the ashift of VDevs are left out if the
+// host does not provide them, e.g. on FreeBSD or before OpenZFS 2.2, and
+// snapshot counts and space and the I/O rates of VDevs if reading them
+// fails, e.g. in fixtures recorded before they were collected. Sampling
+// the I/O rates takes a second.
+func (s *ZFS) Collect(ctx context.Context) (*Snapshot, error) {
+	useJSON := s.useJSON(ctx)
+	pools, err := s.poolStatus(ctx, useJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.run(ctx, poolListCommand)
+	if err != nil {
+		return nil, err
+	}
+	capacities, err := zfs.ParsePoolList(list)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.host, err)
+	}
+	for _, c := range capacities {
+		for _, pool := range pools {
+			if pool.Name == c.Name {
+				pool.Size, pool.Allocated, pool.Free = c.Size, c.Allocated, c.Free
+				pool.Fragmentation = c.Fragmentation
+				if pool.RootVDev != nil {
+					pool.RootVDev.Size, pool.RootVDev.Allocated = c.Size, c.Allocated
+				}
+			}
+		}
+	}
+
+	for _, pool := range pools {
+		if out, err := s.run(ctx, ashiftCommand(pool.Name)); err == nil {
+			if ashift, err := zfs.ParseVDevAshift(out); err == nil {
+				for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+					setAshift(vdev, ashift)
+				}
+			}
+		}
+	}
+
+	names := make([]string, len(pools))
+	for i, pool := range pools {
+		names[i] = pool.Name
+	}
+	if out, err := s.run(ctx, ioStatCommand); err == nil {
+		if io, err := zfs.ParseIOStat(out, names); err == nil {
+			for _, pool := range pools {
+				for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+					setIO(vdev, io[pool.Name])
+				}
+			}
+		}
+	}
+
+	datasets, err := s.datasets(ctx, useJSON)
+	if err != nil {
+		return nil, err
+	}
+	if out, err := s.run(ctx, snapshotUsageCommand); err == nil {
+		if usage, err := zfs.ParseSnapshotUsage(out); err == nil {
+			setSnapshotUsage(datasets, usage)
+		}
+	}
+
+	snap := &Snapshot{Host: s.host, Pools: pools, Datasets: datasets, CollectedAt: time.Now()}
+	if out, err := s.run(ctx, arcStatsCommand); err == nil {
+		snap.ARC, _ = zfs.ParseARCStats(out)
+	}
+	return snap, nil
+}
+
+// setAshift fills in the ashift of a VDev tree by VDev name.
+func setAshift(vdev *zfs.VDev, ashift map[string]int) {
+	if vdev == nil {
+		return
+	}
+	vdev.Ashift = ashift[vdev.Name]
+	for _, child := range vdev.Children {
+		setAshift(child, ashift)
+	}
+}
+
+// setIO fills in the I/O rates of a VDev tree by VDev name.
+func setIO(vdev *zfs.VDev, io map[string]zfs.IOStats) {
+	if vdev == nil {
+		return
+	}
+	vdev.IO = io[vdev.Name]
+	for _, child := range vdev.Children {
+		setIO(child, io)
+	}
+}
+
+// setSnapshotUsage fills in the snapshot counts and space of a dataset
+// tree by dataset name.
+func setSnapshotUsage(datasets []*zfs.Dataset, usage map[string]zfs.SnapshotUsage) {
+	for _, ds := range datasets {
+		u := usage[ds.Name]
+		ds.Snapshots, ds.SnapshotsUsed = u.Count, u.Used
+		setSnapshotUsage(ds.Children, usage)
+	}
+}
+
+// useJSON reports whether the host's OpenZFS prints JSON. The version is
+// detected once; releases without zfs version (before 0.8) and hosts
+// whose fixture did not record it use the text output. If the command
+// could not be run at all, text is used and detection is tried again on
+// the next collection.
+func (s *ZFS) useJSON(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.detected {
+		return s.json
+	}
+
+	res, err := s.exec.Run(ctx, versionCommand)
+	var exitErr *executor.ExitError
+	switch {
+	case err == nil:
+		version, err := zfs.ParseVersion(res.Stdout)
+		s.json = err == nil && version.AtLeast(zfs.JSONVersion)
+	case errors.As(err, &exitErr), errors.Is(err, executor.ErrNoFixture):
+	default:
+		return false
+	}
+	s.detected = true
+	return s.json
+}
+
+// poolStatus collects the pool status, from the JSON output if the host
+// supports it. The text output is the fallback if the JSON command fails
+// or prints something the parser does not understand.
+func (s *ZFS) poolStatus(ctx context.Context, useJSON bool) ([]*zfs.Pool, error) {
+	if useJSON {
+		if out, err := s.run(ctx, poolStatusJSONCommand); err == nil {
+			if pools, err := zfs.ParsePoolStatusJSON(out); err == nil {
+				return pools, nil
+			}
+		}
+	}
+	out, err := s.run(ctx, poolStatusCommand)
+	if err != nil {
+		return nil, err
+	}
+	pools, err := zfs.ParsePoolStatus(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.host, err)
+	}
+	return pools, nil
+}
+
+// datasets collects the dataset tree, preferring JSON like poolStatus.
+func (s *ZFS) datasets(ctx context.Context, useJSON bool) ([]*zfs.Dataset, error) {
+	if useJSON {
+		if out, err := s.run(ctx, datasetListJSONCommand); err == nil {
+			if datasets, err := zfs.ParseDatasetListJSON(out); err == nil {
+				return datasets, nil
+			}
+		}
+	}
+	out, err := s.run(ctx, datasetListCommand)
+	if err != nil {
+		return nil, err
+	}
+	datasets, err := zfs.ParseDatasetList(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.host, err)
+	}
+	return datasets, nil
+}
+
+// Events implements EventSource by following zpool events -f, which needs
+// an executor.Starter. When zpool events exits it is started again after
+// a while; since it prints the whole log again, events already sent are
+// skipped by their ID.
+func (s *ZFS) Events(ctx context.Context, events chan<- EventUpdate) error {
+	starter, ok := s.exec.(executor.Starter)
+	if !ok {
+		return fmt.Errorf("%s: %T cannot follow zpool events", s.host, s.exec)
+	}
+
+	var last uint64 // ID of the last event sent
+	for {
+		err := s.followEvents(ctx, starter, &last, events)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			select {
+			case events <- EventUpdate{Err: err}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		select {
+		case <-time.After(s.retry):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// followEvents runs zpool events -f once, sending the events newer than
+// *last until it exits.
+func (s *ZFS) followEvents(ctx context.Context, starter executor.Starter, last *uint64, events chan<- EventUpdate) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	out, err := starter.Start(ctx, eventsCommand)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	err = zfs.ParseEvents(out, func(e *zfs.Event) {
+		if e.EID != 0 && e.EID <= *last {
+			return
+		}
+		if e.EID != 0 {
+			*last = e.EID
+		}
+		select {
+		case events <- EventUpdate{Event: e}:
+		case <-ctx.Done():
+		}
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// History implements HistorySource.
+func (s *ZFS) History(ctx context.Context, pool string) ([]*zfs.HistoryEntry, error) {
+	out, err := s.run(ctx, historyCommand(pool))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := zfs.ParseHistory(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.host, err)
+	}
+	return entries, nil
+}
@@ -11,30 +11,33 @@ import (
 // program with appropriate options for terminal handling.
 //
 // The function:
-// 1. Creates a new application model
+// 1. Creates a new application model from the given options
 // 2. Initializes the Bubble Tea program with:
 //   - Alternate screen buffer for clean UI
 //   - Mouse support for enhanced interaction
 //
 // 3. Runs the main program loop
 //
+// Parameters:
+//   - opts: Options for the application model
+//
 // Returns:
 //   - error: Any error that occurred during program execution
 //
 // Example usage:
 //
-//	if err := tui.Start(); err != nil {
+//	if err := tui.Start(tui.Options{}); err != nil {
 //	    log.Fatal("Failed to start TUI:", err)
 //	}
-func Start() error {
-	// Initialize model with default state
-	model := NewModel()
+func Start(opts Options) error {
+	// Initialize model with the given options
+	model := NewModel(opts)
 
 	// Create program with options for better UI experience
 	fmt.Println("viZFSulizer Starting...")
 	p := tea.NewProgram(model,
-		tea.WithAltScreen(),       // Use alternate screen for clean UI
-		tea.WithMouseCellMotion(), // Enable mouse interaction
+		tea.WithAltScreen(),      // Use alternate screen for clean UI
+		tea.WithMouseAllMotion(), // Enable mouse interaction, including hover
 	)
 
 	// Run the program and return any errors
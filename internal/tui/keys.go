@@ -0,0 +1,264 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/petecog/vizfsulizer/internal/config"
+)
+
+// actionHelp describes each action in the help footer and overlay.
+var actionHelp = map[string]string{
+	config.ActionNextPool:  "next pool",
+	config.ActionPrevPool:  "previous pool",
+	config.ActionNextItem:  "next device",
+	config.ActionPrevItem:  "previous device",
+	config.ActionToggle:    "expand/collapse",
+	config.ActionNextTheme: "next theme",
+	config.ActionHelp:      "help",
+	config.ActionQuit:      "quit",
+	config.ActionNextPane:  "next pane",
+	config.ActionZoom:      "zoom pane",
+	config.ActionWider:     "widen pane",
+	config.ActionNarrower:  "narrow pane",
+	config.ActionTaller:    "heighten pane",
+	config.ActionShorter:   "shorten pane",
+	config.ActionBack:      "back to fleet",
+
+	config.ActionEventClass: "filter event class",
+	config.ActionEventPool:  "events of pool/all",
+	config.ActionHistory:    "pool history",
+	config.ActionDiskLabel:  "label disks by",
+	config.ActionEnclosures: "enclosure map",
+	config.ActionLint:       "layout findings",
+	config.ActionPlanner:    "capacity planner",
+
+	config.ActionPageUp:   "page up",
+	config.ActionPageDown: "page down",
+	config.ActionTop:      "top",
+	config.ActionBottom:   "bottom",
+	config.ActionClose:    "close",
+
+	config.ActionInternalEntries: "internal entries",
+	config.ActionClearSearch:     "clear search",
+
+	config.ActionIncrease:      "increase",
+	config.ActionDecrease:      "decrease",
+	config.ActionPlannerAdd:    "add vdevs",
+	config.ActionPlannerRemove: "remove vdevs",
+	config.ActionPlannerReset:  "reset to pool",
+	config.ActionPlannerNew:    "new pool",
+}
+
+// KeyMap holds the key bindings of every TUI action. It implements
+// help.KeyMap, so the help footer and overlay are generated from the keys
+// actually bound rather than documented separately.
+type KeyMap struct {
+	NextPool   key.Binding
+	PrevPool   key.Binding
+	NextItem   key.Binding
+	PrevItem   key.Binding
+	Toggle     key.Binding
+	Back       key.Binding
+	NextPane   key.Binding
+	Zoom       key.Binding
+	Wider      key.Binding
+	Narrower   key.Binding
+	Taller     key.Binding
+	Shorter    key.Binding
+	EventClass key.Binding
+	EventPool  key.Binding
+	History    key.Binding
+	DiskLabel  key.Binding
+	Enclosures key.Binding
+	Lint       key.Binding
+	Planner    key.Binding
+	NextTheme  key.Binding
+	Help       key.Binding
+	Quit       key.Binding
+
+	// Keys of the overlays
+	PageUp          key.Binding
+	PageDown        key.Binding
+	Top             key.Binding
+	Bottom          key.Binding
+	Close           key.Binding
+	InternalEntries key.Binding
+	ClearSearch     key.Binding
+	Increase        key.Binding
+	Decrease        key.Binding
+	PlannerAdd      key.Binding
+	PlannerRemove   key.Binding
+	PlannerReset    key.Binding
+	PlannerNew      key.Binding
+}
+
+// NewKeyMap builds the key map from the configured keybindings. Actions
+// missing from bindings keep their default keys.
+//
+// Parameters:
+//   - bindings: Keys per action, as in config.Config.Keybindings
+//
+// Returns:
+//   - KeyMap: The key bindings with their help texts
+//
+// Example:
+//
+//	keys := NewKeyMap(map[string][]string{"quit": {"x"}})
+//	key.Matches(msg, keys.Quit) // true for "x", false for "q"
+func NewKeyMap(bindings map[string][]string) KeyMap {
+	defaults := config.DefaultKeybindings()
+	binding := func(action string) key.Binding {
+		keys, ok := bindings[action]
+		if !ok {
+			keys = defaults[action]
+		}
+		return key.NewBinding(
+			key.WithKeys(keys...),
+			key.WithHelp(strings.Join(keys, "/"), actionHelp[action]),
+		)
+	}
+
+	return KeyMap{
+		NextPool:   binding(config.ActionNextPool),
+		PrevPool:   binding(config.ActionPrevPool),
+		NextItem:   binding(config.ActionNextItem),
+		PrevItem:   binding(config.ActionPrevItem),
+		Toggle:     binding(config.ActionToggle),
+		Back:       binding(config.ActionBack),
+		NextPane:   binding(config.ActionNextPane),
+		Zoom:       binding(config.ActionZoom),
+		Wider:      binding(config.ActionWider),
+		Narrower:   binding(config.ActionNarrower),
+		Taller:     binding(config.ActionTaller),
+		Shorter:    binding(config.ActionShorter),
+		EventClass: binding(config.ActionEventClass),
+		EventPool:  binding(config.ActionEventPool),
+		History:    binding(config.ActionHistory),
+		DiskLabel:  binding(config.ActionDiskLabel),
+		Enclosures: binding(config.ActionEnclosures),
+		Lint:       binding(config.ActionLint),
+		Planner:    binding(config.ActionPlanner),
+		NextTheme:  binding(config.ActionNextTheme),
+		Help:       binding(config.ActionHelp),
+		Quit:       binding(config.ActionQuit),
+
+		PageUp:          binding(config.ActionPageUp),
+		PageDown:        binding(config.ActionPageDown),
+		Top:             binding(config.ActionTop),
+		Bottom:          binding(config.ActionBottom),
+		Close:           binding(config.ActionClose),
+		InternalEntries: binding(config.ActionInternalEntries),
+		ClearSearch:     binding(config.ActionClearSearch),
+		Increase:        binding(config.ActionIncrease),
+		Decrease:        binding(config.ActionDecrease),
+		PlannerAdd:      binding(config.ActionPlannerAdd),
+		PlannerRemove:   binding(config.ActionPlannerRemove),
+		PlannerReset:    binding(config.ActionPlannerReset),
+		PlannerNew:      binding(config.ActionPlannerNew),
+	}
+}
+
+// Viewport returns the keys of the topology pane's viewport: only the page
+// keys, so that its built-in keys neither scroll it unseen in the help nor
+// shadow rebound ones. Lines are scrolled by moving the focus.
+func (k KeyMap) Viewport() viewport.KeyMap {
+	return viewport.KeyMap{PageDown: k.PageDown, PageUp: k.PageUp}
+}
+
+// ShortHelp implements help.KeyMap and lists the bindings of the footer.
+// Back is only shown while it is enabled, i.e. when watching a fleet.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.NextPool, k.NextItem, k.Back, k.Help, k.Quit}
+}
+
+// FleetHelp lists the bindings of the footer in the fleet overview, where
+// the toggle key opens the selected host.
+func (k KeyMap) FleetHelp() []key.Binding {
+	next, prev, open := k.NextItem, k.PrevItem, k.Toggle
+	next.SetHelp(next.Help().Key, "next row")
+	prev.SetHelp(prev.Help().Key, "previous row")
+	open.SetHelp(open.Help().Key, "open host")
+	return []key.Binding{next, prev, open, k.Help, k.Quit}
+}
+
+// PageHelp lists the bindings of the paged overlays, such as the
+// enclosure map, shown at their bottom.
+func (k KeyMap) PageHelp() []key.Binding {
+	down, up := k.NextItem, k.PrevItem
+	down.SetHelp(down.Help().Key, "scroll down")
+	up.SetHelp(up.Help().Key, "scroll up")
+	return []key.Binding{down, up, k.PageDown, k.PageUp, k.Close}
+}
+
+// HistoryHelp lists the bindings of the history overlay, closing first so
+// that a narrow help line keeps it. Printable keys type into the search, so
+// they are left out.
+func (k KeyMap) HistoryHelp() []key.Binding {
+	down, up := k.NextItem, k.PrevItem
+	down.SetHelp(down.Help().Key, "scroll down")
+	up.SetHelp(up.Help().Key, "scroll up")
+	bindings := []key.Binding{k.Close, k.InternalEntries, down, up, k.PageDown, k.PageUp, k.Top, k.Bottom, k.ClearSearch}
+	for i, b := range bindings {
+		bindings[i] = unprintable(b)
+	}
+	return bindings
+}
+
+// PlannerHelp lists the bindings of the capacity planner, closing first so
+// that a narrow help line keeps it.
+func (k KeyMap) PlannerHelp() []key.Binding {
+	next, prev := k.NextItem, k.PrevItem
+	next.SetHelp(next.Help().Key, "next field")
+	prev.SetHelp(prev.Help().Key, "previous field")
+	return []key.Binding{k.Close, next, prev, k.Decrease, k.Increase, k.PlannerAdd, k.PlannerRemove, k.PlannerReset, k.PlannerNew}
+}
+
+// unprintable returns a copy of a binding without its printable keys,
+// disabled if no key is left.
+func unprintable(b key.Binding) key.Binding {
+	var keys []string
+	for _, k := range b.Keys() {
+		if utf8.RuneCountInString(k) > 1 {
+			keys = append(keys, k)
+		}
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	b.SetEnabled(len(keys) > 0)
+	return b
+}
+
+// FullHelp implements help.KeyMap and lists the bindings of the help
+// overlay in columns: navigation, layout, events, history, layout findings
+// and the capacity planner, display, and application. Keys of missing
+// sources are disabled.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.NextPool, k.PrevPool, k.NextItem, k.PrevItem, k.PageDown, k.PageUp, k.Toggle, k.Back},
+		{k.NextPane, k.Zoom, k.Wider, k.Narrower, k.Taller, k.Shorter},
+		{k.EventClass, k.EventPool, k.History, k.Lint, k.Planner},
+		{k.DiskLabel, k.Enclosures, k.NextTheme},
+		{k.Help, k.Quit},
+	}
+}
+
+// describe lists the bindings as a sentence for screen readers, e.g.
+// "Keys: tab, right or l next pool; q or ctrl+c quit."
+func describe(bindings []key.Binding) string {
+	var parts []string
+	for _, b := range bindings {
+		if !b.Enabled() {
+			continue
+		}
+		keys := b.Keys()
+		names := strings.Join(keys, ", ")
+		if len(keys) > 1 {
+			names = strings.Join(keys[:len(keys)-1], ", ") + " or " + keys[len(keys)-1]
+		}
+		parts = append(parts, names+" "+b.Help().Desc)
+	}
+	return "Keys: " + strings.Join(parts, "; ") + "."
+}
@@ -0,0 +1,333 @@
+// Package layout arranges the panes of the TUI depending on the terminal
+// size. A layout is a tree of splits: every split divides its area between
+// two children, side by side or stacked, by an adjustable ratio, and every
+// leaf is a pane. Larger terminals get trees with more panes.
+package layout
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PaneID names a pane.
+type PaneID string
+
+// Panes of the TUI.
+const (
+	PanePools    PaneID = "pools"
+	PaneTopology PaneID = "topology"
+	PaneDetails  PaneID = "details"
+	PaneEvents   PaneID = "events"
+	PaneMetrics  PaneID = "metrics"
+)
+
+// Direction is how a split divides its area.
+type Direction int
+
+// Split directions.
+const (
+	// Horizontal places the children side by side
+	Horizontal Direction = iota
+
+	// Vertical stacks the children
+	Vertical
+)
+
+// Ratio limits, so that resizing never hides a pane completely.
+const (
+	minRatio = 0.15
+	maxRatio = 0.85
+)
+
+// Node is a split or, if Pane is set, a pane.
+type Node struct {
+	// Pane is the pane shown by a leaf; empty for splits
+	Pane PaneID
+
+	// Dir, Ratio, First and Second describe a split: First gets Ratio of
+	// the width (Horizontal) or height (Vertical), Second the rest
+	Dir    Direction
+	Ratio  float64
+	First  *Node
+	Second *Node
+}
+
+// Leaf returns a node showing a pane.
+func Leaf(pane PaneID) *Node {
+	return &Node{Pane: pane}
+}
+
+// Split returns a node dividing its area between two children.
+func Split(dir Direction, ratio float64, first, second *Node) *Node {
+	return &Node{Dir: dir, Ratio: ratio, First: first, Second: second}
+}
+
+// Rect is the area of a pane in cells.
+type Rect struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Contains reports whether the cell x, y lies in the rectangle.
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// Breakpoint selects a tree for terminals of at least a given size.
+type Breakpoint struct {
+	MinWidth  int
+	MinHeight int
+	Root      *Node
+}
+
+// Layout picks the tree for the terminal size and tracks the zoomed pane.
+type Layout struct {
+	// Breakpoints are tried in order; the first one the terminal is large
+	// enough for is used
+	Breakpoints []Breakpoint
+
+	// Zoomed is the pane shown alone on the whole screen, empty for none
+	Zoomed PaneID
+}
+
+// New returns the default layout:
+//
+//   - 120x30 and larger: pool list and metrics on the left, topology and
+//     details on the right, events below them
+//   - 80x20 and larger: pool list on the left, topology above details on
+//     the right
+//   - smaller: the topology only
+//
+// Returns:
+//   - *Layout: The layout with its breakpoints
+func New() *Layout {
+	return &Layout{Breakpoints: []Breakpoint{
+		{MinWidth: 120, MinHeight: 30, Root: Split(Horizontal, 0.22,
+			Split(Vertical, 0.55, Leaf(PanePools), Leaf(PaneMetrics)),
+			Split(Vertical, 0.7,
+				Split(Horizontal, 0.65, Leaf(PaneTopology), Leaf(PaneDetails)),
+				Leaf(PaneEvents)))},
+		{MinWidth: 80, MinHeight: 20, Root: Split(Horizontal, 0.25,
+			Leaf(PanePools),
+			Split(Vertical, 0.7, Leaf(PaneTopology), Leaf(PaneDetails)))},
+		{Root: Leaf(PaneTopology)},
+	}}
+}
+
+// root returns the tree for a terminal size, or the zoomed pane alone.
+func (l *Layout) root(width, height int) *Node {
+	if l.Zoomed != "" {
+		return Leaf(l.Zoomed)
+	}
+	for _, bp := range l.Breakpoints {
+		if width >= bp.MinWidth && height >= bp.MinHeight {
+			return bp.Root
+		}
+	}
+	return Leaf(PaneTopology)
+}
+
+// Arrange computes the area of every visible pane.
+//
+// Parameters:
+//   - width, height: The size of the screen area to fill
+//
+// Returns:
+//   - map[PaneID]Rect: Areas of the visible panes
+func (l *Layout) Arrange(width, height int) map[PaneID]Rect {
+	rects := make(map[PaneID]Rect)
+	arrange(l.root(width, height), Rect{Width: width, Height: height}, rects)
+	return rects
+}
+
+// arrange divides area among the leaves of node.
+func arrange(node *Node, area Rect, rects map[PaneID]Rect) {
+	if node.Pane != "" {
+		rects[node.Pane] = area
+		return
+	}
+	first, second := splitArea(node, area)
+	arrange(node.First, first, rects)
+	arrange(node.Second, second, rects)
+}
+
+// splitArea divides the area of a split between its children.
+func splitArea(node *Node, area Rect) (Rect, Rect) {
+	first, second := area, area
+	if node.Dir == Horizontal {
+		first.Width = int(float64(area.Width)*node.Ratio + 0.5)
+		second.X += first.Width
+		second.Width -= first.Width
+	} else {
+		first.Height = int(float64(area.Height)*node.Ratio + 0.5)
+		second.Y += first.Height
+		second.Height -= first.Height
+	}
+	return first, second
+}
+
+// Panes lists the visible panes in reading order, for focus cycling.
+//
+// Parameters:
+//   - width, height: The size of the screen area to fill
+//
+// Returns:
+//   - []PaneID: The visible panes
+func (l *Layout) Panes(width, height int) []PaneID {
+	var panes []PaneID
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		if node.Pane != "" {
+			panes = append(panes, node.Pane)
+			return
+		}
+		walk(node.First)
+		walk(node.Second)
+	}
+	walk(l.root(width, height))
+	return panes
+}
+
+// Resize grows or shrinks a pane by moving the nearest split in the given
+// direction that contains it. The split keeps both sides at least 15%.
+//
+// Parameters:
+//   - width, height: The size of the screen area, selecting the tree
+//   - pane: The pane to resize
+//   - dir: Horizontal to change the width, Vertical to change the height
+//   - delta: Share of the split to add to the pane, negative to shrink it
+//
+// Returns:
+//   - bool: Whether a split was moved
+func (l *Layout) Resize(width, height int, pane PaneID, dir Direction, delta float64) bool {
+	if l.Zoomed != "" {
+		return false
+	}
+	path := find(l.root(width, height), pane)
+	// Walk up from the pane to the nearest split in the direction
+	for i := len(path) - 2; i >= 0; i-- {
+		split := path[i]
+		if split.Dir != dir {
+			continue
+		}
+		if path[i+1] == split.Second {
+			delta = -delta
+		}
+		ratio := min(max(split.Ratio+delta, minRatio), maxRatio)
+		if ratio == split.Ratio {
+			return false
+		}
+		split.Ratio = ratio
+		return true
+	}
+	return false
+}
+
+// find returns the nodes from root down to the leaf showing pane, or nil.
+func find(node *Node, pane PaneID) []*Node {
+	if node.Pane != "" {
+		if node.Pane == pane {
+			return []*Node{node}
+		}
+		return nil
+	}
+	for _, child := range []*Node{node.First, node.Second} {
+		if path := find(child, pane); path != nil {
+			return append([]*Node{node}, path...)
+		}
+	}
+	return nil
+}
+
+// ToggleZoom shows a pane alone on the whole screen, or restores the
+// layout if it is already zoomed.
+//
+// Parameters:
+//   - pane: The pane to zoom
+func (l *Layout) ToggleZoom(pane PaneID) {
+	if l.Zoomed != "" {
+		l.Zoomed = ""
+	} else {
+		l.Zoomed = pane
+	}
+}
+
+// Styles frame and name the panes.
+type Styles struct {
+	// Frame and FocusFrame are the borders of unfocused and focused panes
+	Frame      lipgloss.Style
+	FocusFrame lipgloss.Style
+
+	// Title and FocusTitle style the pane names
+	Title      lipgloss.Style
+	FocusTitle lipgloss.Style
+}
+
+// Render draws every visible pane into its area. A single visible pane is
+// drawn without a frame; otherwise each pane is framed with its name in the
+// first line, and the focused pane uses the focus styles.
+//
+// Parameters:
+//   - width, height: The size of the screen area to fill
+//   - focused: The pane with the keyboard focus
+//   - st: Frame and title styles
+//   - render: Draws the content of a pane in the given size
+//
+// Returns:
+//   - string: The screen area, width x height cells
+func (l *Layout) Render(width, height int, focused PaneID, st Styles,
+	render func(pane PaneID, width, height int) string) string {
+	root := l.root(width, height)
+	if root.Pane != "" {
+		return Fit(render(root.Pane, width, height), width, height)
+	}
+
+	var draw func(node *Node, area Rect) string
+	draw = func(node *Node, area Rect) string {
+		if node.Pane == "" {
+			first, second := splitArea(node, area)
+			if node.Dir == Horizontal {
+				return lipgloss.JoinHorizontal(lipgloss.Top, draw(node.First, first), draw(node.Second, second))
+			}
+			return lipgloss.JoinVertical(lipgloss.Left, draw(node.First, first), draw(node.Second, second))
+		}
+
+		frame, title := st.Frame, st.Title
+		if node.Pane == focused {
+			frame, title = st.FocusFrame, st.FocusTitle
+		}
+		inner := ContentRect(Rect{Width: area.Width, Height: area.Height})
+		content := title.Render(string(node.Pane)) + "\n" + render(node.Pane, inner.Width, inner.Height)
+		return frame.Render(Fit(content, inner.Width, inner.Height+1))
+	}
+	return draw(root, Rect{Width: width, Height: height})
+}
+
+// ContentRect returns where the content of a framed pane is drawn: inside
+// the border and below the title line.
+//
+// Parameters:
+//   - area: The pane's area, including the frame
+//
+// Returns:
+//   - Rect: The content area
+func ContentRect(area Rect) Rect {
+	return Rect{
+		X:      area.X + 1,
+		Y:      area.Y + 2,
+		Width:  max(area.Width-2, 0),
+		Height: max(area.Height-3, 0),
+	}
+}
+
+// Fit cuts or pads content to exactly width x height cells.
+//
+// Parameters:
+//   - content: The text to fit, possibly styled
+//   - width, height: The size in cells
+//
+// Returns:
+//   - string: The fitted text
+func Fit(content string, width, height int) string {
+	cut := lipgloss.NewStyle().MaxWidth(width).MaxHeight(height).Render(content)
+	return lipgloss.Place(width, height, lipgloss.Left, lipgloss.Top, cut)
+}
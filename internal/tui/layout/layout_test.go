@@ -0,0 +1,113 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestArrangeBreakpoints(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          []PaneID
+	}{
+		{160, 50, []PaneID{PanePools, PaneMetrics, PaneTopology, PaneDetails, PaneEvents}},
+		{100, 25, []PaneID{PanePools, PaneTopology, PaneDetails}},
+		{100, 15, []PaneID{PaneTopology}},
+		{60, 40, []PaneID{PaneTopology}},
+	}
+	for _, tt := range tests {
+		l := New()
+		got := l.Panes(tt.width, tt.height)
+		if len(got) != len(tt.want) {
+			t.Errorf("%dx%d: panes %v, want %v", tt.width, tt.height, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%dx%d: panes %v, want %v", tt.width, tt.height, got, tt.want)
+				break
+			}
+		}
+
+		// The panes cover the screen exactly once
+		area := 0
+		for _, r := range l.Arrange(tt.width, tt.height) {
+			area += r.Width * r.Height
+		}
+		if area != tt.width*tt.height {
+			t.Errorf("%dx%d: panes cover %d cells", tt.width, tt.height, area)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	l := New()
+	before := l.Arrange(100, 25)
+
+	// The pool list is the first child of a horizontal split
+	if !l.Resize(100, 25, PanePools, Horizontal, 0.1) {
+		t.Fatal("widening the pool list failed")
+	}
+	after := l.Arrange(100, 25)
+	if after[PanePools].Width <= before[PanePools].Width || after[PaneTopology].Width >= before[PaneTopology].Width {
+		t.Errorf("widths after widening the pool list: pools %d, topology %d", after[PanePools].Width, after[PaneTopology].Width)
+	}
+
+	// The topology is the second child: widening it narrows the pool list
+	l.Resize(100, 25, PaneTopology, Horizontal, 0.1)
+	if got := l.Arrange(100, 25)[PanePools].Width; got != before[PanePools].Width {
+		t.Errorf("pool list width %d after widening both, want %d", got, before[PanePools].Width)
+	}
+
+	// Splits stop at the limits
+	for l.Resize(100, 25, PanePools, Horizontal, -0.1) {
+	}
+	if got := l.Breakpoints[1].Root.Ratio; got != minRatio {
+		t.Errorf("ratio %v after shrinking repeatedly, want %v", got, minRatio)
+	}
+
+	// No vertical split contains the pool list
+	if l.Resize(100, 25, PanePools, Vertical, 0.1) {
+		t.Error("pool list resized vertically")
+	}
+}
+
+func TestZoom(t *testing.T) {
+	l := New()
+	l.ToggleZoom(PaneDetails)
+	rects := l.Arrange(120, 40)
+	if len(rects) != 1 || rects[PaneDetails] != (Rect{Width: 120, Height: 40}) {
+		t.Errorf("zoomed arrangement %v", rects)
+	}
+	if l.Resize(120, 40, PaneDetails, Horizontal, 0.1) {
+		t.Error("resized a zoomed pane")
+	}
+	l.ToggleZoom(PaneDetails)
+	if got := len(l.Panes(120, 40)); got != 5 {
+		t.Errorf("%d panes after unzooming, want 5", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	st := Styles{Frame: lipgloss.NewStyle().Border(lipgloss.NormalBorder())}
+	st.FocusFrame = st.Frame
+	render := func(pane PaneID, width, height int) string {
+		return strings.Repeat(string(pane)+" content that is far too long to fit\n", height+5)
+	}
+
+	for _, size := range [][2]int{{160, 50}, {100, 25}, {60, 10}} {
+		out := New().Render(size[0], size[1], PaneTopology, st, render)
+		lines := strings.Split(out, "\n")
+		if len(lines) != size[1] {
+			t.Errorf("%dx%d: %d lines", size[0], size[1], len(lines))
+		}
+		for i, line := range lines {
+			if w := lipgloss.Width(line); w != size[0] {
+				t.Errorf("%dx%d: line %d is %d cells wide", size[0], size[1], i, w)
+				break
+			}
+		}
+	}
+}
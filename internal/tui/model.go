@@ -1,32 +1,248 @@
 package tui
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/tui/layout"
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
 	"github.com/petecog/vizfsulizer/internal/tui/views"
 	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
 )
 
+// Options configures the TUI. Zero values select the defaults.
+type Options struct {
+	// Source is where pool data is collected from (default: mock data)
+	Source source.Source
+
+	// Fleet replaces Source with several hosts, shown in a fleet overview
+	// that drills down into the pools of one host
+	Fleet *source.Fleet
+
+	// Events is the event log of the Source's host, followed live in the
+	// events pane (default: none). It is not followed for a Fleet.
+	Events source.EventSource
+
+	// History reads the command history of the Source's pools, shown on
+	// demand (default: none). It is not read for a Fleet.
+	History source.HistorySource
+
+	// RefreshInterval is how often pool data is collected again.
+	// Zero collects only once at startup. Sources that stream updates,
+	// such as remote agents, push them instead.
+	RefreshInterval time.Duration
+
+	// Keybindings maps actions to keys (default: config.DefaultKeybindings)
+	Keybindings map[string][]string
+
+	// DisplayMode selects color or black & white status display
+	// (default: config.DisplayModeAuto)
+	DisplayMode config.DisplayMode
+
+	// Charset selects Unicode or ASCII-only drawing (default: config.CharsetAuto)
+	Charset config.Charset
+
+	// ScreenReader renders linear text with focus announcements instead
+	// of the visual layout
+	ScreenReader bool
+
+	// Themes are the color themes to cycle through (default: the default theme)
+	Themes []styles.Theme
+
+	// Theme is the name of the initial theme (default: the first of Themes)
+	Theme string
+
+	// DiskLabel is the identity leaves are labelled by, one of
+	// zfs.DiskLabels (default: "name")
+	DiskLabel string
+
+	// SlotsPerRow is the number of slots per row of the enclosure map
+	// (default: views.DefaultEnclosureColumns)
+	SlotsPerRow int
+
+	// Analyzer judges the health and layout of the pools, applying the
+	// configured limits (default: the limits of the status package)
+	Analyzer *status.Analyzer
+}
+
 // Model represents the main application state and handles the core UI logic.
-// It manages the viewport, pool view, and pool selection state.
+// It manages the pane layout, the viewport of the topology pane, the pool
+// and metrics views, and pool selection state.
 type Model struct {
-	viewport viewport.Model  // Manages scrollable view area
-	poolView *views.PoolView // Handles pool visualization
-	pools    []*zfs.Pool     // List of ZFS pools to display
-	selected int             // Currently selected pool index
+	viewport viewport.Model     // Scrollable content of the topology pane
+	poolView *views.PoolView    // Handles pool visualization
+	metrics  *views.MetricsView // Renders the metrics pane
+	pools    []*zfs.Pool        // List of ZFS pools to display
+	selected int                // Currently selected pool index
+
+	fleet     *source.Fleet         // Hosts to collect, nil for a single source
+	fleetView *views.FleetView      // Renders the fleet overview
+	hosts     []source.HostSnapshot // Last fleet collection
+	host      int                   // Index of the host drilled into, -1 for the overview
+
+	layout    *layout.Layout // Arranges the panes for the terminal size
+	focusPane layout.PaneID  // Pane receiving layout keys and highlighted
+	width     int            // Terminal width, 0 until the first WindowSizeMsg
+	height    int            // Terminal height
+
+	src      source.Source      // Where pool data is collected from
+	updates  chan source.Update // Pushed snapshots if src is a Streamer, else nil
+	interval time.Duration      // Time between collections, 0 for none
+
+	eventSrc     source.EventSource      // Event log followed, nil for none
+	eventUpdates chan source.EventUpdate // Events pushed by eventSrc
+	events       *views.EventsView       // Renders the followed events, nil for none
+
+	historySrc  source.HistorySource // Where pool histories are read, nil for none
+	history     *views.HistoryView   // Renders the history overlay
+	showHistory bool                 // Whether the history overlay is open
+	query       string               // Search typed into the history overlay
+
+	enclosures *views.EnclosureView // Renders the enclosure map overlay
+	lint       *views.LintView      // Renders the layout findings overlay
+	page       string               // Paged overlay open: pageEnclosures, pageLint or none
+	pageTop    int                  // First line of the paged overlay shown
+
+	planner     *views.PlannerView // Renders the capacity planner overlay
+	showPlanner bool               // Whether the capacity planner is open
+
+	keys     KeyMap         // Key bindings of every action
+	help     help.Model     // Renders the key help footer and overlay
+	showHelp bool           // Whether the help overlay is open
+	err      error          // Last collection error, shown until the next success
+	styles   *styles.Styles // Styles for the current display mode and theme
+	mode     config.DisplayMode
+	charset  config.Charset
+	themes   []styles.Theme
+	theme    int // Index of the current theme in themes
+
+	screenReader bool   // Render linear text instead of the visual layout
+	announcement string // What changed last, shown first in screen reader mode
+
+	tooltip   string       // Full name of the hovered, shortened VDev name
+	lastClick time.Time    // Time of the last left click, for double-clicks
+	lastHit   views.Region // Region of the last left click
 }
 
-// NewModel creates and initializes a new Model with default values.
+// doubleClickInterval is the longest time between two clicks on the same
+// VDev that counts as a double-click.
+const doubleClickInterval = 400 * time.Millisecond
+
+// snapshotMsg carries a freshly collected snapshot.
+type snapshotMsg *source.Snapshot
+
+// fleetMsg carries a freshly collected snapshot of every host of a fleet.
+type fleetMsg []source.HostSnapshot
+
+// collectErrMsg reports a failed collection.
+type collectErrMsg struct{ err error }
+
+// eventMsg carries an event, or the error following the event log.
+type eventMsg source.EventUpdate
+
+// historyMsg carries the history of a pool, or the error reading it.
+type historyMsg struct {
+	pool    string
+	entries []*zfs.HistoryEntry
+	err     error
+}
+
+// refreshMsg triggers the next collection.
+type refreshMsg struct{}
+
+// NewModel creates and initializes a new Model.
 // It sets up the viewport with zero initial size (will be updated later)
 // and creates a new PoolView instance.
 //
+// Parameters:
+//   - opts: Source, refresh interval, keybindings and display settings;
+//     zero values select defaults
+//
 // Returns:
 //   - Model: A new Model instance ready for use
-func NewModel() Model {
+func NewModel(opts Options) Model {
+	if opts.Source == nil {
+		opts.Source = source.Mock{}
+	}
+
+	if len(opts.Themes) == 0 {
+		opts.Themes = []styles.Theme{styles.DefaultTheme()}
+	}
+	theme := 0
+	for i, t := range opts.Themes {
+		if t.Name == opts.Theme {
+			theme = i
+		}
+	}
+
+	st := styles.New(opts.DisplayMode, opts.Charset, opts.Themes[theme])
 	m := Model{
-		viewport: viewport.New(0, 0), // Start with zero size, will be updated
-		poolView: views.NewPoolView(),
-		selected: 0,
+		viewport:  viewport.New(0, 0), // Start with zero size, will be updated
+		poolView:  views.NewPoolView(st),
+		metrics:   views.NewMetricsView(st),
+		fleet:     opts.Fleet,
+		host:      -1,
+		fleetView: views.NewFleetView(st),
+		styles:    st,
+
+		enclosures: views.NewEnclosureView(st, opts.SlotsPerRow),
+		lint:       views.NewLintView(st),
+		planner:    views.NewPlannerView(st),
+		mode:       opts.DisplayMode,
+		charset:    opts.Charset,
+		themes:     opts.Themes,
+		theme:      theme,
+		selected:   0,
+		src:        opts.Source,
+		interval:   opts.RefreshInterval,
+		keys:       NewKeyMap(opts.Keybindings),
+		help:       help.New(),
+
+		layout:    layout.New(),
+		focusPane: layout.PaneTopology,
+
+		screenReader: opts.ScreenReader,
+	}
+	if _, ok := m.src.(source.Streamer); ok && m.fleet == nil {
+		m.updates = make(chan source.Update)
+	}
+	if opts.Events != nil && m.fleet == nil {
+		m.eventSrc = opts.Events
+		m.eventUpdates = make(chan source.EventUpdate)
+		m.events = views.NewEventsView(st)
+	}
+	if opts.History != nil && m.fleet == nil {
+		m.historySrc = opts.History
+		m.history = views.NewHistoryView(st)
+	}
+	if opts.DiskLabel != "" {
+		m.poolView.SetLabel(opts.DiskLabel)
+		m.enclosures.SetLabel(opts.DiskLabel)
+	}
+	if opts.Analyzer != nil {
+		m.poolView.SetAnalyzer(opts.Analyzer)
+		m.fleetView.SetAnalyzer(opts.Analyzer)
+		m.lint.SetAnalyzer(opts.Analyzer)
+	}
+	m.viewport.KeyMap = m.keys.Viewport()
+	m.keys.Back.SetEnabled(m.fleet != nil)
+	m.keys.History.SetEnabled(m.history != nil)
+	m.keys.EventClass.SetEnabled(m.events != nil)
+	m.keys.EventPool.SetEnabled(m.events != nil)
+	m.keys.Enclosures.SetEnabled(false) // until a snapshot has enclosures
+	m.setStyles(st)
+	if m.fleet != nil {
+		m.announcement = fmt.Sprintf("Fleet of %d hosts.", len(m.fleet.Hosts()))
 	}
 	return m
 }
@@ -35,19 +251,113 @@ func NewModel() Model {
 // This is called once when the program starts.
 //
 // Returns:
-//   - tea.Cmd: Command to fetch initial pool data
+//   - tea.Cmd: Command to fetch initial pool data, or to start streaming
+//     it, and to start following events
 func (m Model) Init() tea.Cmd {
+	cmd := m.collect()
+	if m.updates != nil {
+		cmd = tea.Batch(m.stream(), m.nextUpdate())
+	}
+	if m.eventSrc != nil {
+		cmd = tea.Batch(cmd, m.followEvents(), m.nextEvent())
+	}
+	return cmd
+}
+
+// followEvents returns a command starting to follow the event log. It
+// runs for the lifetime of the program.
+func (m Model) followEvents() tea.Cmd {
+	src, updates := m.eventSrc, m.eventUpdates
+	return func() tea.Msg {
+		go func() {
+			if err := src.Events(context.Background(), updates); err != nil {
+				updates <- source.EventUpdate{Err: err}
+			}
+		}()
+		return nil
+	}
+}
+
+// nextEvent returns a command waiting for the next event.
+func (m Model) nextEvent() tea.Cmd {
+	updates := m.eventUpdates
+	return func() tea.Msg {
+		return eventMsg(<-updates)
+	}
+}
+
+// stream returns a command starting the source's stream of updates. It
+// runs for the lifetime of the program.
+func (m Model) stream() tea.Cmd {
+	streamer, updates := m.src.(source.Streamer), m.updates
 	return func() tea.Msg {
-		pools, _ := zfs.GetPools()
-		return pools
+		go streamer.Stream(context.Background(), updates)
+		return nil
 	}
 }
 
+// nextUpdate returns a command waiting for the next update pushed by a
+// streaming source.
+func (m Model) nextUpdate() tea.Cmd {
+	updates := m.updates
+	return func() tea.Msg {
+		u := <-updates
+		if u.Err != nil {
+			return collectErrMsg{u.Err}
+		}
+		return snapshotMsg(u.Snapshot)
+	}
+}
+
+// collect returns a command collecting a snapshot from the source, or
+// from every host of the fleet.
+func (m Model) collect() tea.Cmd {
+	if fleet := m.fleet; fleet != nil {
+		return func() tea.Msg {
+			return fleetMsg(fleet.Collect(context.Background()))
+		}
+	}
+	src := m.src
+	return func() tea.Msg {
+		snap, err := src.Collect(context.Background())
+		if err != nil {
+			return collectErrMsg{err}
+		}
+		return snapshotMsg(snap)
+	}
+}
+
+// scheduleRefresh returns a command triggering the next collection after
+// the refresh interval, or nil if periodic refresh is disabled. Streaming
+// sources are not polled; the command waits for their next update.
+func (m Model) scheduleRefresh() tea.Cmd {
+	if m.updates != nil {
+		return m.nextUpdate()
+	}
+	if m.interval <= 0 {
+		return nil
+	}
+	return tea.Tick(m.interval, func(time.Time) tea.Msg { return refreshMsg{} })
+}
+
 // Update implements tea.Model and handles all state updates.
 // It processes different types of messages:
-//   - WindowSizeMsg: Updates viewport dimensions
-//   - KeyMsg: Handles keyboard input for navigation and quitting
-//   - []*zfs.Pool: Updates pool data and view
+//   - WindowSizeMsg: Arranges the panes and sizes the viewport to the
+//     topology pane
+//   - KeyMsg: Handles keyboard input through the key map: navigation, pane
+//     focus, zoom and resizing, event filters, theme switching, the help
+//     and history overlays, the capacity planner and quitting
+//   - MouseMsg: Scrolls with the wheel, focuses clicked panes, handles
+//     clicks on pools, tabs and VDevs, and shows shortened names in full
+//     when hovered
+//   - snapshotMsg: Updates pool data and view, then schedules the next refresh
+//   - fleetMsg: Updates the fleet overview and, when drilled down, the
+//     pools of the host, then schedules the next refresh
+//   - collectErrMsg: Records the error and schedules the next refresh
+//   - eventMsg: Adds the event to the events pane, or shows the error,
+//     and waits for the next one
+//   - historyMsg: Shows the history read in the history overlay
+//   - refreshMsg: Starts the next collection
 //
 // Parameters:
 //   - msg: The message to process
@@ -59,45 +369,983 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.viewport.Width = msg.Width
-		m.viewport.Height = msg.Height
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		m.arrange()
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
+		if m.showHelp {
+			// The overlay is modal: only closing it and quitting work
+			switch {
+			case key.Matches(msg, m.keys.Help, m.keys.Close):
+				m.showHelp = false
+				m.announcement = "Help closed."
+			case key.Matches(msg, m.keys.Quit):
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		if m.showHistory {
+			return m.historyKey(msg)
+		}
+		if m.showPlanner {
+			return m.plannerKey(msg)
+		}
+		if m.page != "" {
+			return m.pageKey(msg)
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
-		case "tab", "right", "l":
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+			m.announcement = "Help opened."
+			return m, nil
+		}
+
+		if m.inFleet() {
+			// The overview only moves the cursor and opens hosts
+			switch {
+			case key.Matches(msg, m.keys.NextItem, m.keys.PrevItem):
+				delta := 1
+				if key.Matches(msg, m.keys.PrevItem) {
+					delta = -1
+				}
+				if m.fleetView.MoveCursor(delta) {
+					m.announcement = m.fleetView.AnnounceCursor()
+					m.render()
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Toggle):
+				m.openHost()
+				return m, nil
+			case !key.Matches(msg, m.keys.NextTheme):
+				return m, nil
+			}
+		}
+
+		if m.events != nil {
+			if handled := m.handleEventKey(msg); handled {
+				return m, nil
+			}
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Back):
+			m.host = -1
+			m.err = nil
+			m.arrange()
+			m.announcement = "Fleet overview. " + m.fleetView.AnnounceCursor()
+			m.render()
+			return m, nil
+		case key.Matches(msg, m.keys.NextPool):
 			if len(m.pools) > 0 {
 				m.selected = (m.selected + 1) % len(m.pools)
 				m.poolView.SetSelected(m.selected)
-				m.viewport.SetContent(m.poolView.Render())
+				m.announcement = m.poolView.AnnouncePool()
+				m.render()
 			}
-		case "shift+tab", "left", "h":
+			return m, nil
+		case key.Matches(msg, m.keys.PrevPool):
 			if len(m.pools) > 0 {
 				m.selected = (m.selected - 1 + len(m.pools)) % len(m.pools)
 				m.poolView.SetSelected(m.selected)
-				m.viewport.SetContent(m.poolView.Render())
+				m.announcement = m.poolView.AnnouncePool()
+				m.render()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.NextItem, m.keys.PrevItem):
+			delta := 1
+			if key.Matches(msg, m.keys.PrevItem) {
+				delta = -1
+			}
+			if m.poolView.MoveFocus(delta) {
+				m.announcement = m.poolView.AnnounceFocus()
+				m.render()
 			}
+			return m, nil
+		case key.Matches(msg, m.keys.Toggle):
+			if m.poolView.ToggleFocused() {
+				m.announcement = m.poolView.AnnounceFocus()
+				m.render()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.NextPane):
+			panes := m.layout.Panes(m.area())
+			for i, pane := range panes {
+				if pane == m.focusPane {
+					m.focusPane = panes[(i+1)%len(panes)]
+					break
+				}
+			}
+			m.announcement = "Pane " + string(m.focusPane) + "."
+			return m, nil
+		case key.Matches(msg, m.keys.Zoom):
+			m.layout.ToggleZoom(m.focusPane)
+			m.arrange()
+			return m, nil
+		case key.Matches(msg, m.keys.Wider, m.keys.Narrower, m.keys.Taller, m.keys.Shorter):
+			dir, delta := layout.Horizontal, resizeStep
+			if key.Matches(msg, m.keys.Taller, m.keys.Shorter) {
+				dir = layout.Vertical
+			}
+			if key.Matches(msg, m.keys.Narrower, m.keys.Shorter) {
+				delta = -delta
+			}
+			width, height := m.area()
+			if m.layout.Resize(width, height, m.focusPane, dir, delta) {
+				m.arrange()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.History):
+			if m.history == nil || len(m.pools) == 0 {
+				return m, nil
+			}
+			pool := m.pools[m.selected].Name
+			m.history.Load(pool)
+			m.showHistory, m.query = true, ""
+			m.announcement = m.history.Summary()
+			return m, m.readHistory(pool)
+		case key.Matches(msg, m.keys.DiskLabel):
+			m.poolView.CycleLabel()
+			m.enclosures.SetLabel(m.poolView.Label())
+			m.announcement = "Disks labelled by " + m.poolView.Label() + "."
+			m.render()
+			return m, nil
+		case key.Matches(msg, m.keys.Enclosures):
+			if m.enclosures.Len() == 0 {
+				return m, nil
+			}
+			m.openEnclosures()
+			return m, nil
+		case key.Matches(msg, m.keys.Lint):
+			if len(m.pools) == 0 {
+				return m, nil
+			}
+			m.page, m.pageTop = pageLint, 0
+			m.announcement = m.lint.Summary()
+			return m, nil
+		case key.Matches(msg, m.keys.Planner):
+			m.planner.Load(m.selectedPool())
+			m.showPlanner = true
+			m.announcement = m.planner.Title() + ". " + m.planner.Summary()
+			return m, nil
+		case key.Matches(msg, m.keys.NextTheme):
+			m.theme = (m.theme + 1) % len(m.themes)
+			m.setStyles(styles.New(m.mode, m.charset, m.themes[m.theme]))
+			m.announcement = "Theme " + m.themes[m.theme].Name + "."
+			m.render()
+			return m, nil
 		}
 
-	case []*zfs.Pool:
-		m.pools = msg
-		m.poolView.Update(msg)
-		m.poolView.SetSelected(m.selected)
-		m.viewport.SetContent(m.poolView.Render())
+	case tea.MouseMsg:
+		if m.screenReader || m.showHelp || m.showPlanner {
+			return m, nil
+		}
+		if m.showHistory {
+			if tea.MouseEvent(msg).IsWheel() {
+				delta := historyWheelStep
+				if msg.Button == tea.MouseButtonWheelUp {
+					delta = -delta
+				}
+				m.history.Scroll(delta, m.historyRows())
+			}
+			return m, nil
+		}
+		if m.page != "" {
+			if tea.MouseEvent(msg).IsWheel() {
+				delta := historyWheelStep
+				if msg.Button == tea.MouseButtonWheelUp {
+					delta = -delta
+				}
+				m.scrollPage(delta)
+			}
+			return m, nil
+		}
+		if tea.MouseEvent(msg).IsWheel() {
+			if m.inFleet() {
+				m.fleetWheel(msg)
+				return m, nil
+			}
+			break // scroll the viewport
+		}
+		m.handleMouse(msg)
+		return m, nil
+
+	case snapshotMsg:
+		m.applySnapshot(msg)
+		return m, m.scheduleRefresh()
+
+	case fleetMsg:
+		m.hosts = msg
+		m.fleetView.Update(m.hosts)
+		if m.host >= 0 {
+			m.applyHost()
+		}
+		m.render()
+		return m, m.scheduleRefresh()
+
+	case collectErrMsg:
+		m.setErr(msg.err)
+		return m, m.scheduleRefresh()
+
+	case historyMsg:
+		if m.history != nil && msg.pool == m.history.Pool() {
+			m.history.SetEntries(msg.entries, msg.err)
+			m.announcement = m.history.Summary()
+		}
+		return m, nil
+
+	case eventMsg:
+		if msg.Err != nil {
+			m.events.SetErr(msg.Err)
+		} else {
+			m.events.Add(msg.Event)
+		}
+		return m, m.nextEvent()
+
+	case refreshMsg:
+		return m, m.collect()
 	}
 
 	m.viewport, cmd = m.viewport.Update(msg)
 	return m, cmd
 }
 
+// applySnapshot shows a freshly collected snapshot.
+func (m *Model) applySnapshot(snap *source.Snapshot) {
+	m.setErr(nil)
+	m.pools = snap.Pools
+	m.metrics.Update(snap.Pools, snap.ARC)
+	if m.selected >= len(m.pools) {
+		m.selected = 0
+	}
+	m.poolView.SetSelected(m.selected)
+	m.poolView.Update(m.pools)
+	m.enclosures.Update(snap.Enclosures, snap.Pools)
+	m.lint.Update(snap.Pools)
+	m.keys.Enclosures.SetEnabled(m.enclosures.Len() > 0)
+	m.render()
+}
+
+// readHistory returns a command reading the history of a pool.
+func (m Model) readHistory(pool string) tea.Cmd {
+	src := m.historySrc
+	return func() tea.Msg {
+		entries, err := src.History(context.Background(), pool)
+		return historyMsg{pool: pool, entries: entries, err: err}
+	}
+}
+
+// historyWheelStep is the number of history entries scrolled by one turn
+// of the mouse wheel.
+const historyWheelStep = 3
+
+// historyKey handles the keys of the history overlay, which is modal:
+// printable keys edit the search, even if they are bound, so that of the
+// other bindings only those with unprintable keys apply. The keys of the
+// overlays take precedence over those of the main view.
+func (m Model) historyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := m.historyRows()
+	switch {
+	case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+		m.query += string(msg.Runes)
+	case key.Matches(msg, m.keys.Close):
+		m.showHistory = false
+		m.announcement = "History closed."
+		return m, nil
+	case key.Matches(msg, m.keys.InternalEntries):
+		m.history.ToggleInternal()
+	case key.Matches(msg, m.keys.PageUp):
+		m.history.Scroll(-rows, rows)
+	case key.Matches(msg, m.keys.PageDown):
+		m.history.Scroll(rows, rows)
+	case key.Matches(msg, m.keys.Top):
+		m.history.Scroll(-len(m.history.Shown()), rows)
+	case key.Matches(msg, m.keys.Bottom):
+		m.history.Scroll(len(m.history.Shown()), rows)
+	case key.Matches(msg, m.keys.ClearSearch):
+		m.query = ""
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.PrevItem):
+		m.history.Scroll(-1, rows)
+	case key.Matches(msg, m.keys.NextItem):
+		m.history.Scroll(1, rows)
+	case msg.Type == tea.KeyBackspace:
+		if runes := []rune(m.query); len(runes) > 0 {
+			m.query = string(runes[:len(runes)-1])
+		}
+	default:
+		return m, nil
+	}
+	m.history.SetQuery(m.query)
+	m.announcement = m.history.Summary()
+	return m, nil
+}
+
+// historyRows is the number of entries the history overlay draws: the
+// screen without the title, search and help lines.
+func (m *Model) historyRows() int {
+	return max(m.height-3, 1)
+}
+
+// plannerKey handles the keys of the capacity planner, which is modal
+// like the paged overlays: the item keys choose a field, the increase and
+// decrease keys change its value, and the planner or close key closes it.
+// The keys of the overlays take precedence over those of the main view.
+func (m Model) plannerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Close):
+		m.showPlanner = false
+		m.announcement = "Capacity planner closed."
+		return m, nil
+	case key.Matches(msg, m.keys.Decrease):
+		m.planner.Adjust(-1)
+	case key.Matches(msg, m.keys.Increase):
+		m.planner.Adjust(1)
+	case key.Matches(msg, m.keys.PlannerAdd):
+		m.planner.AddGroup()
+	case key.Matches(msg, m.keys.PlannerRemove):
+		if !m.planner.RemoveGroup() {
+			m.announcement = "Choose a group of vdevs to remove; the last one stays."
+			return m, nil
+		}
+	case key.Matches(msg, m.keys.PlannerReset):
+		m.planner.Load(m.selectedPool())
+		m.announcement = m.planner.Title() + ". " + m.planner.Summary()
+		return m, nil
+	case key.Matches(msg, m.keys.PlannerNew):
+		m.planner.Load(nil)
+		m.announcement = m.planner.Title() + ". " + m.planner.Summary()
+		return m, nil
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Planner):
+		m.showPlanner = false
+		m.announcement = "Capacity planner closed."
+		return m, nil
+	case key.Matches(msg, m.keys.PrevItem):
+		m.planner.Move(-1)
+	case key.Matches(msg, m.keys.NextItem):
+		m.planner.Move(1)
+	default:
+		return m, nil
+	}
+	m.announcement = m.planner.Focus() + " " + m.planner.Summary()
+	return m, nil
+}
+
+// selectedPool returns the selected pool, or nil before the first
+// snapshot or on a host without pools.
+func (m *Model) selectedPool() *zfs.Pool {
+	if len(m.pools) == 0 {
+		return nil
+	}
+	return m.pools[m.selected]
+}
+
+// openEnclosures opens the enclosure map, highlighting the slot of the
+// focused disk, and announces where that disk is.
+func (m *Model) openEnclosures() {
+	m.page, m.pageTop = pageEnclosures, 0
+	m.announcement = fmt.Sprintf("Enclosure map of %d enclosures.", m.enclosures.Len())
+	m.enclosures.SetFocus("")
+	node := m.poolView.Focused()
+	if node == nil || node.Disk == nil {
+		return
+	}
+	m.enclosures.SetFocus(node.Disk.Device)
+	if location := node.Disk.Location(); location != "" {
+		m.announcement += " " + node.Name + " is in " + location + "."
+	}
+}
+
+// Paged overlays, which show a long text on the whole screen.
+const (
+	pageEnclosures = "enclosures"
+	pageLint       = "lint"
+)
+
+// pageKey handles the keys of the open paged overlay, which is modal like
+// the help overlay: only scrolling, closing it and quitting work. The keys
+// of the overlays take precedence over those of the main view.
+func (m Model) pageKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	toggle := m.keys.Enclosures
+	if m.page == pageLint {
+		toggle = m.keys.Lint
+	}
+	rows := m.pageRows()
+	switch {
+	case key.Matches(msg, m.keys.Close):
+		m.announcement = m.pageTitle() + " closed."
+		m.page = ""
+	case key.Matches(msg, m.keys.PageDown):
+		m.scrollPage(rows)
+	case key.Matches(msg, m.keys.PageUp):
+		m.scrollPage(-rows)
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, toggle):
+		m.announcement = m.pageTitle() + " closed."
+		m.page = ""
+	case key.Matches(msg, m.keys.NextItem):
+		m.scrollPage(1)
+	case key.Matches(msg, m.keys.PrevItem):
+		m.scrollPage(-1)
+	}
+	return m, nil
+}
+
+// pageTitle is the title of the open paged overlay.
+func (m *Model) pageTitle() string {
+	if m.page == pageLint {
+		return "Layout findings"
+	}
+	return "Enclosure map"
+}
+
+// pageBody renders the whole text of the open paged overlay, as linear
+// text in screen reader mode.
+func (m *Model) pageBody() string {
+	switch {
+	case m.page == pageLint && m.screenReader:
+		return m.lint.RenderLinear()
+	case m.page == pageLint:
+		return m.lint.Render(m.width)
+	case m.screenReader:
+		return m.enclosures.RenderLinear()
+	}
+	return m.enclosures.Render()
+}
+
+// scrollPage scrolls the paged overlay by delta lines, keeping the screen
+// filled.
+func (m *Model) scrollPage(delta int) {
+	lines := strings.Count(m.pageBody(), "\n") + 1
+	m.pageTop = max(min(m.pageTop+delta, lines-m.pageRows()), 0)
+}
+
+// pageRows is the number of lines of a paged overlay drawn: the screen
+// without the title and help lines.
+func (m *Model) pageRows() int {
+	return max(m.height-2, 1)
+}
+
+// handleEventKey handles the keys of the events pane: the filters, and
+// while the pane is focused, moving the cursor and showing the VDev of
+// the selected event.
+//
+// Returns:
+//   - bool: Whether the key was handled
+func (m *Model) handleEventKey(msg tea.KeyMsg) bool {
+	switch {
+	case key.Matches(msg, m.keys.EventClass):
+		m.events.CycleClass()
+		m.announcement = m.events.Filter() + "."
+		return true
+	case key.Matches(msg, m.keys.EventPool):
+		m.events.TogglePool()
+		m.announcement = m.events.Filter() + "."
+		return true
+	case m.focusPane != layout.PaneEvents:
+		return false
+	case key.Matches(msg, m.keys.NextItem, m.keys.PrevItem):
+		delta := 1
+		if key.Matches(msg, m.keys.PrevItem) {
+			delta = -1
+		}
+		if m.events.MoveCursor(delta) {
+			m.announcement = m.events.Announce()
+		}
+		return true
+	case key.Matches(msg, m.keys.Toggle):
+		if e := m.events.Selected(); e != nil {
+			m.showEvent(e)
+		}
+		return true
+	}
+	return false
+}
+
+// showEvent selects the pool of an event and focuses the VDev it is about.
+func (m *Model) showEvent(e *zfs.Event) {
+	for i, pool := range m.pools {
+		if pool.Name == e.Pool && i != m.selected {
+			m.selected = i
+			m.poolView.SetSelected(m.selected)
+		}
+	}
+	if len(m.pools) == 0 || m.pools[m.selected].Name != e.Pool {
+		m.announcement = m.events.Announce() + " Pool " + e.Pool + " not found."
+		return
+	}
+	if m.poolView.FocusEvent(e) {
+		m.announcement = m.poolView.AnnounceFocus()
+	} else {
+		m.announcement = m.poolView.AnnouncePool()
+	}
+	m.render()
+}
+
+// setErr records a collection error, or clears it if err is nil, and
+// makes room for the error line or takes it back.
+func (m *Model) setErr(err error) {
+	hadErr := m.err != nil
+	m.err = err
+	if hadErr != (err != nil) {
+		m.arrange()
+	}
+}
+
+// inFleet reports whether the fleet overview is shown.
+func (m *Model) inFleet() bool {
+	return m.fleet != nil && m.host < 0
+}
+
+// openHost drills down from the fleet overview into the host of the
+// selected row, selecting its pool.
+func (m *Model) openHost() {
+	row, ok := m.fleetView.Selected()
+	if !ok {
+		return
+	}
+	m.host = row.HostIndex
+	m.pools = nil
+	m.selected = 0
+	m.metrics = views.NewMetricsView(m.styles) // the history belongs to the last host
+	m.arrange()
+	m.applyHost()
+	for i, pool := range m.pools {
+		if pool.Name == row.Pool {
+			m.selected = i
+		}
+	}
+	m.poolView.SetSelected(m.selected)
+	m.announcement = "Host " + row.Host + ". " + m.poolView.AnnouncePool()
+	m.render()
+}
+
+// applyHost shows the last collection of the host drilled into.
+func (m *Model) applyHost() {
+	result := m.hosts[m.host]
+	if result.Err != nil {
+		m.setErr(result.Err)
+		return
+	}
+	m.applySnapshot(result.Snapshot)
+}
+
+// fleetWheel moves the fleet cursor with the mouse wheel.
+func (m *Model) fleetWheel(msg tea.MouseMsg) {
+	delta := 1
+	if msg.Button == tea.MouseButtonWheelUp {
+		delta = -1
+	}
+	if m.fleetView.MoveCursor(delta) {
+		m.announcement = m.fleetView.AnnounceCursor()
+	}
+}
+
+// handleFleetMouse selects the clicked row of the fleet overview, and opens
+// its host on a double-click.
+func (m *Model) handleFleetMouse(msg tea.MouseMsg) {
+	row, ok := m.fleetView.RowAt(msg.Y - m.headerLines())
+	if !ok || msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return
+	}
+	now := time.Now()
+	hit := views.Region{Kind: views.RegionRow, Index: row}
+	double := hit == m.lastHit && now.Sub(m.lastClick) < doubleClickInterval
+	m.lastClick, m.lastHit = now, hit
+	if double {
+		m.lastClick = time.Time{}
+		m.openHost()
+		return
+	}
+	m.fleetView.SetCursor(row)
+	m.announcement = m.fleetView.AnnounceCursor()
+}
+
+// handleMouse reacts to clicks and hovering: a click focuses the pane it
+// lands in, a click on a pool in the pool list or on a pool tab selects
+// the pool, a click on a VDev focuses it, a double-click on a VDev expands
+// or collapses it, a click on an event shows the VDev it is about, and
+// hovering a shortened VDev name shows it in full.
+func (m *Model) handleMouse(msg tea.MouseMsg) {
+	if m.inFleet() {
+		m.handleFleetMouse(msg)
+		return
+	}
+
+	// Mouse coordinates are relative to the screen; panes to the area
+	// below the header lines
+	x, y := msg.X, msg.Y-m.headerLines()
+	m.tooltip = ""
+	pane, rect, ok := m.paneAt(x, y)
+	if !ok {
+		return
+	}
+	content := m.contentRect(rect)
+	inside := content.Contains(x, y)
+	x, y = x-content.X, y-content.Y
+	if !inside {
+		x, y = -1, -1 // on the frame or the title line
+	}
+
+	press := msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+	if press {
+		m.focusPane = pane
+	}
+
+	switch pane {
+	case layout.PanePools:
+		if press && y >= 0 && y < len(m.pools) && y != m.selected {
+			m.selected = y
+			m.poolView.SetSelected(m.selected)
+			m.announcement = m.poolView.AnnouncePool()
+			m.render()
+		}
+
+	case layout.PaneEvents:
+		if !press || y < 0 || m.events == nil {
+			return
+		}
+		if idx, ok := m.events.EventAt(y - m.problemLines()); ok {
+			m.events.SetCursor(idx)
+			m.showEvent(m.events.Selected())
+		}
+
+	case layout.PaneTopology:
+		if y < 0 {
+			return
+		}
+		hit, ok := m.poolView.HitTest(x, y+m.viewport.YOffset)
+		switch {
+		case msg.Action == tea.MouseActionMotion:
+			if ok && hit.Truncated {
+				m.tooltip = hit.Name
+			}
+
+		case press && ok:
+			now := time.Now()
+			double := hit == m.lastHit && now.Sub(m.lastClick) < doubleClickInterval
+			m.lastClick, m.lastHit = now, hit
+			if double {
+				m.lastClick = time.Time{} // a third click starts over
+			}
+
+			switch hit.Kind {
+			case views.RegionTab:
+				if hit.Index != m.selected {
+					m.selected = hit.Index
+					m.poolView.SetSelected(m.selected)
+					m.announcement = m.poolView.AnnouncePool()
+				}
+			case views.RegionNode:
+				m.poolView.SetFocus(hit.Index)
+				if double {
+					m.poolView.ToggleFocused()
+				}
+				m.announcement = m.poolView.AnnounceFocus()
+			}
+			m.render()
+		}
+	}
+}
+
+// paneAt returns the visible pane at a position in the pane area.
+func (m *Model) paneAt(x, y int) (layout.PaneID, layout.Rect, bool) {
+	for pane, rect := range m.layout.Arrange(m.area()) {
+		if rect.Contains(x, y) {
+			return pane, rect, true
+		}
+	}
+	return "", layout.Rect{}, false
+}
+
+// contentRect returns where the content of a pane is drawn: inside its
+// frame, or the whole pane if it is the only one and drawn unframed.
+func (m *Model) contentRect(rect layout.Rect) layout.Rect {
+	if len(m.layout.Panes(m.area())) == 1 {
+		return rect
+	}
+	return layout.ContentRect(rect)
+}
+
+// resizeStep is the share of a split moved by one resize key press.
+const resizeStep = 0.05
+
+// headerLines is the number of lines above the panes: the host drilled
+// into from the fleet overview, and the last collection error.
+func (m *Model) headerLines() int {
+	n := 0
+	if m.fleet != nil && m.host >= 0 {
+		n++
+	}
+	if m.err != nil {
+		n++
+	}
+	return n
+}
+
+// area returns the size of the screen area the panes are arranged in:
+// the terminal without the header lines and the footer.
+func (m *Model) area() (int, int) {
+	return m.width, max(m.height-footerHeight-m.headerLines(), 0)
+}
+
+// arrange sizes the viewport to the topology pane after the terminal size
+// or the layout changed, and moves the pane focus to a visible pane. In
+// screen reader mode there are no panes and the viewport fills the area.
+func (m *Model) arrange() {
+	if m.screenReader {
+		m.viewport.Width, m.viewport.Height = m.area()
+		return
+	}
+	rects := m.layout.Arrange(m.area())
+	if rect, ok := rects[layout.PaneTopology]; ok {
+		content := m.contentRect(rect)
+		m.viewport.Width, m.viewport.Height = content.Width, content.Height
+	}
+	if _, ok := rects[m.focusPane]; !ok {
+		m.focusPane = m.layout.Panes(m.area())[0]
+	}
+}
+
+// setStyles switches to new styles, e.g. after the theme was switched.
+func (m *Model) setStyles(st *styles.Styles) {
+	m.styles = st
+	m.poolView.SetStyles(st)
+	m.metrics.SetStyles(st)
+	m.fleetView.SetStyles(st)
+	if m.events != nil {
+		m.events.SetStyles(st)
+	}
+	if m.history != nil {
+		m.history.SetStyles(st)
+	}
+	m.enclosures.SetStyles(st)
+	m.lint.SetStyles(st)
+	m.planner.SetStyles(st)
+	m.help.Styles = st.HelpStyles()
+	m.help.ShortSeparator = st.Glyphs.Separator
+	m.help.Ellipsis = "..."
+	help := m.keys.NextTheme.Help()
+	m.keys.NextTheme.SetHelp(help.Key, actionHelp[config.ActionNextTheme]+" ("+st.Theme.Name+")")
+}
+
+// render updates the viewport content from the pool view, as linear text
+// in screen reader mode. The visual fleet overview is drawn by View
+// directly.
+func (m *Model) render() {
+	if m.inFleet() {
+		if m.screenReader {
+			m.viewport.SetContent(m.fleetView.RenderLinear())
+		}
+		return
+	}
+	if len(m.pools) == 0 {
+		return
+	}
+	if m.events != nil {
+		m.events.SetPool(m.pools[m.selected].Name)
+	}
+	if m.screenReader {
+		m.viewport.SetContent(m.poolView.RenderLinear())
+	} else {
+		m.viewport.SetContent(m.poolView.Render())
+	}
+}
+
+// problemLines is the number of lines the problems of the selected pool
+// take at the top of the events pane, above the followed events.
+func (m *Model) problemLines() int {
+	return strings.Count(m.poolView.RenderEvents(), "\n") + 1
+}
+
+// footerHeight is the number of lines below the viewport.
+const footerHeight = 1
+
 // View implements tea.Model and returns the string to be displayed.
-// It delegates to the viewport's View method to handle scrolling
-// and content display.
+// It draws the panes of the layout, the topology pane through the viewport
+// to handle scrolling, followed by a footer with short key help generated
+// from the key map, or the full name of a hovered VDev. A failed collection
+// is reported above the view, and the overlays replace the view while they
+// are open. In screen reader mode the last announcement comes first, so
+// that it is read out as soon as the screen changes, and key help is a
+// sentence.
 //
 // Returns:
 //   - string: The complete rendered view
 func (m Model) View() string {
-	return m.viewport.View()
+	if m.showHistory {
+		return m.historyOverlay()
+	}
+	if m.showPlanner {
+		return m.plannerOverlay()
+	}
+	if m.page != "" {
+		return m.pageOverlay()
+	}
+	if m.screenReader {
+		return m.linearView()
+	}
+	if m.showHelp {
+		return m.helpOverlay()
+	}
+
+	footer := m.help.ShortHelpView(m.keys.ShortHelp())
+	if m.inFleet() {
+		footer = m.help.ShortHelpView(m.keys.FleetHelp())
+	}
+	if m.tooltip != "" {
+		footer = m.styles.HelpText.Render(m.tooltip)
+	}
+	view := m.panes() + "\n" + footer
+	if m.err != nil {
+		view = m.styles.StatusFaulted.Render("Error collecting pool data: "+m.err.Error()) + "\n" + view
+	}
+	if m.fleet != nil && m.host >= 0 {
+		view = m.styles.Title.UnsetMarginLeft().Render("Host "+m.hosts[m.host].Host) + "\n" + view
+	}
+	return view
+}
+
+// panes renders the panes of the layout, or just the topology before the
+// terminal size is known.
+func (m Model) panes() string {
+	if m.width == 0 {
+		return m.viewport.View()
+	}
+
+	width, height := m.area()
+	if m.inFleet() {
+		return layout.Fit(m.fleetView.Render(width, height), width, height)
+	}
+	st := layout.Styles{
+		Frame:      m.styles.Pane,
+		FocusFrame: m.styles.PaneFocused,
+		Title:      m.styles.PaneTitle,
+		FocusTitle: m.styles.PaneTitleFocused,
+	}
+	return m.layout.Render(width, height, m.focusPane, st, func(pane layout.PaneID, width, height int) string {
+		switch pane {
+		case layout.PaneTopology:
+			return m.viewport.View()
+		case layout.PanePools:
+			return m.poolView.RenderPoolList(width)
+		case layout.PaneDetails:
+			return m.poolView.RenderDetails()
+		case layout.PaneEvents:
+			if m.events == nil {
+				return m.poolView.RenderEvents()
+			}
+			return m.poolView.RenderEvents() + "\n" + m.events.Render(height-m.problemLines())
+		case layout.PaneMetrics:
+			if len(m.pools) == 0 {
+				return ""
+			}
+			return m.metrics.Render(m.pools[m.selected].Name, width)
+		}
+		return ""
+	})
+}
+
+// linearView renders the screen reader view: the announcement, the pool
+// as linear text, and the keys.
+func (m Model) linearView() string {
+	header := m.announcement
+	if m.err != nil {
+		header = "Error collecting pool data: " + m.err.Error()
+	}
+
+	var body, keys string
+	if m.showHelp {
+		var all []key.Binding
+		for _, column := range m.keys.FullHelp() {
+			all = append(all, column...)
+		}
+		keys = describe(all)
+	} else {
+		body = m.viewport.View() + "\n"
+		keys = describe(m.keys.ShortHelp())
+		if m.inFleet() {
+			keys = describe(m.keys.FleetHelp())
+		}
+	}
+
+	if header != "" {
+		header += "\n"
+	}
+	return header + body + keys
+}
+
+// historyOverlay renders the history of the selected pool on the whole
+// screen: a summary, the search, the entries and the keys. In screen
+// reader mode the summary follows the search, as it changes with it.
+func (m Model) historyOverlay() string {
+	summary := m.styles.Title.UnsetMarginLeft().Render(m.history.Summary())
+	search := "Search: " + m.query + "_"
+	if m.screenReader {
+		keys := "Type to search. " + describe(m.keys.HistoryHelp())
+		return search + "\n" + m.history.Summary() + "\n" + m.history.Render(m.width, m.historyRows()) + "\n" + keys
+	}
+	body := m.history.Render(m.width, m.historyRows())
+	if m.width > 0 {
+		body = layout.Fit(body, m.width, m.historyRows())
+	}
+	typing := m.help.Styles.ShortDesc.Render("type to search") + m.help.Styles.ShortSeparator.Render(m.help.ShortSeparator)
+	h := m.help
+	if h.Width > 0 {
+		h.Width = max(h.Width-lipgloss.Width(typing), 1)
+	}
+	return summary + "\n" + search + "\n" + body + "\n" + typing + h.ShortHelpView(m.keys.HistoryHelp())
+}
+
+// pageOverlay renders the open paged overlay on the whole screen: a
+// title, the visible lines of its text and the keys. In screen reader mode
+// the whole text follows the announcement.
+func (m Model) pageOverlay() string {
+	if m.screenReader {
+		return m.announcement + "\n" + m.pageBody() + "\n" + describe(m.keys.PageHelp())
+	}
+	body := m.pageBody()
+	if m.width > 0 {
+		lines := strings.Split(body, "\n")
+		body = layout.Fit(strings.Join(lines[min(m.pageTop, len(lines)):], "\n"), m.width, m.pageRows())
+	}
+	title := m.styles.Title.UnsetMarginLeft().Render(m.pageTitle())
+	return title + "\n" + body + "\n" + m.help.ShortHelpView(m.keys.PageHelp())
+}
+
+// plannerOverlay renders the capacity planner on the whole screen: a
+// title, the fields with the estimate and the keys. In screen reader mode
+// the planner follows the announcement.
+func (m Model) plannerOverlay() string {
+	if m.screenReader {
+		return m.announcement + "\n" + m.planner.RenderLinear() + "\n" + describe(m.keys.PlannerHelp())
+	}
+	body := m.planner.Render()
+	if m.width > 0 {
+		body = layout.Fit(body, m.width, m.pageRows())
+	}
+	title := m.styles.Title.UnsetMarginLeft().Render(m.planner.Title())
+	return title + "\n" + body + "\n" + m.help.ShortHelpView(m.keys.PlannerHelp())
+}
+
+// helpOverlay renders the full key help in a box centered on the screen.
+func (m Model) helpOverlay() string {
+	h := m.help
+	h.ShowAll = true
+	box := lipgloss.NewStyle().
+		Border(m.styles.Glyphs.Box).
+		Padding(1, 2).
+		Render(m.styles.Title.Render("Keys") + "\n\n" + h.FullHelpView(m.keys.FullHelp()))
+	if m.width == 0 {
+		return box
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
 }
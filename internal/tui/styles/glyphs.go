@@ -0,0 +1,103 @@
+package styles
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Glyphs is the set of characters used to draw borders, tree branches, bars
+// and sparklines.
+type Glyphs struct {
+	// Box, Dashed and Double are the border sets; Dashed and Double mark
+	// DEGRADED and FAULTED components in black & white mode
+	Box    lipgloss.Border
+	Dashed lipgloss.Border
+	Double lipgloss.Border
+
+	// Branch connects a tree node to its parent
+	Branch string
+
+	// BarFull and BarEmpty draw the filled and empty parts of a bar
+	BarFull  string
+	BarEmpty string
+
+	// Spark holds the sparkline levels from lowest to highest
+	Spark []string
+
+	// Separator joins items of the help line
+	Separator string
+
+	// Ellipsis marks shortened text
+	Ellipsis string
+}
+
+var (
+	// UnicodeGlyphs uses box-drawing and block characters.
+	UnicodeGlyphs = Glyphs{
+		Box:       BoxBorder,
+		Dashed:    DashedBorder,
+		Double:    DoubleBorder,
+		Branch:    "├─",
+		BarFull:   "█",
+		BarEmpty:  "░",
+		Spark:     []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"},
+		Separator: " • ",
+		Ellipsis:  "…",
+	}
+
+	// ASCIIGlyphs uses printable ASCII only.
+	ASCIIGlyphs = Glyphs{
+		Box:       ASCIIBoxBorder,
+		Dashed:    ASCIIDashedBorder,
+		Double:    ASCIIDoubleBorder,
+		Branch:    "|-",
+		BarFull:   "#",
+		BarEmpty:  "-",
+		Spark:     []string{"_", ".", "-", "~", "=", "*", "#"},
+		Separator: " - ",
+		Ellipsis:  "...",
+	}
+)
+
+// Bar draws a horizontal bar filled to ratio.
+//
+// Parameters:
+//   - ratio: The filled share, clamped to 0-1
+//   - width: Number of characters in the bar
+//
+// Returns:
+//   - string: The bar, e.g. "███████░░░" or "#######---" for 0.7
+func (s *Styles) Bar(ratio float64, width int) string {
+	full := int(min(max(ratio, 0), 1)*float64(width) + 0.5)
+	return strings.Repeat(s.Glyphs.BarFull, full) + strings.Repeat(s.Glyphs.BarEmpty, width-full)
+}
+
+// Sparkline draws one character per value, scaled between the smallest
+// and largest value.
+//
+// Parameters:
+//   - values: The series to draw, oldest first
+//
+// Returns:
+//   - string: The sparkline, e.g. "▁▃▇█" or "_-*#"
+func (s *Styles) Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	levels := s.Glyphs.Spark
+	var sb strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(levels)-1))
+		}
+		sb.WriteString(levels[level])
+	}
+	return sb.String()
+}
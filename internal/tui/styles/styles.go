@@ -1,44 +1,149 @@
 package styles
 
 import (
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/petecog/vizfsulizer/internal/config"
 )
 
-// Style definitions for the TUI components.
-// Each style defines colors and text formatting for different UI elements
-// using the Lipgloss styling library.
-var (
+// Styles holds the style definitions for the TUI components, built for one
+// display mode and theme. Each style defines colors and text formatting for
+// different UI elements using the Lipgloss styling library. In black & white
+// mode no colors are set; status is conveyed by borders, text attributes and
+// markers.
+type Styles struct {
+	// Mode is the resolved display mode the styles were built for
+	Mode config.DisplayMode
+
+	// Charset is the resolved character set the styles were built for
+	Charset config.Charset
+
+	// Glyphs are the drawing characters of the character set
+	Glyphs Glyphs
+
+	// Theme is the color palette the styles were built from
+	Theme Theme
+
 	// StatusOnline defines the style for healthy/online components
-	// Uses bright green (ANSI color 2) to indicate normal operation
-	StatusOnline = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("2")). // Green - indicates healthy state
-			Bold(true)
-
-	// StatusDegraded defines the style for components with reduced functionality
-	// Uses yellow (ANSI color 3) to indicate warning state
-	StatusDegraded = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("3")). // Yellow - indicates warning/degraded
-			Bold(true)
-
-	// StatusFaulted defines the style for failed components
-	// Uses red (ANSI color 1) to indicate critical failure
-	StatusFaulted = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("1")). // Red - indicates failure/error
-			Bold(true)
+	StatusOnline lipgloss.Style
+
+	// StatusDegraded defines the style for components with reduced functionality,
+	// underlined in BW mode
+	StatusDegraded lipgloss.Style
+
+	// StatusFaulted defines the style for failed components, reversed in BW mode
+	StatusFaulted lipgloss.Style
 
 	// PoolName defines the style for ZFS pool names
-	// Uses blue (ANSI color 4) to make pool names stand out
-	PoolName = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("4")). // Blue - highlights pool names
-			Bold(true)
+	PoolName lipgloss.Style
 
 	// VDevType defines the style for virtual device type labels
-	// Uses magenta (ANSI color 5) to distinguish device types
-	VDevType = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("5")) // Magenta - shows device types
+	VDevType lipgloss.Style
 
 	// TreeBranch defines the style for the tree view connection lines
-	// Uses gray (ANSI color 8) to create subtle connection lines
-	TreeBranch = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")) // Gray - subtle tree structure
-)
+	TreeBranch lipgloss.Style
+
+	// PoolBox, VDevBox, Selected, Title, HelpText, TabActive and
+	// TabInactive are the layout styles described in theme.go
+	PoolBox     lipgloss.Style
+	VDevBox     lipgloss.Style
+	Selected    lipgloss.Style
+	Title       lipgloss.Style
+	HelpText    lipgloss.Style
+	TabActive   lipgloss.Style
+	TabInactive lipgloss.Style
+
+	// Pane, PaneFocused, PaneTitle and PaneTitleFocused frame and name the
+	// panes of the multi-pane layout, see theme.go
+	Pane             lipgloss.Style
+	PaneFocused      lipgloss.Style
+	PaneTitle        lipgloss.Style
+	PaneTitleFocused lipgloss.Style
+}
+
+// New builds the styles for a display mode, character set and theme.
+// DisplayModeAuto and CharsetAuto are resolved first, so NO_COLOR and the
+// locale are honoured. The theme is ignored in black & white mode.
+//
+// Parameters:
+//   - mode: The display mode to build styles for
+//   - charset: The character set to draw with
+//   - theme: The color palette to use in RGB mode
+//
+// Returns:
+//   - *Styles: The styles ready for use by the views
+//
+// Example:
+//
+//	st := styles.New(config.DisplayModeBW, config.CharsetASCII, styles.DefaultTheme())
+//	fmt.Println(st.RenderStatus(zfs.VDevStatusFaulted)) // "FAULTED !!"
+func New(mode config.DisplayMode, charset config.Charset, theme Theme) *Styles {
+	charset = charset.Resolve()
+	glyphs := UnicodeGlyphs
+	if charset == config.CharsetASCII {
+		glyphs = ASCIIGlyphs
+	}
+
+	mode = mode.Resolve()
+	if mode == config.DisplayModeBW {
+		return newBW(charset, glyphs, theme)
+	}
+
+	s := &Styles{
+		Mode:    mode,
+		Charset: charset,
+		Glyphs:  glyphs,
+		Theme:   theme,
+
+		StatusOnline:   lipgloss.NewStyle().Foreground(theme.Online).Bold(true),
+		StatusDegraded: lipgloss.NewStyle().Foreground(theme.Degraded).Bold(true),
+		StatusFaulted:  lipgloss.NewStyle().Foreground(theme.Faulted).Bold(true),
+		PoolName:       lipgloss.NewStyle().Foreground(theme.PoolName).Bold(true),
+		VDevType:       lipgloss.NewStyle().Foreground(theme.VDevType),
+		TreeBranch:     lipgloss.NewStyle().Foreground(theme.Tree),
+	}
+	s.applyLayout()
+	return s
+}
+
+// newBW builds black & white styles that rely on text attributes only.
+func newBW(charset config.Charset, glyphs Glyphs, theme Theme) *Styles {
+	s := &Styles{
+		Mode:           config.DisplayModeBW,
+		Charset:        charset,
+		Glyphs:         glyphs,
+		Theme:          theme,
+		StatusOnline:   lipgloss.NewStyle().Bold(true),
+		StatusDegraded: lipgloss.NewStyle().Bold(true).Underline(true),
+		StatusFaulted:  lipgloss.NewStyle().Bold(true).Reverse(true),
+		PoolName:       lipgloss.NewStyle().Bold(true),
+		VDevType:       lipgloss.NewStyle().Italic(true),
+		TreeBranch:     lipgloss.NewStyle(),
+	}
+	s.applyLayout()
+	return s
+}
+
+// HelpStyles returns the styles of the key help footer and overlay: keys
+// in the title color, descriptions in the help color, or bold keys and
+// plain descriptions in black & white mode.
+//
+// Returns:
+//   - help.Styles: Styles for a bubbles help.Model
+func (s *Styles) HelpStyles() help.Styles {
+	keyStyle := lipgloss.NewStyle().Bold(true)
+	descStyle := lipgloss.NewStyle()
+	if s.Mode != config.DisplayModeBW {
+		keyStyle = keyStyle.Foreground(s.Theme.Title)
+		descStyle = descStyle.Foreground(s.Theme.Help)
+	}
+	return help.Styles{
+		Ellipsis:       descStyle,
+		ShortKey:       keyStyle,
+		ShortDesc:      descStyle,
+		ShortSeparator: descStyle,
+		FullKey:        keyStyle,
+		FullDesc:       descStyle,
+		FullSeparator:  descStyle,
+	}
+}
@@ -0,0 +1,125 @@
+package styles
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+func TestNewResolvesNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if got := New(config.DisplayModeAuto, config.CharsetUnicode, DefaultTheme()).Mode; got != config.DisplayModeBW {
+		t.Errorf("auto with NO_COLOR = %s, want bw", got)
+	}
+	if got := New(config.DisplayModeRGB, config.CharsetUnicode, DefaultTheme()).Mode; got != config.DisplayModeRGB {
+		t.Errorf("explicit rgb with NO_COLOR = %s, want rgb", got)
+	}
+
+	t.Setenv("NO_COLOR", "")
+	if got := New(config.DisplayModeAuto, config.CharsetUnicode, DefaultTheme()).Mode; got != config.DisplayModeRGB {
+		t.Errorf("auto without NO_COLOR = %s, want rgb", got)
+	}
+}
+
+func TestBWBorders(t *testing.T) {
+	st := New(config.DisplayModeBW, config.CharsetUnicode, DefaultTheme())
+	tests := []struct {
+		status zfs.VDevStatus
+		corner string
+	}{
+		{zfs.VDevStatusOnline, BoxBorder.TopLeft},
+		{zfs.VDevStatusDegraded, DashedBorder.TopLeft},
+		{zfs.VDevStatusFaulted, DoubleBorder.TopLeft},
+	}
+	for _, tt := range tests {
+		out := st.GetStatusBorderStyle(tt.status).Render("x")
+		if !strings.HasPrefix(out, tt.corner) {
+			t.Errorf("%s border starts %q, want %q", tt.status, out, tt.corner)
+		}
+	}
+}
+
+func TestBWStatusMarkers(t *testing.T) {
+	st := New(config.DisplayModeBW, config.CharsetUnicode, DefaultTheme())
+	tests := map[zfs.VDevStatus]string{
+		zfs.VDevStatusOnline:   "ONLINE",
+		zfs.VDevStatusDegraded: "DEGRADED !",
+		zfs.VDevStatusFaulted:  "FAULTED !!",
+	}
+	for status, want := range tests {
+		if got := st.RenderStatus(status); !strings.Contains(got, want) {
+			t.Errorf("RenderStatus(%s) = %q, want %q", status, got, want)
+		}
+	}
+	if st.BorderLegend() == "" {
+		t.Error("BW mode should have a border legend")
+	}
+	if New(config.DisplayModeRGB, config.CharsetUnicode, DefaultTheme()).BorderLegend() != "" {
+		t.Error("RGB mode should not have a border legend")
+	}
+}
+
+func TestLoadThemes(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+theme: mine
+themes:
+  mine:
+    base: solarized
+    colors:
+      faulted: "#ff00ff"
+      tree: "240"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	themes, err := LoadThemes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(themes) != len(config.Themes)+1 {
+		t.Fatalf("got %d themes, want the built-ins and mine", len(themes))
+	}
+	for i, name := range config.Themes {
+		if themes[i].Name != name || themes[i].Online == "" {
+			t.Errorf("theme %d = %q, want built-in %q with colors", i, themes[i].Name, name)
+		}
+	}
+
+	mine := themes[len(themes)-1]
+	solarized := builtinThemes["solarized"]
+	if mine.Name != "mine" || mine.Faulted != "#ff00ff" || mine.Tree != "240" {
+		t.Errorf("overrides not applied: %+v", mine)
+	}
+	if mine.Online != solarized.Online {
+		t.Errorf("online = %s, want %s from the base theme", mine.Online, solarized.Online)
+	}
+}
+
+func TestThemeColorsCoverRoles(t *testing.T) {
+	var theme Theme
+	for _, role := range config.ThemeColors {
+		if theme.color(role) == nil {
+			t.Errorf("colour role %q has no Theme field", role)
+		}
+	}
+}
+
+func TestBarAndSparkline(t *testing.T) {
+	unicode := New(config.DisplayModeRGB, config.CharsetUnicode, DefaultTheme())
+	ascii := New(config.DisplayModeRGB, config.CharsetASCII, DefaultTheme())
+
+	if got := unicode.Bar(0.7, 10); got != "███████░░░" {
+		t.Errorf("unicode bar = %q", got)
+	}
+	if got := ascii.Bar(1.5, 4); got != "####" {
+		t.Errorf("ascii bar over 100%% = %q", got)
+	}
+	if got := ascii.Sparkline([]float64{0, 5, 10}); got != "_~#" {
+		t.Errorf("ascii sparkline = %q", got)
+	}
+	if got := unicode.Sparkline([]float64{3, 3}); got != "▁▁" {
+		t.Errorf("flat sparkline = %q", got)
+	}
+}
@@ -2,15 +2,19 @@ package styles
 
 import (
 	"github.com/charmbracelet/lipgloss"
+	"github.com/petecog/vizfsulizer/internal/config"
 	"github.com/petecog/vizfsulizer/internal/zfs" // Fix import path
 )
 
-// Theme definitions for the TUI components.
-// This file contains all the styling and theme related constants and functions
-// using the Lipgloss styling library for terminal UI rendering.
+// Border sets for the TUI components.
+// This file contains the border sets and layout styles, and the functions
+// that encode status in borders and text for the current display mode.
+// The colors come from a Theme, see themes.go; the border set in use
+// depends on the character set, see glyphs.go.
 var (
 	// BoxBorder defines the default border characters used for boxes and panels.
 	// Uses Unicode box-drawing characters to create clean, professional borders.
+	// In black & white mode it marks ONLINE components.
 	BoxBorder = lipgloss.Border{
 		Top:         "─", // Horizontal line for top border
 		Bottom:      "─", // Horizontal line for bottom border
@@ -22,84 +26,227 @@ var (
 		BottomRight: "╯", // Corner piece for bottom-right
 	}
 
+	// DashedBorder marks DEGRADED components in black & white mode.
+	DashedBorder = lipgloss.Border{
+		Top:         "╌",
+		Bottom:      "╌",
+		Left:        "┊",
+		Right:       "┊",
+		TopLeft:     "┌",
+		TopRight:    "┐",
+		BottomLeft:  "└",
+		BottomRight: "┘",
+	}
+
+	// DoubleBorder marks FAULTED components in black & white mode.
+	DoubleBorder = lipgloss.Border{
+		Top:         "═",
+		Bottom:      "═",
+		Left:        "║",
+		Right:       "║",
+		TopLeft:     "╔",
+		TopRight:    "╗",
+		BottomLeft:  "╚",
+		BottomRight: "╝",
+	}
+
+	// ASCIIBoxBorder replaces BoxBorder in ASCII mode.
+	ASCIIBoxBorder = lipgloss.Border{
+		Top:         "-",
+		Bottom:      "-",
+		Left:        "|",
+		Right:       "|",
+		TopLeft:     "+",
+		TopRight:    "+",
+		BottomLeft:  "+",
+		BottomRight: "+",
+	}
+
+	// ASCIIDashedBorder replaces DashedBorder in ASCII mode.
+	ASCIIDashedBorder = lipgloss.Border{
+		Top:         ".",
+		Bottom:      ".",
+		Left:        ":",
+		Right:       ":",
+		TopLeft:     ".",
+		TopRight:    ".",
+		BottomLeft:  ":",
+		BottomRight: ":",
+	}
+
+	// ASCIIDoubleBorder replaces DoubleBorder in ASCII mode.
+	ASCIIDoubleBorder = lipgloss.Border{
+		Top:         "=",
+		Bottom:      "=",
+		Left:        "#",
+		Right:       "#",
+		TopLeft:     "#",
+		TopRight:    "#",
+		BottomLeft:  "#",
+		BottomRight: "#",
+	}
+)
+
+// applyLayout sets the layout styles from the theme. In black & white mode
+// no colors are set.
+func (s *Styles) applyLayout() {
+	color := func(style lipgloss.Style, fg, bg lipgloss.Color) lipgloss.Style {
+		if s.Mode == config.DisplayModeBW {
+			return style
+		}
+		if fg != "" {
+			style = style.Foreground(fg)
+		}
+		if bg != "" {
+			style = style.Background(bg)
+		}
+		return style
+	}
+	t := s.Theme
+
 	// PoolBox defines the style for the main pool container.
-	// Uses blue borders (ANSI color 4) with padding and fixed width
-	// to create a consistent layout for pool information.
-	PoolBox = lipgloss.NewStyle().
-		Border(BoxBorder).
-		BorderForeground(lipgloss.Color("4")). // Blue border
-		Padding(1).
-		Width(76)
+	// Uses the pool border color with padding; the width follows the
+	// content, as the pane it is drawn in may be of any size.
+	s.PoolBox = lipgloss.NewStyle().
+		Border(s.Glyphs.Box).
+		Padding(1)
+	if s.Mode != config.DisplayModeBW {
+		s.PoolBox = s.PoolBox.BorderForeground(t.PoolBorder)
+	}
 
 	// VDevBox defines the style for virtual device containers.
-	// Uses purple borders (ANSI color 5) with left margin for hierarchy
+	// Uses the vdev border color with left margin for hierarchy
 	// and minimal padding for compact display.
-	VDevBox = lipgloss.NewStyle().
-		Border(BoxBorder).
-		BorderForeground(lipgloss.Color("5")). // Purple border
+	s.VDevBox = lipgloss.NewStyle().
+		Border(s.Glyphs.Box).
 		MarginLeft(2).
 		Padding(0, 1)
+	if s.Mode != config.DisplayModeBW {
+		s.VDevBox = s.VDevBox.BorderForeground(t.VDevBorder)
+	}
 
-	// Selected defines the highlight style for selected items.
-	// Uses blue background (ANSI color 4) with black text (ANSI color 0)
-	// to create high contrast for selected elements.
-	Selected = lipgloss.NewStyle().
-			Background(lipgloss.Color("4")). // Blue background
-			Foreground(lipgloss.Color("0"))  // Black text
+	// Selected defines the highlight style for selected items,
+	// reversed in BW mode.
+	s.Selected = color(lipgloss.NewStyle(), t.SelectedFg, t.SelectedBg)
+	if s.Mode == config.DisplayModeBW {
+		s.Selected = s.Selected.Reverse(true)
+	}
 
 	// Title defines the style for section headers and titles.
-	// Uses cyan text (ANSI color 6) in bold with left margin
-	// for visual hierarchy.
-	Title = lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("6")). // Cyan text
-		MarginLeft(2)
+	// Bold with left margin for visual hierarchy.
+	s.Title = color(lipgloss.NewStyle().Bold(true).MarginLeft(2), t.Title, "")
 
 	// HelpText defines the style for user instructions and help messages.
-	// Uses gray text (ANSI color 8) aligned to the right
-	// for subtle but accessible help information.
-	HelpText = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")). // Gray text
-			AlignHorizontal(lipgloss.Right)
-
-	// TabActive defines the style for the currently selected tab.
-	// Uses blue text (ANSI color 4) on black background (ANSI color 0)
-	// with bold for emphasis.
-	TabActive = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("4")). // Blue text
-			Background(lipgloss.Color("0"))  // Black background
+	// Aligned to the right for subtle but accessible help information.
+	s.HelpText = color(lipgloss.NewStyle().AlignHorizontal(lipgloss.Right), t.Help, "")
+
+	// TabActive defines the style for the currently selected tab,
+	// bold for emphasis.
+	s.TabActive = color(lipgloss.NewStyle().Bold(true), t.TabActiveFg, t.TabActiveBg)
 
 	// TabInactive defines the style for non-selected tabs.
-	// Uses gray text (ANSI color 8) for de-emphasized display.
-	TabInactive = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")) // Gray text
-)
+	s.TabInactive = color(lipgloss.NewStyle(), t.TabInactive, "")
+
+	// Pane and PaneFocused frame the panes of the layout. The focused
+	// pane's frame takes the title color; in BW mode the border shapes
+	// already mean health states, so only its title is marked.
+	s.Pane = lipgloss.NewStyle().Border(s.Glyphs.Box)
+	s.PaneFocused = s.Pane
+	if s.Mode != config.DisplayModeBW {
+		s.Pane = s.Pane.BorderForeground(t.Tree)
+		s.PaneFocused = s.PaneFocused.BorderForeground(t.Title)
+	}
+
+	// PaneTitle and PaneTitleFocused name the panes in their first line,
+	// the focused one highlighted like a selected item.
+	s.PaneTitle = color(lipgloss.NewStyle().Bold(true), t.Title, "")
+	s.PaneTitleFocused = s.Selected.Bold(true)
+}
 
 // GetStatusBorderStyle returns a border style based on the VDev status.
-// The border color indicates the health state:
-//   - Green (ANSI color 2) for ONLINE status
-//   - Yellow (ANSI color 3) for DEGRADED status
-//   - Red (ANSI color 1) for FAULTED status
+// In RGB mode the border color indicates the health state, using the
+// theme's online, degraded and faulted colors.
+//
+// In black & white mode the border shape indicates it instead:
+//   - Normal borders ─│╭╮╰╯ for ONLINE status
+//   - Dashed borders ╌┊┌┐└┘ for DEGRADED status
+//   - Double borders ═║╔╗╚╝ for FAULTED status
+//
+// In ASCII mode these are -|+, .: and =# respectively.
 //
 // Parameters:
-//   - status: The VDev status to determine the border color
+//   - status: The VDev status to determine the border style
 //
 // Returns:
 //   - lipgloss.Style: A styled border matching the status severity
-func GetStatusBorderStyle(status zfs.VDevStatus) lipgloss.Style {
-	var color string
+func (s *Styles) GetStatusBorderStyle(status zfs.VDevStatus) lipgloss.Style {
+	style := lipgloss.NewStyle().Padding(0, 1)
+
+	if s.Mode == config.DisplayModeBW {
+		switch status {
+		case zfs.VDevStatusFaulted:
+			return style.Border(s.Glyphs.Double)
+		case zfs.VDevStatusDegraded:
+			return style.Border(s.Glyphs.Dashed)
+		default:
+			return style.Border(s.Glyphs.Box)
+		}
+	}
+
+	color := s.Theme.Online
 	switch status {
 	case zfs.VDevStatusFaulted:
-		color = "1" // Red for critical failure
+		color = s.Theme.Faulted
+	case zfs.VDevStatusDegraded:
+		color = s.Theme.Degraded
+	}
+
+	return style.
+		Border(s.Glyphs.Box).
+		BorderForeground(color)
+}
+
+// RenderStatus converts a VDevStatus to a styled string representation.
+// In black & white mode a textual marker follows non-ONLINE statuses so the
+// state is still clear where text attributes are not shown either.
+//
+// Parameters:
+//   - status: the VDevStatus to render
+//
+// Returns a styled string representing the status.
+func (s *Styles) RenderStatus(status zfs.VDevStatus) string {
+	text := string(status)
+	if s.Mode == config.DisplayModeBW {
+		switch status {
+		case zfs.VDevStatusOnline:
+		case zfs.VDevStatusFaulted:
+			text += " !!"
+		default:
+			text += " !"
+		}
+	}
+
+	switch status {
+	case zfs.VDevStatusOnline:
+		return s.StatusOnline.Render(text)
 	case zfs.VDevStatusDegraded:
-		color = "3" // Yellow for warning
+		return s.StatusDegraded.Render(text)
+	case zfs.VDevStatusFaulted:
+		return s.StatusFaulted.Render(text)
 	default:
-		color = "2" // Green for healthy
+		return text
 	}
+}
 
-	return lipgloss.NewStyle().
-		Border(BoxBorder).
-		BorderForeground(lipgloss.Color(color)).
-		Padding(0, 1)
+// BorderLegend explains the border styles of black & white mode for the
+// help text. It is empty in RGB mode, where the colors speak for themselves.
+//
+// Returns:
+//   - string: The legend, or "" in RGB mode
+func (s *Styles) BorderLegend() string {
+	if s.Mode != config.DisplayModeBW {
+		return ""
+	}
+	g := s.Glyphs
+	return g.Box.Top + " online" + g.Separator + g.Dashed.Top + " degraded" + g.Separator + g.Double.Top + " faulted"
 }
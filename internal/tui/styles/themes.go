@@ -0,0 +1,198 @@
+package styles
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/petecog/vizfsulizer/internal/config"
+)
+
+// Theme is a color palette for the TUI. Colors are ANSI color numbers
+// ("0"-"255") or true-color hex values ("#rrggbb"); terminals with fewer
+// colors get the closest match.
+type Theme struct {
+	// Name identifies the theme in the configuration file
+	Name string
+
+	// Online, Degraded and Faulted color status text and borders
+	Online   lipgloss.Color
+	Degraded lipgloss.Color
+	Faulted  lipgloss.Color
+
+	// PoolName, VDevType and Tree color the pool tree
+	PoolName lipgloss.Color
+	VDevType lipgloss.Color
+	Tree     lipgloss.Color
+
+	// PoolBorder and VDevBorder color the container boxes
+	PoolBorder lipgloss.Color
+	VDevBorder lipgloss.Color
+
+	// SelectedFg and SelectedBg highlight selected items
+	SelectedFg lipgloss.Color
+	SelectedBg lipgloss.Color
+
+	// Title and Help color headers and help text
+	Title lipgloss.Color
+	Help  lipgloss.Color
+
+	// TabActiveFg, TabActiveBg and TabInactive color the pool tabs
+	TabActiveFg lipgloss.Color
+	TabActiveBg lipgloss.Color
+	TabInactive lipgloss.Color
+}
+
+// builtinThemes holds the palettes named in config.Themes.
+var builtinThemes = map[string]Theme{
+	// default uses the terminal's own ANSI palette
+	"default": {
+		Online: "2", Degraded: "3", Faulted: "1",
+		PoolName: "4", VDevType: "5", Tree: "8",
+		PoolBorder: "4", VDevBorder: "5",
+		SelectedFg: "0", SelectedBg: "4",
+		Title: "6", Help: "8",
+		TabActiveFg: "4", TabActiveBg: "0", TabInactive: "8",
+	},
+	// dark is tuned for dark terminal backgrounds
+	"dark": {
+		Online: "#50fa7b", Degraded: "#f1fa8c", Faulted: "#ff5555",
+		PoolName: "#bd93f9", VDevType: "#ff79c6", Tree: "#6272a4",
+		PoolBorder: "#bd93f9", VDevBorder: "#ff79c6",
+		SelectedFg: "#282a36", SelectedBg: "#bd93f9",
+		Title: "#8be9fd", Help: "#6272a4",
+		TabActiveFg: "#f8f8f2", TabActiveBg: "#44475a", TabInactive: "#6272a4",
+	},
+	// light is tuned for light terminal backgrounds
+	"light": {
+		Online: "#1a7f37", Degraded: "#9a6700", Faulted: "#cf222e",
+		PoolName: "#0969da", VDevType: "#8250df", Tree: "#8c959f",
+		PoolBorder: "#0969da", VDevBorder: "#8250df",
+		SelectedFg: "#ffffff", SelectedBg: "#0969da",
+		Title: "#0550ae", Help: "#6e7781",
+		TabActiveFg: "#0969da", TabActiveBg: "#ddf4ff", TabInactive: "#6e7781",
+	},
+	// solarized uses Ethan Schoonover's Solarized accent colors
+	"solarized": {
+		Online: "#859900", Degraded: "#b58900", Faulted: "#dc322f",
+		PoolName: "#268bd2", VDevType: "#d33682", Tree: "#586e75",
+		PoolBorder: "#268bd2", VDevBorder: "#6c71c4",
+		SelectedFg: "#fdf6e3", SelectedBg: "#268bd2",
+		Title: "#2aa198", Help: "#586e75",
+		TabActiveFg: "#268bd2", TabActiveBg: "#073642", TabInactive: "#586e75",
+	},
+	// deuteranopia avoids red/green pairs, using the Okabe-Ito palette:
+	// sky blue, yellow and vermillion differ clearly in hue and lightness
+	"deuteranopia": {
+		Online: "#56b4e9", Degraded: "#f0e442", Faulted: "#d55e00",
+		PoolName: "#0072b2", VDevType: "#cc79a7", Tree: "8",
+		PoolBorder: "#0072b2", VDevBorder: "#cc79a7",
+		SelectedFg: "#000000", SelectedBg: "#56b4e9",
+		Title: "#56b4e9", Help: "8",
+		TabActiveFg: "#56b4e9", TabActiveBg: "0", TabInactive: "8",
+	},
+	// protanopia also avoids red, which protanopes see as dark, and marks
+	// faults with reddish purple instead
+	"protanopia": {
+		Online: "#0072b2", Degraded: "#f0e442", Faulted: "#cc79a7",
+		PoolName: "#56b4e9", VDevType: "#e69f00", Tree: "8",
+		PoolBorder: "#56b4e9", VDevBorder: "#e69f00",
+		SelectedFg: "#000000", SelectedBg: "#56b4e9",
+		Title: "#56b4e9", Help: "8",
+		TabActiveFg: "#56b4e9", TabActiveBg: "0", TabInactive: "8",
+	},
+}
+
+// DefaultTheme returns the theme used when none is configured.
+//
+// Returns:
+//   - Theme: The "default" ANSI palette
+func DefaultTheme() Theme {
+	t := builtinThemes["default"]
+	t.Name = "default"
+	return t
+}
+
+// LoadThemes resolves every theme selectable in a configuration: the
+// built-in themes followed by the user-defined ones, which replace some
+// colors of their base theme.
+//
+// Parameters:
+//   - cfg: The configuration with the user-defined themes
+//
+// Returns:
+//   - []Theme: The themes in the order the TUI cycles through them
+//   - error: Error if a user-defined theme has an unknown base or colour role
+//
+// Example:
+//
+//	themes, err := styles.LoadThemes(cfg)
+//	st := styles.New(cfg.Color, themes[0])
+func LoadThemes(cfg *config.Config) ([]Theme, error) {
+	var themes []Theme
+	for _, name := range cfg.ThemeNames() {
+		custom, isCustom := cfg.Themes[name]
+		if !isCustom {
+			t := builtinThemes[name]
+			t.Name = name
+			themes = append(themes, t)
+			continue
+		}
+
+		base := custom.Base
+		if base == "" {
+			base = "default"
+		}
+		t, ok := builtinThemes[base]
+		if !ok {
+			return nil, fmt.Errorf("theme %s: unknown base theme %q", name, base)
+		}
+		t.Name = name
+		for role, value := range custom.Colors {
+			color := t.color(role)
+			if color == nil {
+				return nil, fmt.Errorf("theme %s: unknown colour %q", name, role)
+			}
+			*color = lipgloss.Color(value)
+		}
+		themes = append(themes, t)
+	}
+	return themes, nil
+}
+
+// color returns the field holding a config.ThemeColors role, or nil.
+func (t *Theme) color(role string) *lipgloss.Color {
+	switch role {
+	case config.ColorOnline:
+		return &t.Online
+	case config.ColorDegraded:
+		return &t.Degraded
+	case config.ColorFaulted:
+		return &t.Faulted
+	case config.ColorPoolName:
+		return &t.PoolName
+	case config.ColorVDevType:
+		return &t.VDevType
+	case config.ColorTree:
+		return &t.Tree
+	case config.ColorPoolBorder:
+		return &t.PoolBorder
+	case config.ColorVDevBorder:
+		return &t.VDevBorder
+	case config.ColorSelectedFg:
+		return &t.SelectedFg
+	case config.ColorSelectedBg:
+		return &t.SelectedBg
+	case config.ColorTitle:
+		return &t.Title
+	case config.ColorHelp:
+		return &t.Help
+	case config.ColorTabActiveFg:
+		return &t.TabActiveFg
+	case config.ColorTabActiveBg:
+		return &t.TabActiveBg
+	case config.ColorTabInactive:
+		return &t.TabInactive
+	default:
+		return nil
+	}
+}
@@ -1,9 +1,21 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+	"unicode"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/executor"
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/tui/layout"
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
 	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
 )
 
 func TestBasicFunctionality(t *testing.T) {
@@ -16,7 +28,7 @@ func TestBasicFunctionality(t *testing.T) {
 
 func TestPoolNavigation(t *testing.T) {
 	// Setup
-	model := NewModel()
+	model := NewModel(Options{})
 	pools := []*zfs.Pool{
 		{Name: "pool1"},
 		{Name: "pool2"},
@@ -47,3 +59,544 @@ func TestPoolNavigation(t *testing.T) {
 		t.Errorf("Wraparound should be 2, got %d", model.selected)
 	}
 }
+
+func TestKeybindingsFromOptions(t *testing.T) {
+	model := NewModel(Options{Keybindings: map[string][]string{
+		"next_pool": {"n"},
+		"quit":      {"x"},
+	}})
+	updated, _ := model.Update(snapshotMsg(&source.Snapshot{Pools: []*zfs.Pool{
+		{Name: "pool1", RootVDev: &zfs.VDev{Name: "sda", Type: "disk"}},
+		{Name: "pool2", RootVDev: &zfs.VDev{Name: "sdb", Type: "disk"}},
+	}}))
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if got := updated.(Model).selected; got != 1 {
+		t.Errorf("rebound next_pool key selected %d, want 1", got)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got := updated.(Model).selected; got != 1 {
+		t.Errorf("unbound default key still switched pools to %d", got)
+	}
+	if _, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}); cmd == nil {
+		t.Error("rebound quit key did not quit")
+	}
+}
+
+func TestViewportKeys(t *testing.T) {
+	model := NewModel(Options{Keybindings: map[string][]string{"page_down": {"n"}}})
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 12})
+	m := updated.(Model)
+	m.viewport.SetContent(strings.Repeat("line\n", 100))
+
+	// The viewport's built-in keys no longer scroll the topology pane
+	updated = m
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("f")}, {Type: tea.KeySpace}, {Type: tea.KeyPgDown},
+	} {
+		updated, _ = updated.Update(msg)
+	}
+	if got := updated.(Model).viewport.YOffset; got != 0 {
+		t.Errorf("unbound keys scrolled the topology to line %d", got)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if got := updated.(Model).viewport.YOffset; got == 0 {
+		t.Error("rebound page_down key did not scroll the topology")
+	}
+}
+
+func TestThemeSwitchKey(t *testing.T) {
+	model := NewModel(Options{
+		Themes: []styles.Theme{{Name: "one"}, {Name: "two"}},
+		Theme:  "two",
+	})
+	if got := model.styles.Theme.Name; got != "two" {
+		t.Fatalf("initial theme %q, want two", got)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if got := updated.(Model).styles.Theme.Name; got != "one" {
+		t.Errorf("theme after switch %q, want one", got)
+	}
+}
+
+func TestASCIIRendering(t *testing.T) {
+	for _, mode := range []config.DisplayMode{config.DisplayModeRGB, config.DisplayModeBW} {
+		model := NewModel(Options{DisplayMode: mode, Charset: config.CharsetASCII})
+		snap, err := source.Mock{}.Collect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated, _ := model.Update(snapshotMsg(snap))
+		out := updated.(Model).poolView.Render()
+		for _, r := range out {
+			if r > unicode.MaxASCII {
+				t.Fatalf("%s mode rendered non-ASCII %q:\n%s", mode, r, out)
+			}
+		}
+	}
+}
+
+func TestScreenReaderAnnouncements(t *testing.T) {
+	model := NewModel(Options{ScreenReader: true})
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := model.Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	view := updated.View()
+	if !strings.HasPrefix(view, "Focus: disk sda, degraded") {
+		t.Errorf("focus announcement not shown first:\n%s", view)
+	}
+	if !strings.Contains(view, "Disk sdb, online.") {
+		t.Errorf("linear pool description missing:\n%s", view)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if view := updated.View(); !strings.HasPrefix(view, "Pool fastpool, 2 of 2") {
+		t.Errorf("pool announcement not shown first:\n%s", view)
+	}
+}
+
+func TestHelpFromKeyMap(t *testing.T) {
+	model := NewModel(Options{
+		DisplayMode: config.DisplayModeBW,
+		Keybindings: map[string][]string{"quit": {"x"}},
+	})
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	footer := updated.View()
+	if !strings.Contains(footer, "x quit") || strings.Contains(footer, "q quit") {
+		t.Errorf("footer does not follow the rebound quit key:\n%s", footer)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	overlay := updated.View()
+	for _, want := range []string{"Keys", "previous pool", "next theme (default)", "tab/right/l"} {
+		if !strings.Contains(overlay, want) {
+			t.Errorf("help overlay missing %q:\n%s", want, overlay)
+		}
+	}
+
+	// The overlay is modal: navigation keys are ignored until it is closed
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showHelp {
+		t.Error("esc did not close the help overlay")
+	}
+}
+
+func TestMouseClickSelectsPool(t *testing.T) {
+	model := NewModel(Options{})
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := model.Update(snapshotMsg(snap))
+	// Small enough for the topology pane alone, drawn unframed at 0,0
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 60, Height: 15})
+
+	// Tabs are "[ testpool ]   fastpool  ": the second starts at column 13
+	click := tea.MouseMsg{X: 15, Y: 0, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	updated, _ = updated.Update(click)
+	if got := updated.(Model).selected; got != 1 {
+		t.Errorf("click on the second tab selected pool %d", got)
+	}
+
+	wheel := tea.MouseMsg{X: 15, Y: 5, Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown}
+	updated, _ = updated.Update(wheel)
+	if got := updated.(Model).selected; got != 1 {
+		t.Errorf("wheel changed the selected pool to %d", got)
+	}
+}
+
+func TestPaneLayout(t *testing.T) {
+	model := NewModel(Options{})
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := model.Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	// Every pane is drawn, filling the screen above the footer
+	view := updated.View()
+	for _, pane := range []string{"pools", "topology", "details", "events", "metrics"} {
+		if !strings.Contains(view, pane) {
+			t.Errorf("pane %s missing from the view", pane)
+		}
+	}
+	if got := strings.Count(view, "\n") + 1; got != 40 {
+		t.Errorf("view has %d lines, want 40", got)
+	}
+	m := updated.(Model)
+	if m.viewport.Width >= 120 || m.viewport.Height >= 40 {
+		t.Errorf("viewport %dx%d not sized to the topology pane", m.viewport.Width, m.viewport.Height)
+	}
+
+	// A click on the second line of the pool list selects the second pool
+	pools := m.layout.Arrange(m.area())[layout.PanePools]
+	click := tea.MouseMsg{X: pools.X + 2, Y: pools.Y + 3, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	updated, _ = updated.Update(click)
+	m = updated.(Model)
+	if m.selected != 1 || m.focusPane != layout.PanePools {
+		t.Errorf("click on the pool list: selected %d, focus %s", m.selected, m.focusPane)
+	}
+
+	// Zooming shows the focused pane alone and gives the viewport the area
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if got := updated.(Model).focusPane; got != layout.PaneMetrics {
+		t.Errorf("next pane after pools is %s, want metrics", got)
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	m = updated.(Model)
+	if m.focusPane != layout.PaneTopology || m.viewport.Width != 120 || m.viewport.Height != 39 {
+		t.Errorf("zoomed %s with viewport %dx%d, want topology 120x39", m.focusPane, m.viewport.Width, m.viewport.Height)
+	}
+	if strings.Contains(m.View(), "metrics") {
+		t.Error("zoomed view still shows other panes")
+	}
+
+	// Resizing is limited, but moves the split
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	before := updated.(Model).viewport.Width
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("]")})
+	if after := updated.(Model).viewport.Width; after <= before {
+		t.Errorf("widening the topology pane changed its width from %d to %d", before, after)
+	}
+}
+
+// unreachable is a source that always fails, like a host that is down.
+type unreachable struct{}
+
+func (unreachable) Collect(context.Context) (*source.Snapshot, error) {
+	return nil, errors.New("ssh: connect to host nas2 port 22: Connection refused")
+}
+
+func TestFleetOverview(t *testing.T) {
+	fleet := source.NewFleet([]source.Host{
+		{Name: "nas1", Source: source.Mock{}},
+		{Name: "nas2", Source: unreachable{}},
+	}, 2, 0)
+	model := NewModel(Options{Fleet: fleet})
+	updated, _ := model.Update(model.collect()())
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	view := updated.View()
+	for _, want := range []string{"2 hosts, 1 unreachable, 2 pools", "testpool", "fastpool", "UNREACHABLE", "Connection refused"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("fleet overview missing %q:\n%s", want, view)
+		}
+	}
+
+	// Open the second pool of nas1
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m := updated.(Model)
+	if m.host != 0 || m.selected != 1 || len(m.pools) != 2 {
+		t.Fatalf("opened host %d, pool %d of %d", m.host, m.selected, len(m.pools))
+	}
+	if view := m.View(); !strings.HasPrefix(view, "Host nas1") || !strings.Contains(view, "topology") {
+		t.Errorf("host view:\n%s", view)
+	}
+
+	// Back to the overview, then into the unreachable host
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m := updated.(Model); !m.inFleet() {
+		t.Fatal("esc did not return to the fleet overview")
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m := updated.(Model); m.host != 1 || m.err == nil {
+		t.Errorf("unreachable host opened as %d without error", m.host)
+	}
+}
+
+// pushing is a streaming source sending one snapshot and then the error
+// of a dropped connection.
+type pushing struct{ source.Mock }
+
+func (pushing) Stream(ctx context.Context, updates chan<- source.Update) error {
+	snap, _ := source.Mock{}.Collect(ctx)
+	updates <- source.Update{Snapshot: snap}
+	updates <- source.Update{Err: errors.New("agent nas1:9723: stream closed")}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStreamingSource(t *testing.T) {
+	model := NewModel(Options{Source: pushing{}, RefreshInterval: time.Hour})
+	if model.updates == nil {
+		t.Fatal("streaming source not detected")
+	}
+	model.stream()()
+
+	// Each update is applied and followed by waiting for the next one,
+	// not by polling after the refresh interval
+	updated, cmd := model.Update(model.nextUpdate()())
+	if m := updated.(Model); len(m.pools) == 0 {
+		t.Fatal("streamed snapshot not applied")
+	}
+	msg, ok := cmd().(collectErrMsg)
+	if !ok {
+		t.Fatalf("next command returned %T, want the streamed error", msg)
+	}
+	updated, _ = updated.Update(msg)
+	if m := updated.(Model); m.err == nil || len(m.pools) == 0 {
+		t.Errorf("streamed error: err %v, %d pools", m.err, len(m.pools))
+	}
+}
+
+func TestRecordedHost(t *testing.T) {
+	// Outputs captured with "vizfsulizer capture -dir testdata/nas1"
+	src := source.NewZFS("nas1", executor.Fixture{Dir: "testdata/nas1"})
+	model := NewModel(Options{Source: src})
+	updated, _ := model.Update(model.collect()())
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+
+	m := updated.(Model)
+	if m.err != nil || len(m.pools) != 2 {
+		t.Fatalf("collected %d pools: %v", len(m.pools), m.err)
+	}
+	view := m.View()
+	for _, want := range []string{"tank", "backup", "mirror-0", "raidz2-1", "sdb", "special", "ARC       15.0G"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if io := m.pools[0].RootVDev.Children[0].Children[0].IO; !io.Sampled || io.ReadOps != 160 {
+		t.Errorf("sda I/O %+v", io)
+	}
+}
+
+func TestEventsPane(t *testing.T) {
+	src := source.NewZFS("nas1", executor.Fixture{Dir: "testdata/nas1"})
+	model := NewModel(Options{Source: src, Events: src})
+	updated, _ := model.Update(model.collect()())
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan source.EventUpdate)
+	go src.Events(ctx, events)
+	for i := 0; i < 4; i++ {
+		updated, _ = updated.Update(eventMsg(<-events))
+	}
+	if view := updated.View(); !strings.Contains(view, "All events of all pools (4)") || !strings.Contains(view, "statechange") {
+		t.Errorf("events pane missing:\n%s", view)
+	}
+
+	// The first class in alphabetical order is checksum
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m := updated.(Model)
+	if got := m.events.Filter(); got != "checksum events of all pools" {
+		t.Errorf("filter %q", got)
+	}
+
+	// Enter in the focused events pane shows the device of the event
+	m.focusPane = layout.PaneEvents
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if node := m.poolView.Focused(); node == nil || node.Name != "sdb" {
+		t.Errorf("focused %+v, want sdb", node)
+	}
+
+	// So does a click on an event
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updated.(Model)
+	content := m.contentRect(m.layout.Arrange(m.area())[layout.PaneEvents])
+	click := tea.MouseMsg{X: content.X + 1, Y: content.Y + m.problemLines() + 1, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	updated, _ = m.Update(click)
+	if node := updated.(Model).poolView.Focused(); node == nil || node.Name != "nvme1n1" {
+		t.Errorf("click focused %+v, want nvme1n1", node)
+	}
+}
+
+func TestHistoryOverlay(t *testing.T) {
+	model := NewModel(Options{History: source.Mock{}})
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := model.Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+	if cmd == nil || !updated.(Model).showHistory {
+		t.Fatal("history key did not open the history")
+	}
+	updated, _ = updated.Update(cmd())
+	view := updated.View()
+	if !strings.Contains(view, "History of testpool: 3 of 7 entries, commands only") || !strings.Contains(view, "root@mockhost") {
+		t.Errorf("history overlay:\n%s", view)
+	}
+	// Printable keys of the bindings are left out of the help
+	if !strings.Contains(view, "down scroll down") || strings.Contains(view, "down/j") || !strings.Contains(view, "tab internal entries") {
+		t.Errorf("history keys not generated from the key map:\n%s", view)
+	}
+
+	// Keys bound to actions are typed into the search while it is open
+	for _, r := range "set q" {
+		updated, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		if cmd != nil {
+			t.Errorf("%q returned a command", r)
+		}
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m := updated.(Model)
+	if got := m.history.Summary(); got != `History of testpool: 3 of 7 entries, matching "set"` {
+		t.Errorf("summary %q", got)
+	}
+	if view := m.View(); !strings.Contains(view, "zfs set compression=lz4 testpool") || !strings.Contains(view, "[txg 40] set testpool") {
+		t.Errorf("search results:\n%s", view)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showHistory {
+		t.Error("esc did not close the history")
+	}
+}
+
+func TestDiskLabelKey(t *testing.T) {
+	model := NewModel(Options{DiskLabel: "serial"})
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := model.Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	if view := updated.View(); !strings.Contains(view, "VCJ4A8KP") {
+		t.Errorf("leaves not labelled by serial:\n%s", view)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	m := updated.(Model)
+	if m.announcement != "Disks labelled by wwn." || !strings.Contains(m.View(), "0x5000cca27ec1d2a1") {
+		t.Errorf("after the label key: %q\n%s", m.announcement, m.View())
+	}
+}
+
+func TestEnclosureKey(t *testing.T) {
+	model := NewModel(Options{})
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	if updated.(Model).page != "" {
+		t.Error("enclosure map opened without enclosures")
+	}
+
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ = updated.Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown}) // sda of testpool
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	m := updated.(Model)
+	if m.page != pageEnclosures || m.announcement != "Enclosure map of 1 enclosures. sda is in enclosure 1, slot 0." {
+		t.Fatalf("enclosure map not opened: %q", m.announcement)
+	}
+	if view := m.View(); !strings.Contains(view, "Enclosure 1: LSI SAS2X28") {
+		t.Errorf("enclosure map not shown:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = updated.(Model); m.page != "" || m.announcement != "Enclosure map closed." {
+		t.Errorf("enclosure map not closed: %q", m.announcement)
+	}
+}
+
+func TestLintKey(t *testing.T) {
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := NewModel(Options{Keybindings: map[string][]string{"close": {"x"}}}).Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	m := updated.(Model)
+	if m.page != pageLint || m.announcement != "2 layout findings in 2 pools." {
+		t.Fatalf("findings not opened: %q", m.announcement)
+	}
+	if view := m.View(); !strings.Contains(view, "Layout findings") || !strings.Contains(view, "disks referenced by sdX names: sda, sdb") {
+		t.Errorf("findings not shown:\n%s", view)
+	}
+
+	if view := m.View(); !strings.Contains(view, "pgdown page down") || !strings.Contains(view, "x close") {
+		t.Errorf("keys not generated from the key map:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	if m = updated.(Model); m.page != "" || m.announcement != "Layout findings closed." {
+		t.Errorf("findings not closed: %q", m.announcement)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).page != pageLint {
+		t.Error("unbound default close key closed the findings")
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if updated.(Model).page != "" {
+		t.Error("rebound close key did not close the findings")
+	}
+}
+
+func TestAnalyzerFromOptions(t *testing.T) {
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := NewModel(Options{Analyzer: &status.Analyzer{LintCapacity: 1}})
+	updated, _ := model.Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	if view := updated.View(); !strings.Contains(view, "[capacity]") {
+		t.Errorf("configured lint capacity not applied:\n%s", view)
+	}
+}
+
+func TestPlannerKey(t *testing.T) {
+	snap, err := source.Mock{}.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := NewModel(Options{Keybindings: map[string][]string{"planner_new": {"ctrl+n"}}}).Update(snapshotMsg(snap))
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	m := updated.(Model)
+	if !m.showPlanner || m.announcement != "Capacity planner: based on testpool. Usable 9.9T of 20.0T raw, 49%." {
+		t.Fatalf("planner not opened: %q", m.announcement)
+	}
+
+	// A third disk in the mirror adds no space; the planner keeps keys
+	// such as l and n to itself
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	m = updated.(Model)
+	if !m.showPlanner || m.announcement != "Width: 3 disks. Usable 9.9T of 30.0T raw, 33%." {
+		t.Errorf("width not changed: %q", m.announcement)
+	}
+	if view := m.View(); !strings.Contains(view, "Capacity planner: based on testpool") || !strings.Contains(view, "Parity") ||
+		!strings.Contains(view, "esc close") || !strings.Contains(view, "a add vdevs") {
+		t.Errorf("planner not shown:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m = updated.(Model); m.announcement != "Width: 3 disks. Usable 9.9T of 30.0T raw, 33%." {
+		t.Errorf("unbound default key started a new pool: %q", m.announcement)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	if m = updated.(Model); !strings.HasPrefix(m.announcement, "Capacity planner: new pool.") {
+		t.Errorf("rebound key did not start a new pool: %q", m.announcement)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = updated.(Model); m.showPlanner || m.announcement != "Capacity planner closed." {
+		t.Errorf("planner not closed: %q", m.announcement)
+	}
+}
@@ -0,0 +1,242 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// slotWidth is the width of the text in a slot of the enclosure grid.
+const slotWidth = 12
+
+// DefaultEnclosureColumns is the number of slots per row of the enclosure
+// grid, as on the front of most 12 and 24 bay chassis.
+const DefaultEnclosureColumns = 4
+
+// EnclosureView renders the disk enclosures of the host as they look from
+// the front: a grid of slots per enclosure, each showing the disk in it,
+// framed by the ZFS status of the disk. Disks are labelled like the leaves
+// of the pool tree.
+type EnclosureView struct {
+	enclosures []*zfs.Enclosure
+	disks      map[string]slotDisk // Pool disks by kernel name
+	focused    string              // Kernel name of the disk to highlight
+	label      string              // Identity disks are labelled by, see PoolView.SetLabel
+	columns    int                 // Slots per row
+	styles     *styles.Styles
+}
+
+// slotDisk is a disk of a pool in an enclosure slot.
+type slotDisk struct {
+	pool   string
+	vdev   *zfs.VDev
+	status zfs.VDevStatus
+}
+
+// NewEnclosureView creates an empty enclosure view.
+//
+// Parameters:
+//   - st: Styles for the current display mode
+//   - columns: Slots per row, DefaultEnclosureColumns if not positive
+//
+// Returns:
+//   - *EnclosureView: An enclosure view ready for Update
+func NewEnclosureView(st *styles.Styles, columns int) *EnclosureView {
+	if columns <= 0 {
+		columns = DefaultEnclosureColumns
+	}
+	return &EnclosureView{disks: make(map[string]slotDisk), label: "name", columns: columns, styles: st}
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+func (ev *EnclosureView) SetStyles(st *styles.Styles) {
+	ev.styles = st
+}
+
+// Update shows the enclosures of a new snapshot, with the status of the
+// disks of its pools.
+//
+// Parameters:
+//   - enclosures: The enclosures of the host
+//   - pools: The pools of the host; their leaves are found in the slots
+//     by their disk identity
+func (ev *EnclosureView) Update(enclosures []*zfs.Enclosure, pools []*zfs.Pool) {
+	ev.enclosures = enclosures
+	ev.disks = make(map[string]slotDisk)
+	var walk func(pool string, vdev *zfs.VDev)
+	walk = func(pool string, vdev *zfs.VDev) {
+		if vdev == nil {
+			return
+		}
+		if vdev.Disk != nil {
+			ev.disks[vdev.Disk.Device] = slotDisk{pool: pool, vdev: vdev, status: vdev.Status}
+		}
+		for _, child := range vdev.Children {
+			walk(pool, child)
+		}
+	}
+	for _, pool := range pools {
+		for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+			walk(pool.Name, vdev)
+		}
+	}
+}
+
+// Len returns the number of enclosures shown.
+func (ev *EnclosureView) Len() int {
+	return len(ev.enclosures)
+}
+
+// SetFocus highlights the slot of a disk, e.g. of the focused leaf.
+//
+// Parameters:
+//   - device: Kernel name of the disk, empty for none
+func (ev *EnclosureView) SetFocus(device string) {
+	ev.focused = device
+}
+
+// SetLabel sets the identity disks are labelled by, one of zfs.DiskLabels.
+func (ev *EnclosureView) SetLabel(kind string) {
+	ev.label = kind
+}
+
+// heading describes an enclosure, e.g. "Enclosure 1: LSI SAS2X28
+// (0:0:12:0), 12 slots".
+func (ev *EnclosureView) heading(enc *zfs.Enclosure) string {
+	name := strings.TrimSpace(enc.Vendor + " " + enc.Model)
+	if name == "" {
+		name = enc.ID
+	} else {
+		name += " (" + enc.ID + ")"
+	}
+	return fmt.Sprintf("Enclosure %d: %s, %d slots", enc.Number, name, len(enc.Slots))
+}
+
+// diskLabel returns the label of the disk in a slot.
+func (ev *EnclosureView) diskLabel(slot *zfs.EnclosureSlot) string {
+	if d, ok := ev.disks[slot.Device]; ok {
+		if label := d.vdev.Disk.Label(ev.label); label != "" {
+			return label
+		}
+		return d.vdev.Name
+	}
+	return slot.Device
+}
+
+// Render renders every enclosure as a heading and a grid of slots. A slot
+// shows its number with its fault and locate LEDs, the disk in it, and
+// the pool and status of the disk. Slots of pool disks are framed by their status;
+// in black & white mode by the border shape.
+//
+// Returns:
+//   - string: The enclosures, or a note if the host has none
+//
+// Example Output (simplified):
+//
+//	Enclosure 1: LSI SAS2X28 (0:0:12:0), 12 slots
+//	╭──────────────╮╭──────────────╮
+//	│ 0            ││ 1            │
+//	│ sda          ││ sdb          │
+//	│ testpool     ││ testpool     │
+//	│ DEGRADED     ││ ONLINE       │
+//	╰──────────────╯╰──────────────╯
+func (ev *EnclosureView) Render() string {
+	if len(ev.enclosures) == 0 {
+		return "No disk enclosures found. They are read from /sys/class/enclosure of local hosts."
+	}
+	var blocks []string
+	for _, enc := range ev.enclosures {
+		var rows []string
+		for start := 0; start < len(enc.Slots); start += ev.columns {
+			var cells []string
+			for _, slot := range enc.Slots[start:min(start+ev.columns, len(enc.Slots))] {
+				cells = append(cells, ev.renderSlot(slot))
+			}
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+		}
+		blocks = append(blocks, ev.styles.VDevType.Render(ev.heading(enc))+"\n"+strings.Join(rows, "\n"))
+	}
+	// In black & white mode, explain what the border styles mean
+	if legend := ev.styles.BorderLegend(); legend != "" {
+		blocks = append(blocks, ev.styles.HelpText.Render(legend))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// renderSlot renders a slot of the grid.
+func (ev *EnclosureView) renderSlot(slot *zfs.EnclosureSlot) string {
+	number := fmt.Sprint(slot.Number)
+	if slot.Fault {
+		number += " FAULT"
+	}
+	if slot.Locate {
+		number += " LOCATE"
+	}
+
+	box := lipgloss.NewStyle().Padding(0, 1).Border(ev.styles.Glyphs.Box)
+	var lines []string
+	switch d, inPool := ev.disks[slot.Device]; {
+	case slot.Device == "":
+		lines = []string{number, ev.styles.HelpText.Render(fit("empty")), "", ""}
+	case !inPool:
+		lines = []string{number, fit(ev.diskLabel(slot)), ev.styles.HelpText.Render(fit("no pool")), ""}
+	default:
+		box = ev.styles.GetStatusBorderStyle(d.status)
+		lines = []string{number, fit(ev.diskLabel(slot)), fit(d.pool), ev.styles.RenderStatus(d.status)}
+	}
+	for i, line := range lines {
+		lines[i] = line + strings.Repeat(" ", max(slotWidth-lipgloss.Width(line), 0))
+	}
+	content := strings.Join(lines, "\n")
+	if slot.Device != "" && slot.Device == ev.focused {
+		content = ev.styles.Selected.Render(content)
+	}
+	return box.Render(content)
+}
+
+// fit cuts a text to the width of a slot.
+func fit(s string) string {
+	return cut(s, slotWidth)
+}
+
+// RenderLinear renders the enclosures as text for screen readers: one line
+// per enclosure and per occupied slot.
+//
+// Returns:
+//   - string: The enclosures, e.g. "Slot 0: sda, testpool, degraded."
+func (ev *EnclosureView) RenderLinear() string {
+	if len(ev.enclosures) == 0 {
+		return ev.Render()
+	}
+	var lines []string
+	for _, enc := range ev.enclosures {
+		empty := 0
+		lines = append(lines, ev.heading(enc)+".")
+		for _, slot := range enc.Slots {
+			if slot.Device == "" {
+				empty++
+				continue
+			}
+			line := fmt.Sprintf("Slot %d: %s", slot.Number, ev.diskLabel(slot))
+			if d, ok := ev.disks[slot.Device]; ok {
+				line += ", " + d.pool + ", " + strings.ToLower(string(d.status))
+			} else {
+				line += ", no pool"
+			}
+			if slot.Fault {
+				line += ", fault LED on"
+			}
+			if slot.Device == ev.focused {
+				line += ", selected"
+			}
+			lines = append(lines, line+".")
+		}
+		if empty > 0 {
+			lines = append(lines, fmt.Sprintf("%d empty slots.", empty))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,265 @@
+package views
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// maxEvents is the number of events the events view keeps; older ones
+// are dropped.
+const maxEvents = 1000
+
+// eventTimeFormat is how event times are shown.
+const eventTimeFormat = "Jan _2 15:04:05"
+
+// EventsView renders the ZFS events followed live from the host, newest
+// first, with a cursor selecting one of them. The events shown can be
+// narrowed down to one class and to the selected pool.
+type EventsView struct {
+	events   []*zfs.Event // Oldest first, at most maxEvents
+	err      error        // Last error following the event log
+	class    string       // Short class shown, empty for all
+	poolOnly bool         // Whether only events of pool are shown
+	pool     string       // Name of the selected pool
+	cursor   int          // Index of the selected event among the shown ones
+	offset   int          // Index of the first shown event drawn
+	styles   *styles.Styles
+}
+
+// NewEventsView creates an empty events view.
+//
+// Parameters:
+//   - st: Styles for the current display mode
+//
+// Returns:
+//   - *EventsView: An events view ready for Add
+func NewEventsView(st *styles.Styles) *EventsView {
+	return &EventsView{styles: st}
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+func (ev *EventsView) SetStyles(st *styles.Styles) {
+	ev.styles = st
+}
+
+// Add records a new event. The cursor stays on the event it selects.
+//
+// Parameters:
+//   - e: The event, newer than every event added before
+func (ev *EventsView) Add(e *zfs.Event) {
+	ev.err = nil
+	ev.events = append(ev.events, e)
+	if len(ev.events) > maxEvents {
+		ev.events = ev.events[len(ev.events)-maxEvents:]
+	}
+	if ev.cursor > 0 && ev.shows(e) {
+		ev.cursor++
+	}
+}
+
+// SetErr records why the event log could not be followed, shown until the
+// next event arrives.
+func (ev *EventsView) SetErr(err error) {
+	ev.err = err
+}
+
+// SetPool sets the selected pool, whose events are shown once the view
+// is narrowed down to the pool.
+func (ev *EventsView) SetPool(pool string) {
+	if pool != ev.pool && ev.poolOnly {
+		ev.cursor = 0
+	}
+	ev.pool = pool
+}
+
+// CycleClass narrows the events shown down to the next class among the
+// events received, in alphabetical order, or back to all classes.
+func (ev *EventsView) CycleClass() {
+	classes := make(map[string]bool)
+	for _, e := range ev.events {
+		classes[e.ShortClass()] = true
+	}
+	sorted := []string{""}
+	for class := range classes {
+		sorted = append(sorted, class)
+	}
+	sort.Strings(sorted)
+
+	next := ""
+	for i, class := range sorted {
+		if class == ev.class && i+1 < len(sorted) {
+			next = sorted[i+1]
+		}
+	}
+	ev.class, ev.cursor = next, 0
+}
+
+// TogglePool switches between the events of all pools and those of the
+// selected pool.
+func (ev *EventsView) TogglePool() {
+	ev.poolOnly, ev.cursor = !ev.poolOnly, 0
+}
+
+// shows reports whether an event passes the filters.
+func (ev *EventsView) shows(e *zfs.Event) bool {
+	return (ev.class == "" || e.ShortClass() == ev.class) && (!ev.poolOnly || e.Pool == ev.pool)
+}
+
+// Shown returns the events passing the filters, newest first.
+//
+// Returns:
+//   - []*zfs.Event: The events drawn, in display order
+func (ev *EventsView) Shown() []*zfs.Event {
+	var shown []*zfs.Event
+	for i := len(ev.events) - 1; i >= 0; i-- {
+		if ev.shows(ev.events[i]) {
+			shown = append(shown, ev.events[i])
+		}
+	}
+	return shown
+}
+
+// MoveCursor moves the cursor through the shown events, stopping at the
+// newest and the oldest one.
+//
+// Parameters:
+//   - delta: Number of events to move, negative to move to newer ones
+//
+// Returns:
+//   - bool: Whether the cursor moved
+func (ev *EventsView) MoveCursor(delta int) bool {
+	return ev.SetCursor(ev.cursor + delta)
+}
+
+// SetCursor moves the cursor to a shown event, e.g. one that was clicked.
+// Out of range indexes are clamped.
+//
+// Parameters:
+//   - idx: Index of the event among the shown events
+//
+// Returns:
+//   - bool: Whether the cursor moved
+func (ev *EventsView) SetCursor(idx int) bool {
+	cursor := min(max(idx, 0), len(ev.Shown())-1)
+	if cursor < 0 || cursor == ev.cursor {
+		return false
+	}
+	ev.cursor = cursor
+	return true
+}
+
+// Selected returns the event under the cursor.
+//
+// Returns:
+//   - *zfs.Event: The selected event, or nil if no event is shown
+func (ev *EventsView) Selected() *zfs.Event {
+	shown := ev.Shown()
+	if ev.cursor >= len(shown) {
+		return nil
+	}
+	return shown[ev.cursor]
+}
+
+// Filter describes the filters, e.g. "checksum events of tank".
+//
+// Returns:
+//   - string: The classes and pools shown
+func (ev *EventsView) Filter() string {
+	class, pool := "All events", "all pools"
+	if ev.class != "" {
+		class = ev.class + " events"
+	}
+	if ev.poolOnly {
+		pool = ev.pool
+	}
+	return class + " of " + pool
+}
+
+// Announce describes the selected event for screen readers.
+//
+// Returns:
+//   - string: The event, e.g. "Event checksum of tank on sdb1, Oct 13 01:02:11."
+func (ev *EventsView) Announce() string {
+	e := ev.Selected()
+	if e == nil {
+		return ev.Filter() + ": none."
+	}
+	s := "Event " + e.ShortClass()
+	if e.Pool != "" {
+		s += " of " + e.Pool
+	}
+	if e.VDevPath != "" {
+		s += " on " + path.Base(e.VDevPath)
+	}
+	return s + ", " + e.Time.Format(eventTimeFormat) + "."
+}
+
+// Render renders the events pane below a heading line naming the
+// filters. Line i+1 shows the i-th event from the first one drawn, see
+// EventAt. The events scroll to keep the cursor in view.
+//
+// Parameters:
+//   - height: Available height in lines, including the heading
+//
+// Returns:
+//   - string: The heading and the events
+//
+// Example Output:
+//
+//	All events of all pools (3)
+//	Oct 13 01:02:12 tank resource statechange sdb1
+//	Oct 13 01:02:11 tank ereport  checksum    sdb1
+func (ev *EventsView) Render(height int) string {
+	shown := ev.Shown()
+	heading := ev.styles.VDevType.Render(fmt.Sprintf("%s (%d)", ev.Filter(), len(shown)))
+	if ev.err != nil {
+		heading += " " + ev.styles.StatusFaulted.Render(ev.err.Error())
+	}
+	rows := max(height-1, 1)
+	ev.cursor = min(ev.cursor, max(len(shown)-1, 0))
+	ev.offset = min(max(ev.offset, ev.cursor-rows+1), ev.cursor)
+
+	classWidth := 0
+	for _, e := range shown {
+		classWidth = max(classWidth, len(e.ShortClass()))
+	}
+	lines := []string{heading}
+	for i := ev.offset; i < len(shown) && i < ev.offset+rows; i++ {
+		e := shown[i]
+		kind, _, _ := strings.Cut(e.Class, ".")
+		line := fmt.Sprintf("%s %s %-8s %-*s", e.Time.Format(eventTimeFormat), e.Pool, kind, classWidth, e.ShortClass())
+		if e.VDevPath != "" {
+			line += " " + path.Base(e.VDevPath)
+		}
+		switch {
+		case i == ev.cursor:
+			line = ev.styles.Selected.Render(line)
+		case e.IsError():
+			line = ev.styles.StatusDegraded.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// EventAt returns the index among the shown events of the event drawn on
+// a line of the last Render.
+//
+// Parameters:
+//   - line: The line, 0 for the heading
+//
+// Returns:
+//   - int: Index of the event, for SetCursor
+//   - bool: Whether an event is drawn on the line
+func (ev *EventsView) EventAt(line int) (int, bool) {
+	idx := ev.offset + line - 1
+	if line < 1 || idx >= len(ev.Shown()) {
+		return 0, false
+	}
+	return idx, true
+}
@@ -0,0 +1,361 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// FleetRow is a line of the fleet table: a pool of a host, or a host that
+// could not be collected.
+type FleetRow struct {
+	// Host is the host name and HostIndex its position in the fleet
+	Host      string
+	HostIndex int
+
+	// Pool is the pool name, empty for unreachable hosts and hosts without pools
+	Pool string
+
+	// Status is the worst status found anywhere in the pool
+	Status zfs.VDevStatus
+
+	// Size and CapacityPercent describe the pool capacity
+	Size            uint64
+	CapacityPercent float64
+
+	// Scan is the pool's last scrub or resilver, nil if none has run
+	Scan *zfs.ScanInfo
+
+	// Err is why the host could not be collected
+	Err error
+}
+
+// FleetView is the top-level view of the TUI when it watches several
+// hosts: a table of every host and pool with its worst status, capacity
+// and scan state, and a cursor selecting the pool to drill down into.
+type FleetView struct {
+	rows     []FleetRow       // Table rows in fleet order
+	hosts    int              // Number of hosts in the fleet
+	cursor   int              // Index of the selected row
+	offset   int              // First row drawn, to keep the cursor visible
+	analyzer *status.Analyzer // Tool for analyzing pool health
+	styles   *styles.Styles   // Styles for the current display mode
+}
+
+// fleetHeaderLines is the number of lines drawn above the first row: the
+// summary and the column headings.
+const fleetHeaderLines = 2
+
+// NewFleetView creates an empty fleet view.
+//
+// Parameters:
+//   - st: Styles for the current display mode
+//
+// Returns:
+//   - *FleetView: A fleet view ready for Update
+func NewFleetView(st *styles.Styles) *FleetView {
+	return &FleetView{analyzer: &status.Analyzer{}, styles: st}
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+func (fv *FleetView) SetStyles(st *styles.Styles) {
+	fv.styles = st
+}
+
+// SetAnalyzer replaces the analyzer judging the health of the pools. It
+// takes effect with the next Update.
+func (fv *FleetView) SetAnalyzer(analyzer *status.Analyzer) {
+	fv.analyzer = analyzer
+}
+
+// Update rebuilds the table from a fleet collection. The cursor stays on
+// the same host and pool if it still exists.
+//
+// Parameters:
+//   - hosts: The results of source.Fleet.Collect
+func (fv *FleetView) Update(hosts []source.HostSnapshot) {
+	current, hadCursor := fv.Selected()
+
+	fv.rows = fv.rows[:0]
+	fv.hosts = len(hosts)
+	for i, h := range hosts {
+		if h.Err != nil || h.Snapshot == nil || len(h.Snapshot.Pools) == 0 {
+			fv.rows = append(fv.rows, FleetRow{Host: h.Host, HostIndex: i, Err: h.Err})
+			continue
+		}
+		for _, pool := range h.Snapshot.Pools {
+			fv.rows = append(fv.rows, FleetRow{
+				Host:            h.Host,
+				HostIndex:       i,
+				Pool:            pool.Name,
+				Status:          fv.analyzer.GetPoolWorstStatus(pool),
+				Size:            pool.Size,
+				CapacityPercent: pool.CapacityPercent(),
+				Scan:            pool.Scan,
+			})
+		}
+	}
+
+	fv.cursor = min(fv.cursor, max(len(fv.rows)-1, 0))
+	if hadCursor {
+		for i, row := range fv.rows {
+			if row.Host == current.Host && row.Pool == current.Pool {
+				fv.cursor = i
+			}
+		}
+	}
+}
+
+// MoveCursor moves the cursor through the rows, stopping at the first and
+// last one.
+//
+// Parameters:
+//   - delta: Number of rows to move, negative to move up
+//
+// Returns:
+//   - bool: Whether the cursor moved
+func (fv *FleetView) MoveCursor(delta int) bool {
+	return fv.SetCursor(fv.cursor + delta)
+}
+
+// SetCursor moves the cursor to a row, e.g. one that was clicked. Out of
+// range indexes are clamped.
+//
+// Parameters:
+//   - idx: Index of the row
+//
+// Returns:
+//   - bool: Whether the cursor moved
+func (fv *FleetView) SetCursor(idx int) bool {
+	cursor := min(max(idx, 0), len(fv.rows)-1)
+	if cursor < 0 || cursor == fv.cursor {
+		return false
+	}
+	fv.cursor = cursor
+	return true
+}
+
+// Selected returns the row under the cursor.
+//
+// Returns:
+//   - FleetRow: The selected row
+//   - bool: Whether there is one
+func (fv *FleetView) Selected() (FleetRow, bool) {
+	if fv.cursor >= len(fv.rows) {
+		return FleetRow{}, false
+	}
+	return fv.rows[fv.cursor], true
+}
+
+// RowAt returns the row drawn at a line of the last Render.
+//
+// Parameters:
+//   - y: The line, relative to the top of the rendered table
+//
+// Returns:
+//   - int: The row index
+//   - bool: Whether a row is drawn there
+func (fv *FleetView) RowAt(y int) (int, bool) {
+	row := y - fleetHeaderLines + fv.offset
+	if y < fleetHeaderLines || row >= len(fv.rows) {
+		return 0, false
+	}
+	return row, true
+}
+
+// Render renders the fleet table, scrolled so that the cursor is visible.
+//
+// Parameters:
+//   - width: Available width in cells, limiting the capacity bars
+//   - height: Available height in lines
+//
+// Returns:
+//   - string: The table
+//
+// Example Output:
+//
+//	3 hosts, 1 unreachable, 3 pools: 1 degraded
+//	HOST  POOL      STATUS    CAPACITY        SCAN
+//	nas1  tank      ONLINE    ███████░░░  70% scrub 3d ago
+//	nas1  backup    DEGRADED  ██░░░░░░░░  25% scrub 16%
+//	nas2  -         UNREACHABLE               ssh: connect to host nas2 port 22: Connection refused
+func (fv *FleetView) Render(width, height int) string {
+	if fv.hosts == 0 {
+		return "Collecting hosts..."
+	}
+
+	hostWidth, poolWidth := len("HOST"), len("POOL")
+	for _, row := range fv.rows {
+		hostWidth = max(hostWidth, len([]rune(row.Host)))
+		poolWidth = max(poolWidth, len([]rune(row.Pool)))
+	}
+	hostWidth, poolWidth = min(hostWidth, maxNameWidth), min(poolWidth, maxNameWidth)
+	const statusWidth = 12 // "UNREACHABLE" and "FAULTED !!" fit
+	barWidth := min(max(width-hostWidth-poolWidth-statusWidth-30, 0), 10)
+
+	lines := []string{
+		fv.styles.Title.UnsetMarginLeft().Render(fv.Summary()),
+		fv.styles.VDevType.Render(pad("HOST", hostWidth) + " " + pad("POOL", poolWidth) + " " +
+			pad("STATUS", statusWidth) + " " + pad("CAPACITY", barWidth+5) + " SCAN"),
+	}
+
+	// Scroll so that the cursor stays within the rows that fit
+	visible := max(height-fleetHeaderLines, 1)
+	fv.offset = min(fv.offset, fv.cursor)
+	if fv.cursor >= fv.offset+visible {
+		fv.offset = fv.cursor - visible + 1
+	}
+
+	for i := fv.offset; i < len(fv.rows) && i < fv.offset+visible; i++ {
+		row := fv.rows[i]
+		host, _ := shorten(row.Host, fv.styles.Glyphs.Ellipsis)
+		pool, _ := shorten(row.Pool, fv.styles.Glyphs.Ellipsis)
+		if pool == "" {
+			pool = "-"
+		}
+		names := pad(host, hostWidth) + " " + pad(pool, poolWidth)
+		if i == fv.cursor {
+			names = fv.styles.Selected.Render(names)
+		}
+
+		var line string
+		switch {
+		case row.Err != nil:
+			line = names + " " + pad(fv.styles.StatusFaulted.Render("UNREACHABLE"), statusWidth) + " " +
+				pad("", barWidth+5) + " " + firstLine(row.Err.Error())
+		case row.Pool == "":
+			line = names + " " + pad("", statusWidth) + " " + pad("", barWidth+5) + " no pools"
+		default:
+			capacity := ""
+			if row.Size > 0 {
+				capacity = fmt.Sprintf("%s %3.0f%%", fv.styles.Bar(row.CapacityPercent/100, barWidth), row.CapacityPercent)
+			}
+			line = names + " " + pad(fv.styles.RenderStatus(row.Status), statusWidth) + " " +
+				pad(capacity, barWidth+5) + " " + describeScan(row.Scan, time.Now())
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary counts hosts and pools by state.
+//
+// Returns:
+//   - string: e.g. "40 hosts, 1 unreachable, 95 pools: 3 degraded, 1 faulted"
+func (fv *FleetView) Summary() string {
+	unreachable, pools := 0, 0
+	states := make(map[zfs.VDevStatus]int)
+	for _, row := range fv.rows {
+		switch {
+		case row.Err != nil:
+			unreachable++
+		case row.Pool != "":
+			pools++
+			states[row.Status]++
+		}
+	}
+
+	summary := plural(fv.hosts, "host")
+	if unreachable > 0 {
+		summary += fmt.Sprintf(", %d unreachable", unreachable)
+	}
+	summary += ", " + plural(pools, "pool")
+	var problems []string
+	for _, s := range []zfs.VDevStatus{zfs.VDevStatusDegraded, zfs.VDevStatusFaulted} {
+		if states[s] > 0 {
+			problems = append(problems, fmt.Sprintf("%d %s", states[s], strings.ToLower(string(s))))
+		}
+	}
+	if len(problems) > 0 {
+		summary += ": " + strings.Join(problems, ", ")
+	}
+	return summary
+}
+
+// RenderLinear renders the fleet as plain sentences for screen readers,
+// one line per row.
+//
+// Returns:
+//   - string: The summary followed by a sentence per row
+func (fv *FleetView) RenderLinear() string {
+	lines := []string{capitalize(fv.Summary()) + "."}
+	for i := range fv.rows {
+		lines = append(lines, fv.describeRow(i)+".")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AnnounceCursor describes the selected row after the cursor moved.
+//
+// Returns:
+//   - string: e.g. "Host nas1, pool tank, degraded, 70 percent used, scrub 3d ago. Row 2 of 5."
+func (fv *FleetView) AnnounceCursor() string {
+	if _, ok := fv.Selected(); !ok {
+		return "No hosts."
+	}
+	return fmt.Sprintf("%s. Row %d of %d.", fv.describeRow(fv.cursor), fv.cursor+1, len(fv.rows))
+}
+
+// describeRow describes a row in a sentence without the final period.
+func (fv *FleetView) describeRow(i int) string {
+	row := fv.rows[i]
+	switch {
+	case row.Err != nil:
+		return fmt.Sprintf("Host %s, unreachable: %s", row.Host, firstLine(row.Err.Error()))
+	case row.Pool == "":
+		return fmt.Sprintf("Host %s, no pools", row.Host)
+	}
+	desc := fmt.Sprintf("Host %s, pool %s, %s", row.Host, row.Pool, strings.ToLower(string(row.Status)))
+	if row.Size > 0 {
+		desc += fmt.Sprintf(", %.0f percent used", row.CapacityPercent)
+	}
+	return desc + ", " + describeScan(row.Scan, time.Now())
+}
+
+// describeScan summarises a scan in a few words, e.g. "scrub 16%" while it
+// runs or "scrub 3d ago" once finished.
+func describeScan(scan *zfs.ScanInfo, now time.Time) string {
+	if scan == nil {
+		return "never scrubbed"
+	}
+	switch scan.State {
+	case "scanning":
+		if scan.ToExamine > 0 {
+			return fmt.Sprintf("%s %.0f%%", scan.Function, float64(scan.Examined)/float64(scan.ToExamine)*100)
+		}
+		return scan.Function + " running"
+	case "finished":
+		desc := fmt.Sprintf("%s %dd ago", scan.Function, int(now.Sub(scan.End).Hours()/24))
+		if scan.Errors > 0 {
+			desc += fmt.Sprintf(", %d errors", scan.Errors)
+		}
+		return desc
+	default:
+		return scan.Function + " " + scan.State
+	}
+}
+
+// pad appends spaces to s up to width cells, ignoring styling.
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-lipgloss.Width(s), 0))
+}
+
+// plural formats a count with a noun, e.g. "1 host" or "3 hosts".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// firstLine returns the first line of a possibly multi-line message.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,205 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// historyTimeFormat is how history times are shown.
+const historyTimeFormat = "2006-01-02 15:04:05"
+
+// HistoryView renders the command history of a pool, newest entry first,
+// narrowed down by a search. Only commands are shown unless internal
+// entries are asked for.
+type HistoryView struct {
+	pool     string              // Pool whose history is shown
+	entries  []*zfs.HistoryEntry // Oldest first
+	err      error               // Why the history could not be read
+	loading  bool                // Whether the history is being read
+	query    string              // Search words
+	internal bool                // Whether internal entries and ioctls are shown
+	offset   int                 // Index of the first shown entry drawn
+	styles   *styles.Styles
+}
+
+// NewHistoryView creates an empty history view.
+//
+// Parameters:
+//   - st: Styles for the current display mode
+//
+// Returns:
+//   - *HistoryView: A history view ready for Load
+func NewHistoryView(st *styles.Styles) *HistoryView {
+	return &HistoryView{styles: st}
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+func (hv *HistoryView) SetStyles(st *styles.Styles) {
+	hv.styles = st
+}
+
+// Load starts showing the history of a pool, which is being read: the
+// previous history, search and scroll position are cleared.
+//
+// Parameters:
+//   - pool: The pool name
+func (hv *HistoryView) Load(pool string) {
+	*hv = HistoryView{pool: pool, loading: true, internal: hv.internal, styles: hv.styles}
+}
+
+// Pool returns the name of the pool whose history is shown.
+func (hv *HistoryView) Pool() string {
+	return hv.pool
+}
+
+// SetEntries shows the history read, or why it could not be read.
+//
+// Parameters:
+//   - entries: The entries, oldest first
+//   - err: The error reading the history, or nil
+func (hv *HistoryView) SetEntries(entries []*zfs.HistoryEntry, err error) {
+	hv.entries, hv.err, hv.loading = entries, err, false
+}
+
+// SetQuery narrows the entries shown down to those matching a search, see
+// zfs.HistoryEntry.Matches, and scrolls back to the newest one.
+func (hv *HistoryView) SetQuery(query string) {
+	if query != hv.query {
+		hv.query, hv.offset = query, 0
+	}
+}
+
+// ToggleInternal shows or hides the internal entries and ioctls.
+func (hv *HistoryView) ToggleInternal() {
+	hv.internal, hv.offset = !hv.internal, 0
+}
+
+// Shown returns the entries matching the search, newest first.
+//
+// Returns:
+//   - []*zfs.HistoryEntry: The entries drawn, in display order
+func (hv *HistoryView) Shown() []*zfs.HistoryEntry {
+	var shown []*zfs.HistoryEntry
+	for i := len(hv.entries) - 1; i >= 0; i-- {
+		e := hv.entries[i]
+		if (hv.internal || e.Kind == zfs.HistoryCommand) && e.Matches(hv.query) {
+			shown = append(shown, e)
+		}
+	}
+	return shown
+}
+
+// Scroll moves through the shown entries, stopping at the newest one and
+// once the oldest one is drawn.
+//
+// Parameters:
+//   - delta: Number of entries to scroll, negative for newer ones
+//   - rows: Number of entries drawn at once
+//
+// Returns:
+//   - bool: Whether the view scrolled
+func (hv *HistoryView) Scroll(delta, rows int) bool {
+	offset := min(max(hv.offset+delta, 0), max(len(hv.Shown())-rows, 0))
+	if offset == hv.offset {
+		return false
+	}
+	hv.offset = offset
+	return true
+}
+
+// Summary describes what is shown, e.g. "History of tank: 3 of 8
+// entries, commands only".
+//
+// Returns:
+//   - string: The pool, the number of entries and the filters
+func (hv *HistoryView) Summary() string {
+	s := "History of " + hv.pool
+	switch {
+	case hv.loading:
+		return s + ": reading..."
+	case hv.err != nil:
+		return s + ": " + hv.err.Error()
+	}
+	s += fmt.Sprintf(": %d of %d entries", len(hv.Shown()), len(hv.entries))
+	if !hv.internal {
+		s += ", commands only"
+	}
+	if hv.query != "" {
+		s += ", matching " + fmt.Sprintf("%q", hv.query)
+	}
+	return s
+}
+
+// Render renders the shown entries from the scroll position, one line
+// each: time, user and host, and the command. Internal entries are marked
+// with their transaction group; the details of ioctls follow on the same
+// line.
+//
+// Parameters:
+//   - width: Available width in cells; longer lines are cut, 0 for no limit
+//   - rows: Number of entries to draw
+//
+// Returns:
+//   - string: The entries
+//
+// Example Output:
+//
+//	2024-10-07 09:30:02 root@nas1  zpool add tank raidz2 /dev/sdc /dev/sdd
+//	2024-10-01 10:13:10 nas1       [txg 12] set tank (54) compression=15
+func (hv *HistoryView) Render(width, rows int) string {
+	shown := hv.Shown()
+	hv.offset = min(hv.offset, max(len(shown)-rows, 0))
+	if len(shown) == 0 && !hv.loading && hv.err == nil {
+		return "No entries"
+	}
+
+	whoWidth := 0
+	for _, e := range shown {
+		whoWidth = max(whoWidth, len(who(e)))
+	}
+	var lines []string
+	for i := hv.offset; i < len(shown) && i < hv.offset+rows; i++ {
+		e := shown[i]
+		text := e.Text
+		if e.Kind == zfs.HistoryInternal {
+			text = fmt.Sprintf("[txg %d] %s", e.TXG, text)
+		}
+		if e.Details != "" {
+			text += "  " + strings.Join(strings.Fields(e.Details), " ")
+		}
+		prefix := fmt.Sprintf("%s %-*s ", e.Time.Format(historyTimeFormat), whoWidth, who(e))
+		if width > 0 {
+			text = cut(text, width-len(prefix))
+		}
+		line := prefix + text
+		if e.Kind != zfs.HistoryCommand {
+			line = hv.styles.HelpText.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// who returns who logged an entry: "user@host", or the host for internal
+// entries.
+func who(e *zfs.HistoryEntry) string {
+	if e.User == "" {
+		return e.Host
+	}
+	return e.User + "@" + e.Host
+}
+
+// cut shortens a text to a width, marking the cut with "...".
+func cut(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:max(width, 0)])
+	}
+	return string(runes[:width-3]) + "..."
+}
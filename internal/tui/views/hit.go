@@ -0,0 +1,61 @@
+package views
+
+// RegionKind tells what a hit region was drawn for.
+type RegionKind int
+
+// Kinds of hit regions.
+const (
+	// RegionTab is a pool tab; Index is the pool index
+	RegionTab RegionKind = iota
+
+	// RegionNode is a VDev line; Index is the node's position among the
+	// visible nodes, as used for the focus
+	RegionNode
+
+	// RegionRow is a row of the fleet table; Index is the row index
+	RegionRow
+)
+
+// Region is a rectangle of the rendered view that reacts to the mouse.
+// Coordinates are cells relative to the top left of the rendered content.
+type Region struct {
+	Kind   RegionKind
+	Index  int
+	X, Y   int
+	Width  int
+	Height int
+
+	// Name is the full name of what was drawn, and Truncated tells whether
+	// it had to be shortened to fit
+	Name      string
+	Truncated bool
+}
+
+// contains reports whether the cell x, y lies in the region.
+func (r Region) contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// offset returns the region moved by dx, dy.
+func (r Region) offset(dx, dy int) Region {
+	r.X += dx
+	r.Y += dy
+	return r
+}
+
+// HitTest finds what the last Render drew at a cell.
+//
+// Parameters:
+//   - x, y: The cell, relative to the top left of the rendered content
+//
+// Returns:
+//   - Region: The region at the cell
+//   - bool: Whether there is one
+func (pv *PoolView) HitTest(x, y int) (Region, bool) {
+	for _, r := range pv.regions {
+		if r.contains(x, y) {
+			return r, true
+		}
+	}
+	return Region{}, false
+}
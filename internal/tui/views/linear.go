@@ -0,0 +1,122 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/utils"
+)
+
+// RenderLinear renders the selected pool as plain, labelled sentences for
+// screen readers: one line per pool and VDev, without borders, colors or
+// tree drawing characters. It draws from the same view models as Render.
+//
+// Returns:
+//   - string: The linear text
+//
+// Example Output:
+//
+//	Pool fastpool, 2 of 2, state faulted, 512.0G of 2.0T used, 25 percent.
+//	Data vdev mirror, online, 2 children.
+//	  Disk sda1, online.
+//	  Disk sdb1, online.
+//	Cache vdev, online, 1 child.
+//	  Disk nvme0n1p1, online.
+//	Log vdev mirror, faulted, 2 children.
+//	...
+func (pv *PoolView) RenderLinear() string {
+	if len(pv.pools) == 0 {
+		return "No pools found."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(pv.describePool() + "\n")
+	for _, node := range pv.visible() {
+		sb.WriteString(strings.Repeat("  ", node.Depth) + capitalize(pv.describeNode(node)) + ".\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// AnnouncePool describes the selected pool after switching pools.
+//
+// Returns:
+//   - string: e.g. "Pool fastpool, 2 of 2, state degraded."
+func (pv *PoolView) AnnouncePool() string {
+	if len(pv.pools) == 0 {
+		return "No pools found."
+	}
+	return pv.describePool()
+}
+
+// AnnounceFocus describes the focused VDev after the focus moved.
+//
+// Returns:
+//   - string: e.g. "Focus: disk sda, degraded, 3 read errors, 12 checksum errors. Item 2 of 3."
+func (pv *PoolView) AnnounceFocus() string {
+	node := pv.Focused()
+	if node == nil {
+		return "No devices."
+	}
+	return fmt.Sprintf("Focus: %s. Item %d of %d.",
+		pv.describeNode(node), pv.focus+1, len(pv.visible()))
+}
+
+// describePool describes the selected pool in a sentence.
+func (pv *PoolView) describePool() string {
+	pool := pv.pools[pv.selected]
+	desc := fmt.Sprintf("Pool %s, %d of %d, state %s", pool.Name, pv.selected+1, len(pv.pools),
+		strings.ToLower(string(pool.Status)))
+	if pool.Size > 0 {
+		desc += fmt.Sprintf(", %s of %s used, %.0f percent",
+			utils.FormatBytes(pool.Allocated), utils.FormatBytes(pool.Size), pool.CapacityPercent)
+	}
+	return desc + "."
+}
+
+// describeNode describes a node as a phrase: what it is, its status, its
+// number of children and its error counters.
+func (pv *PoolView) describeNode(node *VDevNode) string {
+	// Top-level nodes are named by role; repeating names such as "log"
+	// or the pool name would only add noise
+	var label string
+	if node.Role != "" {
+		label = node.Role + " vdev"
+		if node.Type != node.Role {
+			label += " " + node.Type
+		}
+		if node.Name != node.Role && node.Name != node.Type && node.Name != pv.pools[pv.selected].Name {
+			label += " " + node.Name
+		}
+	} else {
+		label = node.Type + " " + pv.nodeLabel(node)
+	}
+
+	parts := []string{label, strings.ToLower(string(node.Status))}
+	if pv.IsCollapsed(node) {
+		parts = append(parts, "collapsed")
+	}
+	switch n := len(node.Children); n {
+	case 0:
+	case 1:
+		parts = append(parts, "1 child")
+	default:
+		parts = append(parts, fmt.Sprintf("%d children", n))
+	}
+	for _, c := range []struct {
+		n    uint64
+		kind string
+	}{{node.ReadErrors, "read"}, {node.WriteErrors, "write"}, {node.ChecksumErrors, "checksum"}} {
+		if c.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s errors", c.n, c.kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// capitalize upper-cases the first letter of a sentence.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,154 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// explanationIndent is the indentation of the explanation below a finding,
+// past its severity.
+const explanationIndent = 10
+
+// LintView renders the findings of the pool configuration linter: per
+// pool, every risky or suboptimal part of its layout with its severity
+// and an explanation.
+type LintView struct {
+	pools    []string           // Pool names in snapshot order
+	findings [][]status.Finding // Findings of each pool
+	analyzer *status.Analyzer
+	styles   *styles.Styles
+}
+
+// NewLintView creates an empty lint view.
+//
+// Parameters:
+//   - st: Styles for the current display mode
+//
+// Returns:
+//   - *LintView: A lint view ready for Update
+func NewLintView(st *styles.Styles) *LintView {
+	return &LintView{analyzer: &status.Analyzer{}, styles: st}
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+func (lv *LintView) SetStyles(st *styles.Styles) {
+	lv.styles = st
+}
+
+// SetAnalyzer replaces the analyzer linting the pools, e.g. to apply a
+// configured capacity limit. It takes effect with the next Update.
+func (lv *LintView) SetAnalyzer(analyzer *status.Analyzer) {
+	lv.analyzer = analyzer
+}
+
+// Update lints the pools of a new snapshot.
+func (lv *LintView) Update(pools []*zfs.Pool) {
+	lv.pools, lv.findings = nil, nil
+	for _, pool := range pools {
+		lv.pools = append(lv.pools, pool.Name)
+		lv.findings = append(lv.findings, lv.analyzer.LintPool(pool))
+	}
+}
+
+// Summary counts the findings for announcements.
+//
+// Returns:
+//   - string: E.g. "3 layout findings in 2 pools, 1 critical."
+func (lv *LintView) Summary() string {
+	total, critical := 0, 0
+	for _, findings := range lv.findings {
+		total += len(findings)
+		for _, f := range findings {
+			if f.Severity == status.SeverityCritical {
+				critical++
+			}
+		}
+	}
+	if total == 0 {
+		return "No layout findings in " + plural(len(lv.pools), "pool") + "."
+	}
+	summary := plural(total, "layout finding") + " in " + plural(len(lv.pools), "pool")
+	if critical > 0 {
+		summary += fmt.Sprintf(", %d critical", critical)
+	}
+	return summary + "."
+}
+
+// heading introduces the findings of a pool, e.g. "tank: 2 findings".
+func (lv *LintView) heading(i int) string {
+	if len(lv.findings[i]) == 0 {
+		return lv.pools[i] + ": no findings"
+	}
+	return lv.pools[i] + ": " + plural(len(lv.findings[i]), "finding")
+}
+
+// Render renders the findings of every pool, most severe first, each
+// followed by its explanation wrapped to the width.
+//
+// Parameters:
+//   - width: The width to wrap explanations at, 0 for no wrapping
+//
+// Returns:
+//   - string: The findings
+//
+// Example Output:
+//
+//	tank: 1 finding
+//	critical  sdc: single-disk vdev in a pool of redundant vdevs [single-disk]
+//	          The pool is lost if this disk fails, whatever the redundancy
+//	          of the other vdevs. ...
+func (lv *LintView) Render(width int) string {
+	explanation := lv.styles.HelpText
+	if width > explanationIndent {
+		explanation = explanation.Width(width - explanationIndent)
+	}
+	var blocks []string
+	for i := range lv.pools {
+		lines := []string{lv.styles.VDevType.Render(lv.heading(i))}
+		for _, f := range lv.findings[i] {
+			label := fmt.Sprintf("%-8s", f.Severity)
+			switch f.Severity {
+			case status.SeverityCritical:
+				label = lv.styles.StatusFaulted.Render(label)
+			case status.SeverityWarning:
+				label = lv.styles.StatusDegraded.Render(label)
+			}
+			lines = append(lines, label+"  "+describeFinding(f)+" "+lv.styles.HelpText.Render("["+f.Check+"]"))
+			text := lipgloss.NewStyle().MarginLeft(explanationIndent).Render(explanation.Render(f.Explanation))
+			lines = append(lines, text)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// RenderLinear renders the findings as text for screen readers: one line
+// per pool and per finding, with its explanation.
+//
+// Returns:
+//   - string: The findings, e.g. "Critical: sdc: single-disk vdev ... The
+//     pool is lost ..."
+func (lv *LintView) RenderLinear() string {
+	var lines []string
+	for i := range lv.pools {
+		lines = append(lines, lv.heading(i)+".")
+		for _, f := range lv.findings[i] {
+			severity := f.Severity.String()
+			lines = append(lines, strings.ToUpper(severity[:1])+severity[1:]+": "+describeFinding(f)+". "+f.Explanation)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeFinding describes a finding with the device it is about.
+func describeFinding(f status.Finding) string {
+	if f.Device == "" {
+		return f.Message
+	}
+	return f.Device + ": " + f.Message
+}
@@ -0,0 +1,130 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// historyLength is the number of refreshes the metrics pane remembers.
+const historyLength = 60
+
+// MetricsView renders the metrics pane: ARC statistics and the I/O of the
+// selected pool, with a sparkline of the bandwidth over the last refreshes.
+type MetricsView struct {
+	arc     *zfs.ARCStats
+	io      map[string]zfs.IOStats // Latest I/O per pool
+	history map[string][]float64   // Bandwidth per pool, oldest first
+	styles  *styles.Styles
+}
+
+// NewMetricsView creates an empty metrics view.
+//
+// Parameters:
+//   - st: Styles for the current display mode
+//
+// Returns:
+//   - *MetricsView: A metrics view ready for Update
+func NewMetricsView(st *styles.Styles) *MetricsView {
+	return &MetricsView{
+		io:      make(map[string]zfs.IOStats),
+		history: make(map[string][]float64),
+		styles:  st,
+	}
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+func (mv *MetricsView) SetStyles(st *styles.Styles) {
+	mv.styles = st
+}
+
+// Update records a refresh: the ARC statistics and the I/O of every pool,
+// summed over its disks. Pools whose I/O was not sampled show none.
+//
+// Parameters:
+//   - pools: The pools of the snapshot
+//   - arc: The ARC statistics, or nil if unavailable
+func (mv *MetricsView) Update(pools []*zfs.Pool, arc *zfs.ARCStats) {
+	mv.arc = arc
+	for _, pool := range pools {
+		var io zfs.IOStats
+		for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+			if vdev != nil {
+				sumIO(vdev, &io)
+			}
+		}
+		if !io.Sampled {
+			delete(mv.io, pool.Name)
+			continue
+		}
+		mv.io[pool.Name] = io
+
+		history := append(mv.history[pool.Name], io.ReadBytes+io.WriteBytes)
+		if len(history) > historyLength {
+			history = history[len(history)-historyLength:]
+		}
+		mv.history[pool.Name] = history
+	}
+}
+
+// sumIO adds the I/O of the leaves below vdev to io; io is sampled if any
+// leaf is.
+func sumIO(vdev *zfs.VDev, io *zfs.IOStats) {
+	if len(vdev.Children) == 0 {
+		io.Sampled = io.Sampled || vdev.IO.Sampled
+		io.ReadOps += vdev.IO.ReadOps
+		io.WriteOps += vdev.IO.WriteOps
+		io.ReadBytes += vdev.IO.ReadBytes
+		io.WriteBytes += vdev.IO.WriteBytes
+		return
+	}
+	for _, child := range vdev.Children {
+		sumIO(child, io)
+	}
+}
+
+// Render renders the metrics of a pool.
+//
+// Parameters:
+//   - pool: Name of the selected pool
+//   - width: Available width in cells, limiting the sparkline
+//
+// Returns:
+//   - string: The metrics
+//
+// Example Output:
+//
+//	ARC       3.2G of 4.0G (max 8.0G)
+//	Hit ratio 91.3%
+//
+//	testpool  R 120 ops/s 15.0M/s  W 80 ops/s 10.0M/s
+//	▁▁▂▄▇█▇▅▃
+func (mv *MetricsView) Render(pool string, width int) string {
+	var lines []string
+	label := func(s string) string { return mv.styles.VDevType.Render(fmt.Sprintf("%-10s", s)) }
+
+	if arc := mv.arc; arc != nil {
+		lines = append(lines, label("ARC")+fmt.Sprintf("%s of %s (max %s)",
+			utils.FormatBytes(arc.Size), utils.FormatBytes(arc.TargetSize), utils.FormatBytes(arc.MaxSize)))
+		if total := arc.Hits + arc.Misses; total > 0 {
+			lines = append(lines, label("Hit ratio")+fmt.Sprintf("%.1f%%", float64(arc.Hits)/float64(total)*100))
+		}
+	} else {
+		lines = append(lines, label("ARC")+"unavailable")
+	}
+
+	if io, ok := mv.io[pool]; ok {
+		lines = append(lines, "",
+			label("Read")+fmt.Sprintf("%.0f ops/s %s/s", io.ReadOps, utils.FormatBytes(uint64(io.ReadBytes))),
+			label("Write")+fmt.Sprintf("%.0f ops/s %s/s", io.WriteOps, utils.FormatBytes(uint64(io.WriteBytes))))
+		history := mv.history[pool]
+		if len(history) > width && width > 0 {
+			history = history[len(history)-width:]
+		}
+		lines = append(lines, mv.styles.PoolName.Render(mv.styles.Sparkline(history)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,169 @@
+package views
+
+import (
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// Roles of top-level VDev nodes.
+const (
+	RoleData  = "data"
+	RoleCache = "cache"
+	RoleLog   = "log"
+)
+
+// PoolModel is the display-independent view model of a pool. The visual
+// and the linear renderer both draw from it, so they always show the same
+// facts in the same order.
+type PoolModel struct {
+	// Name is the pool name
+	Name string
+
+	// Status is the worst status found anywhere in the pool
+	Status zfs.VDevStatus
+
+	// Size and Allocated are the pool capacity in bytes, 0 if unknown
+	Size      uint64
+	Allocated uint64
+
+	// CapacityPercent is the allocated share of Size
+	CapacityPercent float64
+
+	// Roots are the top-level nodes: data, then cache and log if present
+	Roots []*VDevNode
+
+	// Warnings are the problems the analyzer found in the pool
+	Warnings []status.Warning
+
+	// nodes holds every node in display order, for focus movement
+	nodes []*VDevNode
+}
+
+// VDevNode is the view model of a VDev.
+type VDevNode struct {
+	// ID identifies the node across refreshes: the pool name and role
+	// followed by the names of the node's ancestors and its own, e.g.
+	// "tank/data/mirror-0/sda"
+	ID string
+
+	// Name, Type and GUID are taken from the VDev
+	Name string
+	Type string
+	GUID uint64
+
+	// Disk identifies the physical disk of a leaf, nil if unknown
+	Disk *zfs.DiskIdentity
+
+	// SMART is the health the disk of a leaf reports, nil if unknown
+	SMART *zfs.SMARTInfo
+
+	// Role is RoleData, RoleCache or RoleLog for top-level nodes, empty below
+	Role string
+
+	// Status is the worst status of the VDev and its children
+	Status zfs.VDevStatus
+
+	// Size and Allocated are the VDev capacity in bytes, 0 if unknown
+	Size      uint64
+	Allocated uint64
+
+	// ReadErrors, WriteErrors and ChecksumErrors are the VDev's own counters
+	ReadErrors     uint64
+	WriteErrors    uint64
+	ChecksumErrors uint64
+
+	// Depth is the nesting level, 0 for top-level nodes
+	Depth int
+
+	// Children are the nodes of the child VDevs
+	Children []*VDevNode
+}
+
+// NewPoolModel builds the view model of a pool.
+//
+// Parameters:
+//   - pool: The pool to display
+//   - analyzer: Status analyzer for determining VDev health
+//
+// Returns:
+//   - *PoolModel: The view model with its nodes in display order
+func NewPoolModel(pool *zfs.Pool, analyzer *status.Analyzer) *PoolModel {
+	pm := &PoolModel{
+		Name:            pool.Name,
+		Status:          analyzer.GetPoolWorstStatus(pool),
+		Size:            pool.Size,
+		Allocated:       pool.Allocated,
+		CapacityPercent: pool.CapacityPercent(),
+		Warnings:        analyzer.GetPoolWarnings(pool),
+	}
+	for _, top := range []struct {
+		role string
+		vdev *zfs.VDev
+	}{{RoleData, pool.RootVDev}, {RoleCache, pool.Cache}, {RoleLog, pool.Slog}} {
+		if top.vdev != nil {
+			node := pm.addNode(top.vdev, pool.Name+"/"+top.role, 0, analyzer)
+			node.Role = top.role
+			pm.Roots = append(pm.Roots, node)
+		}
+	}
+	return pm
+}
+
+// addNode builds the node of a VDev and its children, recording them in
+// display order.
+func (pm *PoolModel) addNode(vdev *zfs.VDev, parent string, depth int, analyzer *status.Analyzer) *VDevNode {
+	node := &VDevNode{
+		ID:             parent + "/" + vdev.Name,
+		Name:           vdev.Name,
+		Type:           vdev.Type,
+		GUID:           vdev.GUID,
+		Disk:           vdev.Disk,
+		SMART:          vdev.SMART,
+		Status:         analyzer.GetVDevWorstStatus(vdev),
+		Size:           vdev.Size,
+		Allocated:      vdev.Allocated,
+		ReadErrors:     vdev.ReadErrors,
+		WriteErrors:    vdev.WriteErrors,
+		ChecksumErrors: vdev.ChecksumErrors,
+		Depth:          depth,
+	}
+	pm.nodes = append(pm.nodes, node)
+	for _, child := range vdev.Children {
+		node.Children = append(node.Children, pm.addNode(child, node.ID, depth+1, analyzer))
+	}
+	return node
+}
+
+// Nodes returns every node in display order: depth first, data before
+// cache and log. See Visible for the nodes drawn when some are collapsed.
+//
+// Returns:
+//   - []*VDevNode: All nodes of the pool
+func (pm *PoolModel) Nodes() []*VDevNode {
+	return pm.nodes
+}
+
+// Visible returns the nodes in display order, leaving out the descendants
+// of collapsed nodes.
+//
+// Parameters:
+//   - collapsed: IDs of the collapsed nodes
+//
+// Returns:
+//   - []*VDevNode: The nodes that are drawn
+func (pm *PoolModel) Visible(collapsed map[string]bool) []*VDevNode {
+	var nodes []*VDevNode
+	var walk func(node *VDevNode)
+	walk = func(node *VDevNode) {
+		nodes = append(nodes, node)
+		if !collapsed[node.ID] {
+			for _, child := range node.Children {
+				walk(child)
+			}
+		}
+	}
+	for _, root := range pm.Roots {
+		walk(root)
+	}
+	return nodes
+}
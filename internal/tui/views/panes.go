@@ -0,0 +1,170 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// RenderPoolList renders the pools pane: one line per pool with its status
+// and capacity, the selected pool highlighted. Line i shows pool i, so a
+// click on a line selects the pool directly.
+//
+// Parameters:
+//   - width: Available width in cells
+//
+// Returns:
+//   - string: The pool list
+//
+// Example Output:
+//
+//	testpool DEGRADED ███████░░░ 70%
+//	fastpool FAULTED  ██░░░░░░░░ 25%
+func (pv *PoolView) RenderPoolList(width int) string {
+	if len(pv.pools) == 0 {
+		return "No pools found"
+	}
+
+	nameWidth, statusWidth := 0, 0
+	for _, pool := range pv.pools {
+		nameWidth = max(nameWidth, len([]rune(pool.Name)))
+		statusWidth = max(statusWidth, len(pool.Status))
+	}
+	nameWidth = min(nameWidth, maxNameWidth)
+	barWidth := min(max(width-nameWidth-statusWidth-8, 0), 10)
+
+	var lines []string
+	for i, pool := range pv.pools {
+		name, _ := pv.shorten(pool.Name)
+		name += strings.Repeat(" ", nameWidth-len([]rune(name)))
+		if i == pv.selected {
+			name = pv.styles.Selected.Render(name)
+		} else {
+			name = pv.styles.PoolName.Render(name)
+		}
+
+		line := name + " " + pv.styles.RenderStatus(pool.Status) +
+			strings.Repeat(" ", statusWidth-len(pool.Status))
+		if pool.Size > 0 && barWidth > 0 {
+			line += fmt.Sprintf(" %s %3.0f%%", pv.styles.Bar(pool.CapacityPercent/100, barWidth), pool.CapacityPercent)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderDetails renders the details pane: everything known about the
+// focused VDev.
+//
+// Returns:
+//   - string: The details, one "label: value" line each
+func (pv *PoolView) RenderDetails() string {
+	node := pv.Focused()
+	if node == nil {
+		return "Nothing selected"
+	}
+
+	rows := [][2]string{
+		{"Name", node.Name},
+		{"Type", node.Type},
+		{"Status", pv.styles.RenderStatus(node.Status)},
+	}
+	if node.Role != "" {
+		rows = append(rows, [2]string{"Role", node.Role})
+	}
+	if node.Size > 0 {
+		rows = append(rows, [2]string{"Size", utils.FormatBytes(node.Size)})
+	}
+	if node.Allocated > 0 {
+		rows = append(rows, [2]string{"Allocated", utils.FormatBytes(node.Allocated)})
+	}
+	if len(node.Children) > 0 {
+		rows = append(rows, [2]string{"Children", fmt.Sprint(len(node.Children))})
+	}
+	rows = append(rows, [2]string{"Errors", fmt.Sprintf("read %d, write %d, checksum %d",
+		node.ReadErrors, node.WriteErrors, node.ChecksumErrors)})
+	if disk := node.Disk; disk != nil {
+		media := "SSD"
+		if disk.Rotational {
+			media = "HDD"
+		}
+		for _, row := range [][2]string{
+			{"Device", disk.Device},
+			{"Location", disk.Location()},
+			{"Model", disk.Model},
+			{"Serial", disk.Serial},
+			{"WWN", disk.WWN},
+			{"Media", media},
+			{"By-id", strings.Join(disk.ByID, ", ")},
+			{"By-path", strings.Join(disk.ByPath, ", ")},
+			{"By-vdev", strings.Join(disk.ByVDev, ", ")},
+		} {
+			if row[1] != "" {
+				rows = append(rows, row)
+			}
+		}
+		if disk.Size > 0 {
+			rows = append(rows, [2]string{"Disk size", utils.FormatBytes(disk.Size)})
+		}
+	}
+	if smart := node.SMART; smart != nil {
+		verdict := smart.Verdict
+		switch verdict {
+		case zfs.SMARTFailed:
+			verdict = pv.styles.StatusFaulted.Render(verdict)
+		case "":
+			verdict = "no verdict"
+		}
+		rows = append(rows, [2]string{"SMART", verdict})
+		if smart.Temperature > 0 {
+			rows = append(rows, [2]string{"Temp", fmt.Sprintf("%d°C", smart.Temperature)})
+		}
+		if smart.PowerOnHours > 0 {
+			rows = append(rows, [2]string{"Power-on", fmt.Sprintf("%d h (%.1f years)", smart.PowerOnHours, float64(smart.PowerOnHours)/(365*24))})
+		}
+		rows = append(rows, [2]string{"Sectors", fmt.Sprintf("reallocated %d, pending %d", smart.Reallocated, smart.Pending)})
+		rows = append(rows, [2]string{"Media err", fmt.Sprint(smart.MediaErrors)})
+	}
+
+	var lines []string
+	for _, row := range rows {
+		lines = append(lines, pv.styles.VDevType.Render(fmt.Sprintf("%-10s", row[0]+":"))+" "+row[1])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderEvents renders the events pane: the problems the analyzer found in
+// the selected pool, most severe first.
+//
+// Returns:
+//   - string: One line per problem
+func (pv *PoolView) RenderEvents() string {
+	if len(pv.pools) == 0 {
+		return ""
+	}
+	warnings := pv.pools[pv.selected].Warnings
+	if len(warnings) == 0 {
+		return "No problems found"
+	}
+
+	var lines []string
+	for severity := status.SeverityCritical; severity >= status.SeverityInfo; severity-- {
+		for _, w := range warnings {
+			if w.Severity != severity {
+				continue
+			}
+			label := fmt.Sprintf("%-8s", w.Severity)
+			switch w.Severity {
+			case status.SeverityCritical:
+				label = pv.styles.StatusFaulted.Render(label)
+			case status.SeverityWarning:
+				label = pv.styles.StatusDegraded.Render(label)
+			}
+			lines = append(lines, label+" "+w.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,386 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/utils"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// maxPlannerWidth is the widest VDev the planner offers; wider raidz is
+// possible but rebuilds too slowly to be planned.
+const maxPlannerWidth = 64
+
+// plannerDiskSizes are the disk sizes the planner steps through, as sold
+// in decimal units: common SSD and hard disk capacities.
+var plannerDiskSizes = []uint64{
+	480e9, 960e9, 1e12, 1.92e12, 2e12, 3.84e12, 4e12, 6e12, 7.68e12, 8e12, 10e12,
+	12e12, 14e12, 15.36e12, 16e12, 18e12, 20e12, 22e12, 24e12, 28e12, 30.72e12,
+}
+
+// DefaultPlannerLayout is the layout a new pool is planned from: a 6-wide
+// raidz2 of 8 TB disks.
+var DefaultPlannerLayout = zfs.PoolLayout{
+	VDevs:      []zfs.VDevLayout{{Count: 1, Type: "raidz2", Width: 6, DiskSize: 8e12}},
+	Ashift:     zfs.DefaultAshift,
+	RecordSize: zfs.DefaultRecordSize,
+}
+
+// Fields of a group of VDevs in the planner, followed by the fields of the
+// whole pool.
+const (
+	plannerCount = iota
+	plannerType
+	plannerWidth
+	plannerDiskSize
+	plannerGroupFields
+
+	plannerAshift     = plannerGroupFields
+	plannerRecordSize = plannerGroupFields + 1
+)
+
+// plannerFieldNames names the fields by kind.
+var plannerFieldNames = [...]string{"Vdevs", "Type", "Width", "Disk size", "Ashift", "Recordsize"}
+
+// PlannerView is an interactive calculator of the usable capacity of a
+// pool layout, real or planned: the layout's fields are edited one at a
+// time, and the breakdown of the raw space into parity, raidz padding,
+// slop space and usable space is shown as it changes.
+type PlannerView struct {
+	layout zfs.PoolLayout
+	pool   string // Pool the layout was loaded from, empty for a new pool
+	note   string // Why the selected pool's layout could not be loaded
+	field  int    // Index of the focused field
+	styles *styles.Styles
+}
+
+// NewPlannerView creates a planner for a new pool of DefaultPlannerLayout.
+//
+// Parameters:
+//   - st: Styles for the current display mode
+//
+// Returns:
+//   - *PlannerView: A planner ready to render
+func NewPlannerView(st *styles.Styles) *PlannerView {
+	pv := &PlannerView{styles: st}
+	pv.Load(nil)
+	return pv
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+func (pv *PlannerView) SetStyles(st *styles.Styles) {
+	pv.styles = st
+}
+
+// Load starts planning from the layout of a pool, or from
+// DefaultPlannerLayout for a new pool. A pool whose layout cannot be
+// estimated, such as one of dRAID VDevs, is noted and a new pool planned
+// instead.
+//
+// Parameters:
+//   - pool: The pool to start from, nil for a new pool
+func (pv *PlannerView) Load(pool *zfs.Pool) {
+	pv.pool, pv.note, pv.field = "", "", 0
+	layout := DefaultPlannerLayout
+	if pool != nil {
+		if l, err := zfs.LayoutOf(pool); err != nil {
+			pv.note = err.Error()
+		} else {
+			layout, pv.pool = l, pool.Name
+		}
+	}
+	// Copy the VDevs, which are edited in place
+	pv.layout = layout
+	pv.layout.VDevs = append([]zfs.VDevLayout(nil), layout.VDevs...)
+}
+
+// Layout returns the layout as edited.
+func (pv *PlannerView) Layout() zfs.PoolLayout {
+	return pv.layout
+}
+
+// fields returns the number of fields: those of every group of VDevs and
+// of the pool.
+func (pv *PlannerView) fields() int {
+	return len(pv.layout.VDevs)*plannerGroupFields + 2
+}
+
+// fieldAt returns the group of VDevs and the kind of a field; the group is
+// -1 for the fields of the pool.
+func (pv *PlannerView) fieldAt(i int) (group, kind int) {
+	if n := len(pv.layout.VDevs) * plannerGroupFields; i >= n {
+		return -1, plannerGroupFields + i - n
+	}
+	return i / plannerGroupFields, i % plannerGroupFields
+}
+
+// Move focuses the field delta fields below the focused one, staying
+// within the fields.
+func (pv *PlannerView) Move(delta int) {
+	pv.field = max(min(pv.field+delta, pv.fields()-1), 0)
+}
+
+// Adjust steps the value of the focused field up (delta > 0) or down: the
+// count and width by one disk or VDev, the type through
+// zfs.VDevLayoutTypes, the disk size through common capacities, the ashift
+// by one and the recordsize by a power of two. Values stay valid: the
+// width is raised to what a type needs.
+func (pv *PlannerView) Adjust(delta int) {
+	group, kind := pv.fieldAt(pv.field)
+	switch kind {
+	case plannerAshift:
+		pv.layout.Ashift = max(min(pv.layout.Ashift+delta, zfs.MaxAshift), zfs.MinAshift)
+		return
+	case plannerRecordSize:
+		if delta > 0 && pv.layout.RecordSize < zfs.MaxRecordSize {
+			pv.layout.RecordSize <<= 1
+		} else if delta < 0 && pv.layout.RecordSize > zfs.MinRecordSize {
+			pv.layout.RecordSize >>= 1
+		}
+		return
+	}
+
+	v := &pv.layout.VDevs[group]
+	switch kind {
+	case plannerCount:
+		v.Count = max(v.Count+delta, 1)
+	case plannerType:
+		i := 0
+		for j, t := range zfs.VDevLayoutTypes {
+			if t == v.Type {
+				i = j
+			}
+		}
+		v.Type = zfs.VDevLayoutTypes[max(min(i+delta, len(zfs.VDevLayoutTypes)-1), 0)]
+		if v.Type == "disk" {
+			v.Width = 1
+		} else {
+			v.Width = max(v.Width, v.MinWidth())
+		}
+	case plannerWidth:
+		if v.Type != "disk" {
+			v.Width = max(min(v.Width+delta, maxPlannerWidth), v.MinWidth())
+		}
+	case plannerDiskSize:
+		v.DiskSize = stepDiskSize(v.DiskSize, delta)
+	}
+}
+
+// stepDiskSize returns the next larger (delta > 0) or smaller of
+// plannerDiskSizes than a size, or the size if there is none.
+func stepDiskSize(size uint64, delta int) uint64 {
+	if delta > 0 {
+		for _, s := range plannerDiskSizes {
+			if s > size {
+				return s
+			}
+		}
+		return size
+	}
+	for i := len(plannerDiskSizes) - 1; i >= 0; i-- {
+		if plannerDiskSizes[i] < size {
+			return plannerDiskSizes[i]
+		}
+	}
+	return size
+}
+
+// AddGroup adds a group of VDevs like the focused one, or like the last
+// one while a field of the pool is focused, and focuses its first field:
+// e.g. to plan a pool of 8 TB disks extended by 16 TB ones.
+func (pv *PlannerView) AddGroup() {
+	group, _ := pv.fieldAt(pv.field)
+	if group < 0 {
+		group = len(pv.layout.VDevs) - 1
+	}
+	pv.layout.VDevs = append(pv.layout.VDevs, pv.layout.VDevs[group])
+	pv.field = (len(pv.layout.VDevs) - 1) * plannerGroupFields
+}
+
+// RemoveGroup removes the focused group of VDevs, unless it is the only one.
+//
+// Returns:
+//   - bool: Whether a group was removed
+func (pv *PlannerView) RemoveGroup() bool {
+	group, _ := pv.fieldAt(pv.field)
+	if group < 0 || len(pv.layout.VDevs) == 1 {
+		return false
+	}
+	pv.layout.VDevs = append(pv.layout.VDevs[:group], pv.layout.VDevs[group+1:]...)
+	pv.Move(0) // the last field may be gone
+	return true
+}
+
+// Title names what planning started from, e.g. "Capacity planner: based
+// on testpool".
+func (pv *PlannerView) Title() string {
+	if pv.pool == "" {
+		return "Capacity planner: new pool"
+	}
+	return "Capacity planner: based on " + pv.pool
+}
+
+// label names a field, e.g. "Disk size", prefixed by its group if the
+// layout has several, e.g. "Group 2 disk size".
+func (pv *PlannerView) label(i int) string {
+	group, kind := pv.fieldAt(i)
+	name := plannerFieldNames[kind]
+	if group < 0 || len(pv.layout.VDevs) == 1 {
+		return name
+	}
+	return fmt.Sprintf("Group %d %s", group+1, strings.ToLower(name))
+}
+
+// value renders the value of a field, e.g. "6 disks".
+func (pv *PlannerView) value(i int) string {
+	group, kind := pv.fieldAt(i)
+	switch kind {
+	case plannerAshift:
+		return fmt.Sprintf("%d (%s sectors)", pv.layout.Ashift, utils.FormatBytes(1<<pv.layout.Ashift))
+	case plannerRecordSize:
+		return utils.FormatBytes(pv.layout.RecordSize)
+	}
+	v := pv.layout.VDevs[group]
+	switch kind {
+	case plannerCount:
+		return strconv.Itoa(v.Count)
+	case plannerType:
+		return v.Type
+	case plannerWidth:
+		return plural(v.Width, "disk")
+	}
+	return decimalSize(v.DiskSize) + " (" + utils.FormatBytes(v.DiskSize) + ")"
+}
+
+// decimalSize formats a disk size in decimal units as disks are sold,
+// e.g. "1.92 TB" or "960 GB".
+func decimalSize(n uint64) string {
+	unit, div := "TB", 1e12
+	if n < 1e12 {
+		unit, div = "GB", 1e9
+	}
+	s := strconv.FormatFloat(float64(n)/div, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	return s + " " + unit
+}
+
+// Focus describes the focused field for announcements.
+//
+// Returns:
+//   - string: E.g. "Width: 6 disks."
+func (pv *PlannerView) Focus() string {
+	return pv.label(pv.field) + ": " + pv.value(pv.field) + "."
+}
+
+// Summary sums up the estimate for announcements.
+//
+// Returns:
+//   - string: E.g. "Usable 28.9T of 43.7T raw, 66%.", or the reason the
+//     layout cannot be estimated
+func (pv *PlannerView) Summary() string {
+	c, err := zfs.EstimateCapacity(pv.layout)
+	if err != nil {
+		return "Invalid layout: " + err.Error() + "."
+	}
+	return fmt.Sprintf("Usable %s of %s raw, %.0f%%.", utils.FormatBytes(c.Usable), utils.FormatBytes(c.Raw), c.Share(c.Usable))
+}
+
+// capacityPart is a part of the raw space of an estimate.
+type capacityPart struct {
+	label string
+	size  uint64
+}
+
+// breakdown lists the parts of an estimate, the raw space first.
+func breakdown(c zfs.Capacity) []capacityPart {
+	return []capacityPart{{"Raw", c.Raw}, {"Parity", c.Parity}, {"Padding", c.Padding}, {"Slop", c.Slop}, {"Usable", c.Usable}}
+}
+
+// Render renders the fields, the focused one highlighted, and the
+// estimate: every part of the raw space with its share. With several
+// groups of VDevs, the fields of each are indented below a heading.
+//
+// Returns:
+//   - string: The planner
+//
+// Example Output:
+//
+//	Vdevs         1
+//	Type          raidz2
+//	Width         6 disks
+//	Disk size     8 TB (7.3T)
+//	Ashift        12 (4.0K sectors)
+//	Recordsize    128.0K
+//
+//	Raw            43.7T  100.0%
+//	Parity         14.6T   33.3%
+//	Padding           0B    0.0%
+//	Slop          128.0G    0.3%
+//	Usable         29.0T   66.4%
+func (pv *PlannerView) Render() string {
+	var lines []string
+	if pv.note != "" {
+		lines = append(lines, pv.styles.StatusDegraded.Render("Cannot plan from the selected pool: "+pv.note), "")
+	}
+	for i := 0; i < pv.fields(); i++ {
+		group, kind := pv.fieldAt(i)
+		if kind == plannerCount && len(pv.layout.VDevs) > 1 {
+			lines = append(lines, pv.styles.VDevType.Render(fmt.Sprintf("Group %d", group+1)))
+		}
+		name := fmt.Sprintf("%-12s", plannerFieldNames[kind])
+		if group >= 0 && len(pv.layout.VDevs) > 1 {
+			name = fmt.Sprintf("  %-10s", plannerFieldNames[kind])
+		}
+		line := name + "  " + pv.value(i)
+		if i == pv.field {
+			line = pv.styles.Selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+
+	c, err := zfs.EstimateCapacity(pv.layout)
+	if err != nil {
+		return strings.Join(append(lines, pv.styles.StatusFaulted.Render("Invalid layout: "+err.Error())), "\n")
+	}
+	for _, part := range breakdown(c) {
+		line := fmt.Sprintf("%-12s %7s  %5.1f%%", part.label, utils.FormatBytes(part.size), c.Share(part.size))
+		if part.label == "Usable" {
+			line = pv.styles.VDevType.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderLinear renders the planner as text for screen readers: one line
+// per field and per part of the estimate.
+//
+// Returns:
+//   - string: The planner, e.g. "Width: 6 disks, selected." and
+//     "Usable: 29.0T, 66.4% of raw."
+func (pv *PlannerView) RenderLinear() string {
+	var lines []string
+	if pv.note != "" {
+		lines = append(lines, "Cannot plan from the selected pool: "+pv.note+".")
+	}
+	for i := 0; i < pv.fields(); i++ {
+		line := pv.label(i) + ": " + pv.value(i)
+		if i == pv.field {
+			line += ", selected"
+		}
+		lines = append(lines, line+".")
+	}
+	c, err := zfs.EstimateCapacity(pv.layout)
+	if err != nil {
+		return strings.Join(append(lines, "Invalid layout: "+err.Error()+"."), "\n")
+	}
+	lines = append(lines, "Raw: "+utils.FormatBytes(c.Raw)+".")
+	for _, part := range breakdown(c)[1:] {
+		lines = append(lines, fmt.Sprintf("%s: %s, %.1f%% of raw.", part.label, utils.FormatBytes(part.size), c.Share(part.size)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -4,32 +4,51 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/petecog/vizfsulizer/internal/tui/styles"
 	"github.com/petecog/vizfsulizer/internal/zfs"
 	"github.com/petecog/vizfsulizer/internal/zfs/status"
 )
 
 // PoolView represents the visual component for displaying ZFS pool information.
-// It maintains the current state of pools and handles their rendering.
-// The view supports multiple pools with tab-based navigation and detailed
-// status information for each pool's virtual devices (VDevs).
-// TODO? Split out maintain current state and rendering into separate structs?
+// It maintains the current state of pools as view models (see model.go) and
+// renders them either visually (Render) or as linear text for screen readers
+// (RenderLinear, see linear.go).
+// The view supports multiple pools with tab-based navigation, a focus
+// cursor over the VDevs of the selected pool, collapsible VDevs, and
+// detailed status information for each pool's virtual devices (VDevs).
+// Render records where it drew tabs and VDevs, for mouse hit tests.
 type PoolView struct {
-	pools    []*zfs.Pool      // List of ZFS pools to display
-	selected int              // Index of currently selected pool
-	analyzer *status.Analyzer // Tool for analyzing pool and VDev health
+	pools     []*PoolModel     // View models of the pools to display
+	selected  int              // Index of currently selected pool
+	focus     int              // Index of the focused node among the visible nodes
+	collapsed map[string]bool  // IDs of collapsed nodes, kept across refreshes
+	regions   []Region         // Hit regions of the last Render
+	label     string           // Identity leaves are labelled by, one of zfs.DiskLabels
+	analyzer  *status.Analyzer // Tool for analyzing pool and VDev health
+	styles    *styles.Styles   // Styles for the current display mode
 }
 
+// maxNameWidth is the widest VDev name drawn in full; longer names are
+// shortened and shown in full when hovered.
+const maxNameWidth = 24
+
 // NewPoolView creates and initializes a new PoolView with default values.
 // It sets up a status analyzer for determining pool and VDev health states.
 // The analyzer is used to recursively check the status of all devices
 // in the pool hierarchy.
 //
+// Parameters:
+//   - st: Styles for the current display mode
+//
 // Returns:
 //   - *PoolView: A new PoolView instance ready for use
-func NewPoolView() *PoolView {
+func NewPoolView(st *styles.Styles) *PoolView {
 	return &PoolView{
-		analyzer: &status.Analyzer{},
+		collapsed: make(map[string]bool),
+		label:     "name",
+		analyzer:  &status.Analyzer{},
+		styles:    st,
 	}
 }
 
@@ -40,7 +59,13 @@ func NewPoolView() *PoolView {
 // Parameters:
 //   - pools: New slice of Pool pointers to display
 func (pv *PoolView) Update(pools []*zfs.Pool) {
-	pv.pools = pools
+	pv.pools = make([]*PoolModel, len(pools))
+	for i, pool := range pools {
+		pv.pools[i] = NewPoolModel(pool, pv.analyzer)
+	}
+	if pv.selected < len(pv.pools) {
+		pv.focus = min(pv.focus, max(len(pv.visible())-1, 0))
+	}
 }
 
 // SetSelected updates the currently selected pool index.
@@ -50,15 +75,137 @@ func (pv *PoolView) Update(pools []*zfs.Pool) {
 // Parameters:
 //   - idx: Index of the pool to select
 func (pv *PoolView) SetSelected(idx int) {
+	if idx != pv.selected {
+		pv.focus = 0
+	}
 	pv.selected = idx
 }
 
+// MoveFocus moves the focus cursor through the VDevs of the selected pool,
+// stopping at the first and last one.
+//
+// Parameters:
+//   - delta: Number of nodes to move, negative to move up
+//
+// Returns:
+//   - bool: Whether the focus moved
+func (pv *PoolView) MoveFocus(delta int) bool {
+	if pv.selected >= len(pv.pools) {
+		return false
+	}
+	return pv.SetFocus(pv.focus + delta)
+}
+
+// SetFocus moves the focus cursor to a visible node of the selected pool,
+// e.g. one that was clicked. Out of range indexes are clamped.
+//
+// Parameters:
+//   - idx: Index of the node among the visible nodes
+//
+// Returns:
+//   - bool: Whether the focus moved
+func (pv *PoolView) SetFocus(idx int) bool {
+	if pv.selected >= len(pv.pools) {
+		return false
+	}
+	focus := min(max(idx, 0), len(pv.visible())-1)
+	if focus < 0 || focus == pv.focus {
+		return false
+	}
+	pv.focus = focus
+	return true
+}
+
+// FocusEvent moves the focus cursor to the VDev of the selected pool an
+// event is about, expanding its collapsed ancestors.
+//
+// Parameters:
+//   - e: The event, e.g. a checksum error report
+//
+// Returns:
+//   - bool: Whether the pool has the VDev, which is focused now
+func (pv *PoolView) FocusEvent(e *zfs.Event) bool {
+	if pv.selected >= len(pv.pools) {
+		return false
+	}
+	for _, node := range pv.pools[pv.selected].Nodes() {
+		if !e.RefersTo(node.Name, node.GUID) {
+			continue
+		}
+		for id := range pv.collapsed {
+			if strings.HasPrefix(node.ID, id+"/") {
+				delete(pv.collapsed, id)
+			}
+		}
+		for i, visible := range pv.visible() {
+			if visible == node {
+				pv.focus = i
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// ToggleFocused collapses the focused node, hiding its children, or
+// expands it again.
+//
+// Returns:
+//   - bool: Whether the node has children and was toggled
+func (pv *PoolView) ToggleFocused() bool {
+	node := pv.Focused()
+	if node == nil || len(node.Children) == 0 {
+		return false
+	}
+	pv.collapsed[node.ID] = !pv.collapsed[node.ID]
+	return true
+}
+
+// IsCollapsed reports whether a node's children are hidden.
+//
+// Parameters:
+//   - node: The node to check
+//
+// Returns:
+//   - bool: Whether the node has children and is collapsed
+func (pv *PoolView) IsCollapsed(node *VDevNode) bool {
+	return len(node.Children) > 0 && pv.collapsed[node.ID]
+}
+
+// visible returns the visible nodes of the selected pool.
+func (pv *PoolView) visible() []*VDevNode {
+	if pv.selected >= len(pv.pools) {
+		return nil
+	}
+	return pv.pools[pv.selected].Visible(pv.collapsed)
+}
+
+// Focused returns the focused node of the selected pool.
+//
+// Returns:
+//   - *VDevNode: The focused node, or nil if there is none
+func (pv *PoolView) Focused() *VDevNode {
+	nodes := pv.visible()
+	if pv.focus >= len(nodes) {
+		return nil
+	}
+	return nodes[pv.focus]
+}
+
+// SetStyles replaces the styles, e.g. after the theme was switched.
+//
+// Parameters:
+//   - st: Styles to render with from now on
+func (pv *PoolView) SetStyles(st *styles.Styles) {
+	pv.styles = st
+}
+
 // Render generates the complete string representation of the PoolView.
 // It creates a formatted display including:
 //   - A tab bar showing all available pools
 //   - Detailed information about the selected pool
 //   - Status indicators for all VDevs in the pool
-//   - Help text showing available commands
+//   - In black & white mode, a legend of the border styles
 //
 // The output uses border styles based on pool health status and
 // includes proper spacing and alignment for readability.
@@ -70,42 +217,55 @@ func (pv *PoolView) SetSelected(idx int) {
 //
 //	[ pool1 ]  pool2   pool3
 //
-//	Pool: pool1 [ONLINE]
+//	Pool: pool1 [ONLINE] ███████░░░ 70%
 //	├─ mirror-0 (mirror) [ONLINE]
 //	│  ├─ sda (disk) [ONLINE]
 //	│  └─ sdb (disk) [ONLINE]
-//
-//	Tab/Arrow Keys to switch pools • q to quit
 func (pv *PoolView) Render() string {
 	if len(pv.pools) == 0 {
 		return "No pools found"
 	}
 
 	var sb strings.Builder
+	pv.regions = pv.regions[:0]
 
 	// Render tabs
-	sb.WriteString(renderTabs(pv.pools, pv.selected) + "\n\n")
+	sb.WriteString(pv.renderTabs() + "\n\n")
+	tabLines := 2
 
 	// Render selected pool
 	pool := pv.pools[pv.selected]
-	worstStatus := pv.analyzer.GetPoolWorstStatus(pool) // Use analyzer's GetPoolWorstStatus
-	poolContent := fmt.Sprintf("Pool: %s [%s]\n%s",
-		styles.PoolName.Render(pool.Name),
-		renderStatus(worstStatus),
-		renderVDev(pool.RootVDev, 0, pv.analyzer))
-
-	if pool.Cache != nil {
-		poolContent += renderVDev(pool.Cache, 0, pv.analyzer)
+	poolContent := fmt.Sprintf("Pool: %s [%s]",
+		pv.styles.PoolName.Render(pool.Name),
+		pv.styles.RenderStatus(pool.Status))
+	if pool.Size > 0 {
+		poolContent += fmt.Sprintf(" %s %.0f%%", pv.styles.Bar(pool.CapacityPercent/100, 10), pool.CapacityPercent)
 	}
-	if pool.Slog != nil {
-		poolContent += renderVDev(pool.Slog, 0, pv.analyzer)
+	poolContent += "\n"
+
+	// Node regions are recorded relative to the content they are part of
+	// and moved by the frame of every box they end up in
+	var regions []Region
+	index := 0
+	for _, root := range pool.Roots {
+		content, rootRegions := pv.renderVDev(root, &index)
+		for _, r := range rootRegions {
+			regions = append(regions, r.offset(0, strings.Count(poolContent, "\n")))
+		}
+		poolContent += content
 	}
 
-	boxedPool := styles.GetStatusBorderStyle(worstStatus).Render(poolContent)
-	sb.WriteString(boxedPool + "\n\n")
+	boxStyle := pv.styles.GetStatusBorderStyle(pool.Status)
+	dx, dy := frameOffset(boxStyle)
+	for _, r := range regions {
+		pv.regions = append(pv.regions, r.offset(dx, dy+tabLines))
+	}
+	sb.WriteString(boxStyle.Render(poolContent))
 
-	// Update help text to include tab navigation
-	sb.WriteString(styles.HelpText.Render("Tab/Arrow Keys to switch pools • q to quit"))
+	// In black & white mode, explain what the border styles mean
+	if legend := pv.styles.BorderLegend(); legend != "" {
+		sb.WriteString("\n\n" + pv.styles.HelpText.Render(legend))
+	}
 	return sb.String()
 }
 
@@ -114,73 +274,146 @@ func (pv *PoolView) Render() string {
 // the currently selected pool. This provides visual feedback for
 // pool navigation.
 //
-// Parameters:
-//   - pools: Slice of Pool pointers to render as tabs
-//   - selected: Index of the currently selected pool
-//
 // Returns:
 //   - string: A formatted string containing the tab bar
 //
 // Example:
 //
 //	[ pool1 ]  pool2   pool3
-func renderTabs(pools []*zfs.Pool, selected int) string {
+func (pv *PoolView) renderTabs() string {
 	var tabs []string
-	for i, pool := range pools {
+	x := 0
+	for i, pool := range pv.pools {
 		tab := pool.Name
-		if i == selected {
-			tab = styles.TabActive.Render("[ " + tab + " ]")
+		if i == pv.selected {
+			tab = pv.styles.TabActive.Render("[ " + tab + " ]")
 		} else {
-			tab = styles.TabInactive.Render("  " + tab + "  ")
+			tab = pv.styles.TabInactive.Render("  " + tab + "  ")
 		}
 		tabs = append(tabs, tab)
+
+		width := lipgloss.Width(tab)
+		pv.regions = append(pv.regions, Region{Kind: RegionTab, Index: i, X: x, Width: width, Height: 1, Name: pool.Name})
+		x += width + 1
 	}
 	return strings.Join(tabs, " ")
 }
 
-// renderVDev creates a string representation of a VDev and its children.
-// It recursively renders the entire VDev tree with proper indentation and styling.
+// renderVDev creates a string representation of a VDev node and its children.
+// It recursively renders the entire VDev tree with proper indentation and
+// styling, highlighting the focused node. Collapsed nodes are drawn with
+// the number of hidden children instead of the children themselves.
+//
 // Parameters:
-//   - vdev: pointer to the VDev to render
-//   - depth: current depth in the VDev tree for indentation
-//   - analyzer: status analyzer for determining VDev health
-//
-// Returns a string containing the rendered VDev tree.
-func renderVDev(vdev *zfs.VDev, depth int, analyzer *status.Analyzer) string {
-	// Use the analyzer to get the worst status of the VDev
-	worstStatus := analyzer.GetVDevWorstStatus(vdev)
+//   - node: pointer to the VDev node to render
+//   - index: position of node among the visible nodes, advanced past the
+//     nodes rendered
+//
+// Returns a string containing the rendered VDev tree, and the hit regions
+// of the rendered nodes relative to its top left corner.
+func (pv *PoolView) renderVDev(node *VDevNode, index *int) (string, []Region) {
+	label := pv.nodeLabel(node)
+	name, truncated := pv.shorten(label)
+	if node == pv.Focused() {
+		name = pv.styles.Selected.Render(name)
+	}
 	content := fmt.Sprintf("%s %s %s [%s]",
-		styles.TreeBranch.Render(strings.Repeat("  ", depth)+"├─"),
-		vdev.Name,
-		styles.VDevType.Render("("+vdev.Type+")"),
-		renderStatus(worstStatus))
+		pv.styles.TreeBranch.Render(strings.Repeat("  ", node.Depth)+pv.styles.Glyphs.Branch),
+		name,
+		pv.styles.VDevType.Render("("+node.Type+")"),
+		pv.styles.RenderStatus(node.Status))
+	if pv.IsCollapsed(node) {
+		content += pv.styles.VDevType.Render(fmt.Sprintf(" [+%d]", len(node.Children)))
+	}
+	regions := []Region{{
+		Kind: RegionNode, Index: *index, Width: lipgloss.Width(content), Height: 1,
+		Name: label, Truncated: truncated,
+	}}
+	*index++
 
-	if len(vdev.Children) > 0 {
+	if len(node.Children) > 0 && !pv.IsCollapsed(node) {
 		childContent := ""
-		for _, child := range vdev.Children {
-			childContent += renderVDev(child, depth+1, analyzer)
+		for _, child := range node.Children {
+			rendered, childRegions := pv.renderVDev(child, index)
+			for _, r := range childRegions {
+				regions = append(regions, r.offset(0, 1+strings.Count(childContent, "\n")))
+			}
+			childContent += rendered
 		}
-		content = styles.GetStatusBorderStyle(worstStatus).Render(content + "\n" + childContent)
+
+		box := pv.styles.GetStatusBorderStyle(node.Status)
+		dx, dy := frameOffset(box)
+		for i := range regions {
+			regions[i] = regions[i].offset(dx, dy)
+		}
+		content = box.Render(content + "\n" + childContent)
 	}
 
-	return content + "\n"
+	return content + "\n", regions
+}
+
+// SetLabel sets the identity leaves are labelled by.
+//
+// Parameters:
+//   - kind: One of zfs.DiskLabels, e.g. "serial"
+func (pv *PoolView) SetLabel(kind string) {
+	pv.label = kind
 }
 
-// renderStatus converts a VDevStatus to a styled string representation.
-// It applies different colors and styles based on the status value.
+// SetAnalyzer replaces the analyzer judging the health of the pools, e.g.
+// to apply configured SMART limits. It takes effect with the next Update.
+//
 // Parameters:
-//   - status: the VDevStatus to render
-//
-// Returns a styled string representing the status.
-func renderStatus(status zfs.VDevStatus) string {
-	switch status {
-	case zfs.VDevStatusOnline:
-		return styles.StatusOnline.Render(string(status))
-	case zfs.VDevStatusDegraded:
-		return styles.StatusDegraded.Render(string(status))
-	case zfs.VDevStatusFaulted:
-		return styles.StatusFaulted.Render(string(status))
-	default:
-		return string(status)
+//   - analyzer: The analyzer to use
+func (pv *PoolView) SetAnalyzer(analyzer *status.Analyzer) {
+	pv.analyzer = analyzer
+}
+
+// Label returns the identity leaves are labelled by.
+func (pv *PoolView) Label() string {
+	return pv.label
+}
+
+// CycleLabel labels leaves by the next identity of zfs.DiskLabels, after
+// the last one by their name again.
+func (pv *PoolView) CycleLabel() {
+	next := zfs.DiskLabels[0]
+	for i, kind := range zfs.DiskLabels {
+		if kind == pv.label && i+1 < len(zfs.DiskLabels) {
+			next = zfs.DiskLabels[i+1]
+		}
 	}
+	pv.label = next
+}
+
+// nodeLabel returns the name a node is drawn with: the chosen identity of
+// its disk, or its VDev name if it has none.
+func (pv *PoolView) nodeLabel(node *VDevNode) string {
+	if node.Disk != nil {
+		if label := node.Disk.Label(pv.label); label != "" {
+			return label
+		}
+	}
+	return node.Name
+}
+
+// shorten truncates names wider than maxNameWidth.
+func (pv *PoolView) shorten(name string) (string, bool) {
+	return shorten(name, pv.styles.Glyphs.Ellipsis)
+}
+
+// shorten truncates a name wider than maxNameWidth, ending it in ellipsis.
+func shorten(name, ellipsis string) (string, bool) {
+	runes := []rune(name)
+	if len(runes) <= maxNameWidth {
+		return name, false
+	}
+	return string(runes[:maxNameWidth-len([]rune(ellipsis))]) + ellipsis, true
+}
+
+// frameOffset returns where the content of a box starts: the width of its
+// left border and padding, and the height of its top border and padding.
+func frameOffset(box lipgloss.Style) (int, int) {
+	return box.GetBorderLeftSize() + box.GetPaddingLeft() + box.GetMarginLeft(),
+		box.GetBorderTopSize() + box.GetPaddingTop() + box.GetMarginTop()
 }
@@ -0,0 +1,390 @@
+package views
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/config"
+	"github.com/petecog/vizfsulizer/internal/tui/styles"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+func newTestView(t *testing.T) *PoolView {
+	t.Helper()
+	pools, err := zfs.GetPools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pv := NewPoolView(styles.New(config.DisplayModeRGB, config.CharsetUnicode, styles.DefaultTheme()))
+	pv.Update(pools)
+	return pv
+}
+
+func TestPoolModelNodeOrder(t *testing.T) {
+	pv := newTestView(t)
+	pv.SetSelected(1) // fastpool
+
+	var got []string
+	for _, node := range pv.pools[1].Nodes() {
+		got = append(got, node.Name)
+	}
+	want := "fastpool sda1 sdb1 cache nvme0n1p1 log nvme1n1p1 nvme1n2p1"
+	if strings.Join(got, " ") != want {
+		t.Errorf("node order %v, want %s", got, want)
+	}
+	if roles := pv.pools[1].Roots; roles[0].Role != RoleData || roles[1].Role != RoleCache || roles[2].Role != RoleLog {
+		t.Errorf("unexpected roles %s %s %s", roles[0].Role, roles[1].Role, roles[2].Role)
+	}
+}
+
+func TestRenderLinear(t *testing.T) {
+	pv := newTestView(t)
+	pv.SetSelected(1)
+	out := pv.RenderLinear()
+
+	for _, want := range []string{
+		"Pool fastpool, 2 of 2, state faulted",
+		"Data vdev mirror, online, 2 children.",
+		"  Disk nvme0n1p1, online.",
+		"Log vdev mirror, faulted, 2 children.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("linear output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.ContainsAny(out, "│╭═") {
+		t.Errorf("linear output contains drawing characters:\n%s", out)
+	}
+}
+
+func TestFocusAnnouncements(t *testing.T) {
+	pv := newTestView(t) // testpool: testpool, sda, sdb
+
+	if pv.MoveFocus(-1) {
+		t.Error("focus moved above the first node")
+	}
+	if !pv.MoveFocus(1) {
+		t.Fatal("focus did not move down")
+	}
+	want := "Focus: disk sda, degraded, 3 read errors, 12 checksum errors. Item 2 of 3."
+	if got := pv.AnnounceFocus(); got != want {
+		t.Errorf("announcement %q, want %q", got, want)
+	}
+
+	pv.MoveFocus(5)
+	if got := pv.Focused().Name; got != "sdb" {
+		t.Errorf("focus stopped at %s, want the last node sdb", got)
+	}
+
+	pv.SetSelected(1)
+	if got := pv.Focused().Name; got != "fastpool" {
+		t.Errorf("focus after switching pools on %s, want the first node", got)
+	}
+}
+
+// ansiEscape matches the SGR sequences lipgloss emits.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// cellAt returns the text of a rendered line from cell x on.
+func cellAt(t *testing.T, out string, x, y int) string {
+	t.Helper()
+	lines := strings.Split(ansiEscape.ReplaceAllString(out, ""), "\n")
+	if y >= len(lines) {
+		t.Fatalf("line %d beyond output of %d lines", y, len(lines))
+	}
+	runes := []rune(lines[y])
+	if x >= len(runes) {
+		t.Fatalf("cell %d beyond line %q", x, lines[y])
+	}
+	return string(runes[x:])
+}
+
+func TestHitRegions(t *testing.T) {
+	pv := newTestView(t)
+	pv.SetSelected(1) // fastpool, with nested boxes for data, cache and log
+	out := pv.Render()
+
+	nodes := pv.visible()
+	var seen int
+	for _, r := range pv.regions {
+		switch r.Kind {
+		case RegionTab:
+			if text := cellAt(t, out, r.X, r.Y); !strings.Contains(text[:r.Width], r.Name) {
+				t.Errorf("tab region %+v covers %q", r, text)
+			}
+		case RegionNode:
+			seen++
+			want := styles.UnicodeGlyphs.Branch + " " + nodes[r.Index].Name + " ("
+			if text := cellAt(t, out, r.X, r.Y); !strings.HasPrefix(strings.TrimLeft(text, " "), want) {
+				t.Errorf("node region %d at %d,%d covers %q, want %q", r.Index, r.X, r.Y, text, want)
+			}
+		}
+	}
+	if seen != len(nodes) {
+		t.Errorf("%d node regions, want %d", seen, len(nodes))
+	}
+
+	r, ok := pv.HitTest(pv.regions[len(pv.regions)-1].X, pv.regions[len(pv.regions)-1].Y)
+	if !ok || r.Index != len(nodes)-1 {
+		t.Errorf("hit test of the last node returned %+v, %v", r, ok)
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	pv := newTestView(t)
+	pv.SetSelected(1)
+	all := len(pv.visible())
+
+	pv.SetFocus(5) // log mirror
+	if !pv.ToggleFocused() {
+		t.Fatal("log mirror did not collapse")
+	}
+	if got := len(pv.visible()); got != all-2 {
+		t.Errorf("%d visible nodes after collapsing, want %d", got, all-2)
+	}
+	if out := pv.Render(); !strings.Contains(out, "[+2]") || strings.Contains(out, "nvme1n1p1") {
+		t.Errorf("collapsed children still drawn:\n%s", out)
+	}
+	if !strings.Contains(pv.RenderLinear(), "Log vdev mirror, faulted, collapsed, 2 children.") {
+		t.Errorf("linear output does not mention the collapsed node:\n%s", pv.RenderLinear())
+	}
+
+	pools, _ := zfs.GetPools()
+	pv.Update(pools)
+	if got := len(pv.visible()); got != all-2 {
+		t.Errorf("collapsed state lost on refresh: %d visible nodes", got)
+	}
+}
+
+func TestPanes(t *testing.T) {
+	pv := newTestView(t)
+	pv.SetSelected(1)
+
+	lines := strings.Split(pv.RenderPoolList(40), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(ansiEscape.ReplaceAllString(lines[1], ""), "fastpool") {
+		t.Errorf("pool list %q, want one line per pool", lines)
+	}
+
+	details := ansiEscape.ReplaceAllString(pv.RenderDetails(), "")
+	if !strings.Contains(details, "Name:      fastpool") || !strings.Contains(details, "Role:      data") {
+		t.Errorf("details of the pool root:\n%s", details)
+	}
+
+	mv := NewMetricsView(pv.styles)
+	pools, _ := zfs.GetPools()
+	mv.Update(pools, &zfs.ARCStats{Size: 1 << 30, TargetSize: 2 << 30, MaxSize: 4 << 30, Hits: 9, Misses: 1})
+	mv.Update(pools, nil)
+	metrics := ansiEscape.ReplaceAllString(mv.Render("fastpool", 20), "")
+	if !strings.Contains(metrics, "ARC       unavailable") || len(mv.history["fastpool"]) != 2 {
+		t.Errorf("metrics after two refreshes:\n%s", metrics)
+	}
+}
+
+func TestDiskLabels(t *testing.T) {
+	pv := newTestView(t)
+	pv.SetFocus(1) // sda of testpool
+
+	details := ansiEscape.ReplaceAllString(pv.RenderDetails(), "")
+	for _, want := range []string{"Serial:    VCJ4A8KP", "Media:     HDD", "By-vdev:   A1", "WWN:       0x5000cca27ec1d2a1",
+		"Location:  enclosure 1, slot 0",
+		"SMART:     PASSED", "Temp:      41°C", "Power-on:  31234 h (3.6 years)"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("details missing %q:\n%s", want, details)
+		}
+	}
+
+	pv.SetLabel("serial")
+	tree := ansiEscape.ReplaceAllString(pv.Render(), "")
+	if !strings.Contains(tree, "VCJ4A8KP (disk)") || strings.Contains(tree, "sda (disk)") {
+		t.Errorf("leaves not labelled by serial:\n%s", tree)
+	}
+	if !strings.Contains(pv.RenderLinear(), "Disk VCJ4A8KP, degraded") {
+		t.Errorf("linear output not labelled by serial:\n%s", pv.RenderLinear())
+	}
+
+	// Leaves without the identity keep their name
+	pv.SetSelected(1)
+	if tree := ansiEscape.ReplaceAllString(pv.Render(), ""); !strings.Contains(tree, "sda1 (disk)") {
+		t.Errorf("unresolved leaf not labelled by name:\n%s", tree)
+	}
+
+	pv.CycleLabel()
+	pv.CycleLabel()
+	if pv.Label() != "name" {
+		t.Errorf("label after wwn = %q, want name", pv.Label())
+	}
+}
+
+func TestEnclosureView(t *testing.T) {
+	pools, err := zfs.GetPools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encs, err := zfs.GetEnclosures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := NewEnclosureView(styles.New(config.DisplayModeBW, config.CharsetUnicode, styles.DefaultTheme()), 6)
+	ev.Update(encs, pools)
+	ev.SetLabel("by-vdev")
+	ev.SetFocus("sdb")
+
+	grid := ansiEscape.ReplaceAllString(ev.Render(), "")
+	lines := strings.Split(grid, "\n")
+	if lines[0] != "Enclosure 1: LSI SAS2X28 (0:0:12:0), 12 slots" {
+		t.Errorf("heading = %q", lines[0])
+	}
+	// 12 slots of 6 per row are two rows of boxes
+	if got := strings.Count(grid, "╮") + strings.Count(grid, "┐"); got != 12 {
+		t.Errorf("%d slots drawn, want 12:\n%s", got, grid)
+	}
+	for _, want := range []string{"A1", "A2", "testpool", "DEGRADED !", "no pool", "empty", "┌"} {
+		if !strings.Contains(grid, want) {
+			t.Errorf("grid missing %q:\n%s", want, grid)
+		}
+	}
+
+	linear := ev.RenderLinear()
+	for _, want := range []string{"Slot 0: A1, testpool, degraded.", "Slot 1: A2, testpool, online, selected.",
+		"Slot 2: sdc, no pool.", "6 empty slots."} {
+		if !strings.Contains(linear, want) {
+			t.Errorf("linear output missing %q:\n%s", want, linear)
+		}
+	}
+
+	ev.Update(nil, pools)
+	if ev.Len() != 0 || !strings.Contains(ev.Render(), "No disk enclosures") {
+		t.Errorf("without enclosures: %q", ev.Render())
+	}
+}
+
+func TestLintView(t *testing.T) {
+	pool := &zfs.Pool{Name: "tank", Status: zfs.VDevStatusOnline, RootVDev: &zfs.VDev{
+		Name: "tank", Type: zfs.VDevTypeRoot, Status: zfs.VDevStatusOnline, Children: []*zfs.VDev{
+			{Name: "mirror-0", Type: "mirror", Status: zfs.VDevStatusOnline, Children: []*zfs.VDev{
+				{Name: "ata-A", Type: "disk", Status: zfs.VDevStatusOnline},
+				{Name: "ata-B", Type: "disk", Status: zfs.VDevStatusOnline},
+			}},
+			{Name: "ata-C", Type: "disk", Status: zfs.VDevStatusOnline},
+		},
+	}}
+	healthy := &zfs.Pool{Name: "backup", Status: zfs.VDevStatusOnline, RootVDev: &zfs.VDev{Name: "ata-D", Type: "disk"}}
+	lv := NewLintView(styles.New(config.DisplayModeBW, config.CharsetUnicode, styles.DefaultTheme()))
+	lv.Update([]*zfs.Pool{pool, healthy})
+
+	if got := lv.Summary(); got != "1 layout finding in 2 pools, 1 critical." {
+		t.Errorf("summary %q", got)
+	}
+	out := ansiEscape.ReplaceAllString(lv.Render(60), "")
+	for _, want := range []string{"tank: 1 finding", "critical  ata-C: single-disk vdev in a pool of redundant vdevs [single-disk]",
+		"          The pool is lost if this disk fails", "backup: no findings"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("findings missing %q:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n")[2:4] {
+		if len(strings.TrimRight(line, " ")) > 60 {
+			t.Errorf("explanation not wrapped: %q", line)
+		}
+	}
+	if linear := lv.RenderLinear(); !strings.Contains(linear, "Critical: ata-C: single-disk vdev in a pool of redundant vdevs. The pool is lost") {
+		t.Errorf("linear output:\n%s", linear)
+	}
+}
+
+func TestPlannerView(t *testing.T) {
+	pv := NewPlannerView(styles.New(config.DisplayModeBW, config.CharsetUnicode, styles.DefaultTheme()))
+	if got := pv.Title() + " " + pv.Summary(); got != "Capacity planner: new pool Usable 29.0T of 43.7T raw, 66%." {
+		t.Errorf("default plan %q", got)
+	}
+	out := ansiEscape.ReplaceAllString(pv.Render(), "")
+	for _, want := range []string{"Width         6 disks", "Disk size     8 TB (7.3T)", "Recordsize    128.0K",
+		"Parity         14.6T   33.3%", "Usable         29.0T   66.4%"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("planner missing %q:\n%s", want, out)
+		}
+	}
+
+	// raidz2 of 5 pads 128K blocks; a disk type drops the width to 1
+	pv.Move(2)
+	pv.Adjust(-1)
+	if got := pv.Focus(); got != "Width: 5 disks." {
+		t.Errorf("focus %q", got)
+	}
+	if linear := pv.RenderLinear(); !strings.Contains(linear, "Width: 5 disks, selected.") || !strings.Contains(linear, "Padding: 275.9G, 0.7% of raw.") {
+		t.Errorf("linear output:\n%s", linear)
+	}
+	pv.Move(-1)
+	for i := 0; i < 5; i++ {
+		pv.Adjust(-1)
+	}
+	if l := pv.Layout(); l.VDevs[0].Type != "disk" || l.VDevs[0].Width != 1 {
+		t.Errorf("type stepped to %+v", l.VDevs[0])
+	}
+	pv.Adjust(1)
+	if l := pv.Layout(); l.VDevs[0].Type != "mirror" || l.VDevs[0].Width != 2 {
+		t.Errorf("type stepped to %+v", l.VDevs[0])
+	}
+
+	// A second group of larger disks
+	pv.AddGroup()
+	pv.Move(3)
+	pv.Adjust(1)
+	if got := pv.Focus(); got != "Group 2 disk size: 10 TB (9.1T)." {
+		t.Errorf("focus %q", got)
+	}
+	if got := pv.Summary(); got != "Usable 16.2T of 32.7T raw, 50%." {
+		t.Errorf("summary %q", got)
+	}
+	if !pv.RemoveGroup() || pv.RemoveGroup() || len(pv.Layout().VDevs) != 1 {
+		t.Errorf("groups %+v", pv.Layout().VDevs)
+	}
+
+	pools, _ := zfs.GetPools()
+	pv.Load(pools[0])
+	if got := pv.Title() + " " + pv.Summary(); got != "Capacity planner: based on testpool Usable 9.9T of 20.0T raw, 49%." {
+		t.Errorf("testpool plan %q", got)
+	}
+	pv.Load(&zfs.Pool{Name: "wide", RootVDev: &zfs.VDev{Name: "draid2:4d:6c:0s-0", Type: "draid2", Children: []*zfs.VDev{{Name: "sda", Size: 1}}}})
+	if !strings.Contains(pv.Render(), "Cannot plan from the selected pool") || pv.Title() != "Capacity planner: new pool" {
+		t.Errorf("dRAID pool planned:\n%s", pv.Render())
+	}
+}
+
+func TestEventsView(t *testing.T) {
+	ev := NewEventsView(styles.New(config.DisplayModeBW, config.CharsetUnicode, styles.DefaultTheme()))
+	add := func(eid uint64, class, pool string) {
+		ev.Add(&zfs.Event{EID: eid, Class: "ereport.fs.zfs." + class, Pool: pool, Time: time.Unix(int64(eid), 0)})
+	}
+	add(1, "checksum", "tank")
+	add(2, "io", "backup")
+	if !ev.MoveCursor(1) || ev.Selected().EID != 1 {
+		t.Fatalf("selected %+v", ev.Selected())
+	}
+	// The cursor stays on its event as newer ones arrive on top
+	add(3, "checksum", "tank")
+	if ev.Selected().EID != 1 {
+		t.Errorf("selected %d after an event arrived", ev.Selected().EID)
+	}
+
+	ev.SetPool("backup")
+	ev.TogglePool()
+	if shown := ev.Shown(); len(shown) != 1 || shown[0].EID != 2 {
+		t.Errorf("events of backup %+v", shown)
+	}
+	ev.TogglePool()
+	ev.CycleClass()
+	ev.CycleClass()
+	if got := ev.Filter(); got != "io events of all pools" {
+		t.Errorf("filter %q", got)
+	}
+	ev.CycleClass()
+	if lines := strings.Split(ev.Render(3), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "All events of all pools (3)") {
+		t.Errorf("rendered %q", lines)
+	}
+	if idx, ok := ev.EventAt(2); !ok || idx != 1 {
+		t.Errorf("EventAt(2) = %d, %v", idx, ok)
+	}
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "strconv"
+
+// FormatBytes formats a byte count using binary units the way the zfs and
+// zpool commands do, e.g. "512B", "1.5K", "10.0T".
+//
+// Parameters:
+//   - n: The number of bytes
+//
+// Returns:
+//   - string: The human readable size
+func FormatBytes(n uint64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return strconv.FormatUint(n, 10) + "B"
+	}
+	v := float64(n)
+	i := -1
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + string(units[i])
+}
@@ -0,0 +1,91 @@
+// Package utils contains small helpers shared across the application,
+// such as parsers for user-supplied values on the command line.
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsePercent parses a percentage such as "80" or "80%".
+// The value must lie between 0 and 100 inclusive.
+//
+// Parameters:
+//   - s: The string to parse
+//
+// Returns:
+//   - float64: The parsed percentage
+//   - error: Error if the value is not a number or is out of range
+func ParsePercent(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	if v < 0 || v > 100 {
+		return 0, fmt.Errorf("percentage %q out of range 0-100", s)
+	}
+	return v, nil
+}
+
+// ParseCount parses a non-negative integer count such as an error counter.
+//
+// Parameters:
+//   - s: The string to parse
+//
+// Returns:
+//   - uint64: The parsed count
+//   - error: Error if the value is not a non-negative integer
+func ParseCount(s string) (uint64, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+	return v, nil
+}
+
+// ParseDuration parses a duration like time.ParseDuration, additionally
+// accepting whole days ("14d") and weeks ("2w") which are the natural
+// units for scrub schedules.
+//
+// Parameters:
+//   - s: The string to parse
+//
+// Returns:
+//   - time.Duration: The parsed duration
+//   - error: Error if the value cannot be parsed or is negative
+//
+// Example:
+//
+//	d, _ := ParseDuration("5w") // 35 days
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+
+	var d time.Duration
+	if unit != 0 {
+		n, err := strconv.ParseUint(s[:len(s)-1], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("duration %q must not be negative", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,174 @@
+package web
+
+import (
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/zfs"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+// dashboard is the JSON document rendered by the browser. Health is computed
+// here with the same analyzer as the TUI so both front ends always agree.
+type dashboard struct {
+	CollectedAt time.Time     `json:"collectedAt"`
+	Health      healthSummary `json:"health"`
+	Pools       []poolView    `json:"pools"`
+	Datasets    []datasetView `json:"datasets"`
+}
+
+// healthSummary counts pools by their worst status.
+type healthSummary struct {
+	Worst    zfs.VDevStatus `json:"worst"`
+	Pools    int            `json:"pools"`
+	Online   int            `json:"online"`
+	Degraded int            `json:"degraded"`
+	Faulted  int            `json:"faulted"`
+}
+
+type poolView struct {
+	Name          string         `json:"name"`
+	Status        zfs.VDevStatus `json:"status"`
+	Size          uint64         `json:"size"`
+	Allocated     uint64         `json:"allocated"`
+	Free          uint64         `json:"free"`
+	Capacity      float64        `json:"capacity"`
+	Fragmentation int            `json:"fragmentation"`
+	Errors        uint64         `json:"errors"`
+	Scan          *scanView      `json:"scan,omitempty"`
+	VDevs         []vdevView     `json:"vdevs"`
+}
+
+type scanView struct {
+	Function string    `json:"function"`
+	State    string    `json:"state"`
+	End      time.Time `json:"end,omitempty"`
+	Progress float64   `json:"progress"`
+}
+
+type vdevView struct {
+	Name           string         `json:"name"`
+	Type           string         `json:"type"`
+	Role           string         `json:"role,omitempty"`
+	Status         zfs.VDevStatus `json:"status"`
+	ReadErrors     uint64         `json:"readErrors"`
+	WriteErrors    uint64         `json:"writeErrors"`
+	ChecksumErrors uint64         `json:"checksumErrors"`
+	Children       []vdevView     `json:"children,omitempty"`
+}
+
+type datasetView struct {
+	Name          string        `json:"name"`
+	Type          string        `json:"type"`
+	Used          uint64        `json:"used"`
+	Available     uint64        `json:"available"`
+	Referenced    uint64        `json:"referenced"`
+	Quota         uint64        `json:"quota,omitempty"`
+	Mountpoint    string        `json:"mountpoint,omitempty"`
+	Compression   string        `json:"compression,omitempty"`
+	CompressRatio float64       `json:"compressRatio,omitempty"`
+	Snapshots     int           `json:"snapshots"`
+	Children      []datasetView `json:"children,omitempty"`
+}
+
+// newDashboard converts a snapshot into the dashboard document.
+func newDashboard(snap *source.Snapshot, analyzer *status.Analyzer) dashboard {
+	d := dashboard{
+		CollectedAt: snap.CollectedAt,
+		Health:      healthSummary{Worst: zfs.VDevStatusOnline, Pools: len(snap.Pools)},
+		Pools:       []poolView{},
+		Datasets:    []datasetView{},
+	}
+
+	for _, pool := range snap.Pools {
+		pv := newPoolView(pool, analyzer)
+		switch pv.Status {
+		case zfs.VDevStatusOnline:
+			d.Health.Online++
+		case zfs.VDevStatusDegraded:
+			d.Health.Degraded++
+		case zfs.VDevStatusFaulted:
+			d.Health.Faulted++
+		}
+		d.Health.Worst = analyzer.GetWorseStatus(d.Health.Worst, pv.Status)
+		d.Pools = append(d.Pools, pv)
+	}
+
+	for _, ds := range snap.Datasets {
+		d.Datasets = append(d.Datasets, newDatasetView(ds))
+	}
+	return d
+}
+
+// newPoolView converts a pool, computing its worst status and error total.
+func newPoolView(pool *zfs.Pool, analyzer *status.Analyzer) poolView {
+	pv := poolView{
+		Name:          pool.Name,
+		Status:        analyzer.GetPoolWorstStatus(pool),
+		Size:          pool.Size,
+		Allocated:     pool.Allocated,
+		Free:          pool.Free,
+		Capacity:      pool.CapacityPercent(),
+		Fragmentation: pool.Fragmentation,
+		Errors:        analyzer.GetPoolErrorCount(pool),
+		VDevs:         []vdevView{},
+	}
+	if scan := pool.Scan; scan != nil {
+		pv.Scan = &scanView{Function: scan.Function, State: scan.State, End: scan.End}
+		if scan.ToExamine > 0 {
+			pv.Scan.Progress = float64(scan.Examined) / float64(scan.ToExamine) * 100
+		}
+	}
+
+	for _, root := range []struct {
+		role string
+		vdev *zfs.VDev
+	}{{"", pool.RootVDev}, {"cache", pool.Cache}, {"log", pool.Slog}} {
+		if root.vdev != nil {
+			vv := newVDevView(root.vdev, analyzer)
+			vv.Role = root.role
+			pv.VDevs = append(pv.VDevs, vv)
+		}
+	}
+	return pv
+}
+
+// newVDevView converts a VDev tree. Status is the worst status of the
+// subtree, as shown by the TUI.
+func newVDevView(vdev *zfs.VDev, analyzer *status.Analyzer) vdevView {
+	vv := vdevView{
+		Name:           vdev.Name,
+		Type:           vdev.Type,
+		Status:         analyzer.GetVDevWorstStatus(vdev),
+		ReadErrors:     vdev.ReadErrors,
+		WriteErrors:    vdev.WriteErrors,
+		ChecksumErrors: vdev.ChecksumErrors,
+	}
+	for _, child := range vdev.Children {
+		vv.Children = append(vv.Children, newVDevView(child, analyzer))
+	}
+	return vv
+}
+
+// newDatasetView converts a dataset tree.
+func newDatasetView(ds *zfs.Dataset) datasetView {
+	dv := datasetView{
+		Name:          ds.Name,
+		Type:          ds.Type,
+		Used:          ds.Used,
+		Available:     ds.Available,
+		Referenced:    ds.Referenced,
+		Quota:         ds.Quota,
+		Mountpoint:    ds.Mountpoint,
+		Compression:   ds.Compression,
+		CompressRatio: ds.CompressRatio,
+		Snapshots:     ds.Snapshots,
+	}
+	if dv.Type == "" {
+		dv.Type = "filesystem"
+	}
+	for _, child := range ds.Children {
+		dv.Children = append(dv.Children, newDatasetView(child))
+	}
+	return dv
+}
@@ -0,0 +1,130 @@
+// Package web serves a read-only HTTP dashboard showing the same pool
+// topology, dataset tree and health summary as the TUI. All assets are
+// embedded in the binary; updates are pushed with server-sent events.
+package web
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Server is the dashboard HTTP handler.
+type Server struct {
+	src      source.Source
+	interval time.Duration
+	analyzer *status.Analyzer
+	mux      *http.ServeMux
+}
+
+// NewServer creates a dashboard server.
+//
+// Parameters:
+//   - src: The source to read state from; wrap it in source.Cached so that
+//     many connected browsers share collections
+//   - interval: How often connected browsers are sent fresh state
+//   - analyzer: Judges the health of the pools
+//
+// Returns:
+//   - *Server: A handler ready to be passed to http.Server
+func NewServer(src source.Source, interval time.Duration, analyzer *status.Analyzer) *Server {
+	s := &Server{
+		src:      src,
+		interval: interval,
+		analyzer: analyzer,
+		mux:      http.NewServeMux(),
+	}
+
+	static, _ := fs.Sub(staticFiles, "static")
+	s.mux.Handle("/", http.FileServer(http.FS(static)))
+	s.mux.HandleFunc("/api/dashboard", s.handleDashboard)
+	s.mux.HandleFunc("/api/events", s.handleEvents)
+	return s
+}
+
+// ServeHTTP implements http.Handler. Only GET and HEAD requests are allowed
+// since the dashboard is read-only.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleDashboard serves the current dashboard document as JSON.
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	data, err := s.collect(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// handleEvents streams dashboard documents as server-sent events. A
+// "dashboard" event is sent immediately and then whenever a new snapshot has
+// been collected; collection failures are sent as "failure" events.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		data, err := s.collect(r)
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "event: failure\ndata: %s\n\n", jsonString(err.Error()))
+			last = nil
+		case !bytes.Equal(data, last):
+			fmt.Fprintf(w, "event: dashboard\ndata: %s\n\n", data)
+			last = data
+		default:
+			// Comment line keeps idle connections alive through proxies
+			fmt.Fprint(w, ": unchanged\n\n")
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect gathers a snapshot and encodes it as a dashboard document.
+func (s *Server) collect(r *http.Request) ([]byte, error) {
+	snap, err := s.src.Collect(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(newDashboard(snap, s.analyzer))
+}
+
+// jsonString encodes s as a JSON string.
+func jsonString(s string) []byte {
+	data, _ := json.Marshal(s)
+	return data
+}
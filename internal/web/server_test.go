@@ -0,0 +1,94 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/source"
+	"github.com/petecog/vizfsulizer/internal/zfs/status"
+)
+
+func TestStaticAssets(t *testing.T) {
+	server := httptest.NewServer(NewServer(source.Mock{}, time.Second, &status.Analyzer{}))
+	defer server.Close()
+
+	for path, want := range map[string]string{
+		"/":          "<title>viZFSulizer</title>",
+		"/app.js":    "EventSource",
+		"/style.css": "--online",
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		bufio.NewReader(resp.Body).WriteTo(&sb)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(sb.String(), want) {
+			t.Errorf("GET %s = %d, missing %q", path, resp.StatusCode, want)
+		}
+		if strings.Contains(sb.String(), "https://") {
+			t.Errorf("GET %s references external resources", path)
+		}
+	}
+}
+
+func TestDashboardMatchesAnalyzer(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(source.Mock{}, time.Second, &status.Analyzer{}).ServeHTTP(rec, httptest.NewRequest("GET", "/api/dashboard", nil))
+
+	var d dashboard
+	if err := json.NewDecoder(rec.Body).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+	// The mock data has a degraded disk in testpool and a faulted log in fastpool
+	if d.Health.Worst != "FAULTED" || d.Health.Degraded != 1 || d.Health.Faulted != 1 {
+		t.Errorf("health = %+v, want one degraded and one faulted pool", d.Health)
+	}
+	if len(d.Pools) != 2 || d.Pools[1].VDevs[2].Role != "log" {
+		t.Errorf("unexpected topology: %+v", d.Pools)
+	}
+	if len(d.Datasets) == 0 || len(d.Datasets[0].Children) == 0 {
+		t.Errorf("dataset tree missing: %+v", d.Datasets)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(source.Mock{}, time.Second, &status.Analyzer{}).ServeHTTP(rec, httptest.NewRequest("POST", "/api/dashboard", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestEventsSendsInitialDashboard(t *testing.T) {
+	server := httptest.NewServer(NewServer(source.Mock{}, time.Hour, &status.Analyzer{}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() || scanner.Text() != "event: dashboard" {
+		t.Fatalf("first line = %q, want dashboard event", scanner.Text())
+	}
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), `data: {"collectedAt"`) {
+		t.Fatalf("second line = %q, want dashboard data", scanner.Text())
+	}
+}
@@ -0,0 +1,120 @@
+// viZFSulizer read-only dashboard. Receives dashboard documents over
+// server-sent events and renders them without any external libraries.
+"use strict";
+
+let state = null;
+let selected = 0;
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (key === "class") node.className = value;
+    else node.setAttribute(key, value);
+  }
+  for (const child of children) {
+    if (child !== null && child !== undefined) {
+      node.append(child instanceof Node ? child : String(child));
+    }
+  }
+  return node;
+}
+
+function formatBytes(n) {
+  const units = ["B", "K", "M", "G", "T", "P", "E"];
+  let i = 0;
+  while (n >= 1024 && i < units.length - 1) { n /= 1024; i++; }
+  return (i === 0 ? n : n.toFixed(1)) + units[i];
+}
+
+function statusSpan(status) {
+  return el("span", { class: "status-" + status }, status);
+}
+
+function renderSummary(health) {
+  const summary = document.getElementById("summary");
+  summary.replaceChildren(
+    statusSpan(health.worst), " ",
+    `${health.pools} pools: ${health.online} online, ${health.degraded} degraded, ${health.faulted} faulted`);
+}
+
+function renderTabs(pools) {
+  const tabs = document.getElementById("tabs");
+  tabs.replaceChildren(...pools.map((pool, i) => {
+    const button = el("button", { role: "tab", "aria-selected": String(i === selected) },
+      pool.name + " ", statusSpan(pool.status));
+    button.addEventListener("click", () => { selected = i; render(); });
+    return button;
+  }));
+}
+
+function renderVDev(vdev) {
+  const errors = vdev.readErrors + vdev.writeErrors + vdev.checksumErrors;
+  const label = el("div", {},
+    "├─ ", el("span", { class: "vdev-name" }, vdev.name), " ",
+    el("span", { class: "vdev-type" }, "(" + (vdev.role || vdev.type) + ")"), " [", statusSpan(vdev.status), "]",
+    errors > 0 ? el("span", { class: "errors" },
+      ` R:${vdev.readErrors} W:${vdev.writeErrors} C:${vdev.checksumErrors}`) : null);
+  if (!vdev.children || vdev.children.length === 0) {
+    return label;
+  }
+  return el("div", { class: "box status-" + vdev.status }, label, ...vdev.children.map(renderVDev));
+}
+
+function renderPool(pool) {
+  const section = document.getElementById("pool");
+  if (!pool) {
+    section.replaceChildren("No pools found");
+    return;
+  }
+
+  let scan = "never scanned";
+  if (pool.scan) {
+    scan = pool.scan.state === "scanning"
+      ? `${pool.scan.function} in progress (${pool.scan.progress.toFixed(1)}%)`
+      : `last ${pool.scan.function} ${pool.scan.state} ${new Date(pool.scan.end).toLocaleString()}`;
+  }
+
+  section.replaceChildren(el("div", { class: "box status-" + pool.status },
+    el("div", {}, "Pool: ", el("span", { class: "pool-name" }, pool.name), " [", statusSpan(pool.status), "]"),
+    el("div", { class: "facts" },
+      el("span", { class: "bar", title: pool.capacity.toFixed(1) + "%" },
+        el("span", { style: `width: ${Math.min(pool.capacity, 100)}%` })),
+      ` ${formatBytes(pool.allocated)} / ${formatBytes(pool.size)} (${pool.capacity.toFixed(1)}%)`,
+      ` • frag ${pool.fragmentation}% • errors ${pool.errors} • ${scan}`),
+    ...pool.vdevs.map(renderVDev)));
+}
+
+function datasetRows(ds, depth) {
+  const name = "  ".repeat(depth) + (depth > 0 ? "└ " : "") + ds.name.split("/").pop();
+  const row = el("tr", {},
+    el("td", { title: ds.name }, name), el("td", {}, ds.type),
+    el("td", {}, formatBytes(ds.used)), el("td", {}, formatBytes(ds.available)),
+    el("td", {}, formatBytes(ds.referenced)), el("td", {}, ds.quota ? formatBytes(ds.quota) : "-"),
+    el("td", {}, ds.compression ? `${ds.compression} ${ds.compressRatio.toFixed(2)}x` : "off"),
+    el("td", {}, ds.snapshots));
+  return [row, ...(ds.children || []).flatMap((child) => datasetRows(child, depth + 1))];
+}
+
+function render() {
+  if (!state) return;
+  if (selected >= state.pools.length) selected = 0;
+  renderSummary(state.health);
+  renderTabs(state.pools);
+  renderPool(state.pools[selected]);
+  document.querySelector("#datasets tbody").replaceChildren(
+    ...state.datasets.flatMap((ds) => datasetRows(ds, 0)));
+  document.getElementById("updated").textContent =
+    "Updated " + new Date(state.collectedAt).toLocaleTimeString();
+}
+
+const events = new EventSource("api/events");
+events.addEventListener("dashboard", (e) => {
+  state = JSON.parse(e.data);
+  render();
+});
+events.addEventListener("failure", (e) => {
+  document.getElementById("updated").textContent = "Collection failed: " + JSON.parse(e.data);
+});
+events.onerror = () => {
+  document.getElementById("updated").textContent = "Disconnected, retrying…";
+};
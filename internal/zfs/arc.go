@@ -0,0 +1,17 @@
+package zfs
+
+// GetARCStats returns the current ARC statistics of the host.
+// Like GetPools it currently provides mock data for development.
+//
+// Returns:
+//   - *ARCStats: The ARC size and hit/miss counters
+//   - error: Error if the statistics cannot be read (currently always nil)
+func GetARCStats() (*ARCStats, error) {
+	return &ARCStats{
+		Size:       12 << 30, // 12 GiB
+		TargetSize: 14 << 30,
+		MaxSize:    16 << 30,
+		Hits:       98_213_554,
+		Misses:     4_311_028,
+	}, nil
+}
@@ -0,0 +1,338 @@
+package zfs
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/utils"
+)
+
+// Defaults of a PoolLayout, as zpool create and zfs create choose them for
+// current disks.
+const (
+	DefaultAshift     = 12
+	DefaultRecordSize = 128 << 10
+)
+
+// Bounds of a PoolLayout, as ZFS accepts them.
+const (
+	MinAshift     = 9
+	MaxAshift     = 16
+	MinRecordSize = 512
+	MaxRecordSize = 16 << 20
+)
+
+// Slop space bounds: ZFS keeps 1/32 of a pool's space back for its own
+// operations, but at least 128 MiB (or half of a tiny pool) and at most
+// 128 GiB.
+const (
+	minSlop   = 128 << 20
+	maxSlop   = 128 << 30
+	slopShift = 5
+)
+
+// VDevLayoutTypes are the types of top-level VDevs capacity can be
+// estimated for, from the least to the most redundant.
+var VDevLayoutTypes = []string{"disk", "mirror", "raidz1", "raidz2", "raidz3"}
+
+// VDevLayout describes identical top-level VDevs of a real or planned pool,
+// e.g. two 6-wide raidz2 VDevs of 8 TB disks.
+type VDevLayout struct {
+	// Count is the number of VDevs
+	Count int
+
+	// Type is one of VDevLayoutTypes
+	Type string
+
+	// Width is the number of disks in each VDev, 1 for "disk"
+	Width int
+
+	// DiskSize is the size of each disk in bytes; of mixed disks, ZFS
+	// only uses the size of the smallest
+	DiskSize uint64
+}
+
+// Parity returns the number of disks' worth of parity of a raidz VDev, 0
+// for other types.
+func (l VDevLayout) Parity() int {
+	switch l.Type {
+	case "raidz1":
+		return 1
+	case "raidz2":
+		return 2
+	case "raidz3":
+		return 3
+	}
+	return 0
+}
+
+// MinWidth returns the smallest width a VDev of the layout's type can be
+// created with: 1 for a disk, 2 for a mirror and one disk more than its
+// parity for raidz.
+func (l VDevLayout) MinWidth() int {
+	switch l.Type {
+	case "disk":
+		return 1
+	case "mirror":
+		return 2
+	}
+	return l.Parity() + 1
+}
+
+// String describes the layout, e.g. "2 x raidz2 6-wide of 8.0T disks".
+func (l VDevLayout) String() string {
+	if l.Type == "disk" {
+		return fmt.Sprintf("%d x disk of %s", l.Count, utils.FormatBytes(l.DiskSize))
+	}
+	return fmt.Sprintf("%d x %s %d-wide of %s disks", l.Count, l.Type, l.Width, utils.FormatBytes(l.DiskSize))
+}
+
+// PoolLayout describes the data VDevs of a real or planned pool and the
+// block sizes its space efficiency depends on.
+type PoolLayout struct {
+	// VDevs are the top-level data VDevs; special, dedup, log and cache
+	// devices do not add to the space of a pool and are left out
+	VDevs []VDevLayout
+
+	// Ashift is the base 2 logarithm of the sector size, e.g. 12 for 4 KiB
+	Ashift int
+
+	// RecordSize is the size of the blocks written, in bytes; raidz
+	// wastes more space on small blocks
+	RecordSize uint64
+}
+
+// Validate checks that the layout could be created by zpool create.
+//
+// Returns:
+//   - error: The first invalid value, e.g. "vdevs[0]: raidz2 needs at
+//     least 3 disks", or nil
+func (l PoolLayout) Validate() error {
+	if len(l.VDevs) == 0 {
+		return fmt.Errorf("vdevs: at least one vdev is required")
+	}
+	for i, v := range l.VDevs {
+		switch {
+		case v.Count < 1:
+			return fmt.Errorf("vdevs[%d]: count must be positive", i)
+		case !isLayoutType(v.Type):
+			return fmt.Errorf("vdevs[%d]: unsupported type %q, want one of %s", i, v.Type, strings.Join(VDevLayoutTypes, ", "))
+		case v.Type == "disk" && v.Width != 1:
+			return fmt.Errorf("vdevs[%d]: a disk vdev has a width of 1", i)
+		case v.Width < v.MinWidth():
+			return fmt.Errorf("vdevs[%d]: %s needs at least %d disks", i, v.Type, v.MinWidth())
+		case v.DiskSize == 0:
+			return fmt.Errorf("vdevs[%d]: disk size must be positive", i)
+		}
+	}
+	if l.Ashift < MinAshift || l.Ashift > MaxAshift {
+		return fmt.Errorf("ashift: must be between %d and %d", MinAshift, MaxAshift)
+	}
+	if l.RecordSize < MinRecordSize || l.RecordSize > MaxRecordSize || l.RecordSize&(l.RecordSize-1) != 0 {
+		return fmt.Errorf("recordsize: must be a power of two between %s and %s", utils.FormatBytes(MinRecordSize), utils.FormatBytes(MaxRecordSize))
+	}
+	return nil
+}
+
+// isLayoutType reports whether capacity can be estimated for a VDev type.
+func isLayoutType(t string) bool {
+	for _, known := range VDevLayoutTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Capacity is the estimated space of a pool, broken down into where the
+// raw space of its disks goes. Raw is the sum of the others.
+type Capacity struct {
+	// Raw is the size of all data disks together
+	Raw uint64
+
+	// Parity is the space taken by redundancy: the extra copies of a
+	// mirror and the parity disks of raidz
+	Parity uint64
+
+	// Padding is the space raidz loses beyond its parity disks when a
+	// block does not fill whole rows of sectors: the parity of partial
+	// rows and the skip sectors allocations are padded with
+	Padding uint64
+
+	// Slop is the space ZFS keeps back for its own operations, which
+	// datasets cannot use
+	Slop uint64
+
+	// Usable is the space left for data, as zfs list reports it for an
+	// empty pool
+	Usable uint64
+}
+
+// Share returns part of the raw space in percent, e.g. c.Share(c.Usable)
+// for the space efficiency of the pool.
+func (c Capacity) Share(part uint64) float64 {
+	if c.Raw == 0 {
+		return 0
+	}
+	return float64(part) / float64(c.Raw) * 100
+}
+
+// EstimateCapacity computes how much of the raw space of a pool is usable,
+// assuming incompressible blocks of the layout's RecordSize. For raidz,
+// each block takes its data sectors, a parity sector per parity disk for
+// every row of data sectors, and padding up to a multiple of parity+1
+// sectors; the space this loses beyond the parity disks is Padding.
+// The few MiB ZFS reserves on each disk for its labels are not counted.
+//
+// Parameters:
+//   - layout: The pool layout, which must be valid
+//
+// Returns:
+//   - Capacity: Where the raw space goes
+//   - error: Error if the layout is invalid
+//
+// Example:
+//
+//	c, err := EstimateCapacity(PoolLayout{
+//	    VDevs:      []VDevLayout{{Count: 1, Type: "raidz2", Width: 6, DiskSize: 8e12}},
+//	    Ashift:     12,
+//	    RecordSize: 128 << 10,
+//	})
+//	if err == nil {
+//	    fmt.Printf("%s usable (%.0f%%)\n", utils.FormatBytes(c.Usable), c.Share(c.Usable))
+//	}
+func EstimateCapacity(layout PoolLayout) (Capacity, error) {
+	if err := layout.Validate(); err != nil {
+		return Capacity{}, err
+	}
+	var c Capacity
+	var data uint64
+	for _, v := range layout.VDevs {
+		raw := uint64(v.Count) * uint64(v.Width) * v.DiskSize
+		var parity, vdata uint64
+		switch v.Type {
+		case "disk":
+			vdata = raw
+		case "mirror":
+			vdata = uint64(v.Count) * v.DiskSize
+			parity = raw - vdata
+		default:
+			p := uint64(v.Parity())
+			parity = mulDiv(raw, p, uint64(v.Width))
+			sectors, allocated := raidzSectors(layout.RecordSize, layout.Ashift, v.Width, v.Parity())
+			vdata = min(mulDiv(raw, sectors, allocated), raw-parity)
+		}
+		c.Raw += raw
+		c.Parity += parity
+		c.Padding += raw - parity - vdata
+		data += vdata
+	}
+	c.Slop = slop(data)
+	c.Usable = data - c.Slop
+	return c, nil
+}
+
+// raidzSectors returns the data sectors of a block on raidz and the
+// sectors allocated for it, including parity and padding.
+func raidzSectors(recordSize uint64, ashift, width, parity int) (data, allocated uint64) {
+	sector := uint64(1) << ashift
+	data = (recordSize + sector - 1) / sector
+	rows := (data + uint64(width-parity) - 1) / uint64(width-parity)
+	allocated = data + uint64(parity)*rows
+	// Allocations are padded to a multiple of parity+1 sectors, so that
+	// no free gap is too small to be used
+	if rem := allocated % uint64(parity+1); rem != 0 {
+		allocated += uint64(parity+1) - rem
+	}
+	return data, allocated
+}
+
+// slop returns the slop space ZFS reserves in a pool with the given space
+// for data.
+func slop(data uint64) uint64 {
+	return min(max(data>>slopShift, min(data>>1, minSlop)), maxSlop)
+}
+
+// mulDiv returns a*b/c without overflowing for results that fit in 64 bits.
+func mulDiv(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c {
+		return ^uint64(0)
+	}
+	q, _ := bits.Div64(hi, lo, c)
+	return q
+}
+
+// LayoutOf describes the data VDevs of a real pool, grouping consecutive
+// identical VDevs, so that its capacity can be estimated or planned from.
+// The disk size of a VDev is that of its smallest disk; if no disk size is
+// known, it is derived from the size of the VDev.
+//
+// Parameters:
+//   - pool: The pool to describe
+//
+// Returns:
+//   - PoolLayout: The layout with the pool's ashift, or DefaultAshift if
+//     unknown, and DefaultRecordSize
+//   - error: Error if the pool has VDevs of a type capacity cannot be
+//     estimated for, such as dRAID
+func LayoutOf(pool *Pool) (PoolLayout, error) {
+	layout := PoolLayout{Ashift: DefaultAshift, RecordSize: DefaultRecordSize}
+	ashiftSet := false
+	for _, vdev := range TopLevel(pool.RootVDev) {
+		if vdev.Type == "special" || vdev.Type == "dedup" {
+			continue
+		}
+		v, err := vdevLayout(vdev)
+		if err != nil {
+			return PoolLayout{}, err
+		}
+		if vdev.Ashift != 0 && !ashiftSet {
+			layout.Ashift, ashiftSet = vdev.Ashift, true
+		}
+		if n := len(layout.VDevs); n > 0 && layout.VDevs[n-1].Type == v.Type &&
+			layout.VDevs[n-1].Width == v.Width && layout.VDevs[n-1].DiskSize == v.DiskSize {
+			layout.VDevs[n-1].Count++
+			continue
+		}
+		layout.VDevs = append(layout.VDevs, v)
+	}
+	if len(layout.VDevs) == 0 {
+		return PoolLayout{}, fmt.Errorf("pool %s has no data vdevs", pool.Name)
+	}
+	return layout, nil
+}
+
+// vdevLayout describes a single top-level VDev.
+func vdevLayout(vdev *VDev) (VDevLayout, error) {
+	v := VDevLayout{Count: 1, Type: vdev.Type, Width: len(vdev.Children)}
+	switch {
+	case len(vdev.Children) == 0:
+		v.Type, v.Width = "disk", 1
+	case vdev.Type == "raidz":
+		v.Type = "raidz1"
+	case !isLayoutType(vdev.Type):
+		return VDevLayout{}, fmt.Errorf("vdev %s: unsupported type %q", vdev.Name, vdev.Type)
+	}
+	for _, child := range vdev.Children {
+		size := child.Size
+		if size == 0 && child.Disk != nil {
+			size = child.Disk.Size
+		}
+		if size != 0 && (v.DiskSize == 0 || size < v.DiskSize) {
+			v.DiskSize = size
+		}
+	}
+	if v.DiskSize == 0 {
+		// The size of a mirror is that of a disk; that of raidz is raw
+		v.DiskSize = vdev.Size
+		if v.Parity() > 0 {
+			v.DiskSize /= uint64(v.Width)
+		}
+	}
+	if v.DiskSize == 0 {
+		return VDevLayout{}, fmt.Errorf("vdev %s: disk size unknown", vdev.Name)
+	}
+	return v, nil
+}
@@ -0,0 +1,71 @@
+package zfs
+
+// GetDatasets returns the dataset hierarchy of every pool.
+// Like GetPools it currently provides mock data for development.
+//
+// Returns:
+//   - []*Dataset: The root dataset of each pool, with nested children
+//   - error: Error if dataset data cannot be retrieved (currently always nil)
+func GetDatasets() ([]*Dataset, error) {
+	return []*Dataset{
+		{
+			Name:       "testpool",
+			Used:       7 << 40,
+			Available:  3 << 40,
+			Referenced: 96 << 10,
+			Mountpoint: "/testpool",
+			Children: []*Dataset{
+				{
+					// Compressed dataset with snapshots
+					Name:          "testpool/dataset1",
+					Used:          5 << 40,
+					Available:     3 << 40,
+					Referenced:    4 << 40,
+					Mountpoint:    "/testpool/dataset1",
+					Compression:   "lz4",
+					CompressRatio: 1.42,
+					Snapshots:     2,
+					SnapshotsUsed: 600 << 30,
+					Children: []*Dataset{
+						{
+							Name:          "testpool/dataset1/nested1",
+							Used:          400 << 30,
+							Available:     3 << 40,
+							Referenced:    400 << 30,
+							Mountpoint:    "/testpool/dataset1/nested1",
+							Compression:   "lz4",
+							CompressRatio: 1.08,
+						},
+					},
+				},
+				{
+					// Dataset limited by a quota
+					Name:       "testpool/dataset2",
+					Used:       2 << 40,
+					Available:  1 << 40,
+					Referenced: 2 << 40,
+					Mountpoint: "/testpool/dataset2",
+					Quota:      3 << 40,
+				},
+			},
+		},
+		{
+			Name:       "fastpool",
+			Used:       1 << 39,
+			Available:  3 << 39,
+			Referenced: 96 << 10,
+			Mountpoint: "/fastpool",
+			Children: []*Dataset{
+				{
+					// Small volume for a VM disk
+					Name:       "fastpool/vm-101-disk-0",
+					Type:       "volume",
+					Used:       200 << 30,
+					Available:  3 << 39,
+					Referenced: 120 << 30,
+					Snapshots:  14,
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,24 @@
+package zfs
+
+import "fmt"
+
+// GetEnclosures returns the disk enclosures of the mock host, holding the
+// disks of the mock pools of GetPools.
+// Currently provides mock data for development and testing purposes.
+//
+// Returns:
+//   - []*Enclosure: A 12-bay backplane with the mirror of testpool in the
+//     first two slots and spare disks in the next four
+//   - error: Error if the enclosures cannot be retrieved (currently always nil)
+func GetEnclosures() ([]*Enclosure, error) {
+	enc := &Enclosure{Number: 1, ID: "0:0:12:0", LogicalID: "0x5003048001c8e0bf", Vendor: "LSI", Model: "SAS2X28"}
+	devices := []string{"sda", "sdb", "sdc", "sdd", "sde", "sdf"}
+	for i := 0; i < 12; i++ {
+		slot := &EnclosureSlot{Number: i, Name: fmt.Sprintf("Slot %02d", i), Status: "Not Installed"}
+		if i < len(devices) {
+			slot.Device, slot.Status = devices[i], "OK"
+		}
+		enc.Slots = append(enc.Slots, slot)
+	}
+	return []*Enclosure{enc}, nil
+}
@@ -0,0 +1,259 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a record of the ZFS event log, as printed by zpool events -v:
+// error reports (ereports) such as checksum errors, and system events such
+// as a scrub starting or a device changing state.
+type Event struct {
+	// Time is when the event happened
+	Time time.Time
+
+	// Class is the event class, e.g. "ereport.fs.zfs.checksum"
+	Class string
+
+	// EID is the event ID, increasing with every event since the ZFS
+	// module was loaded, or 0 if unknown
+	EID uint64
+
+	// Pool is the name of the pool the event is about, empty for events
+	// about no particular pool
+	Pool string
+
+	// VDevGUID, VDevPath and VDevType identify the VDev the event is
+	// about; zero values if it is about none or the field is missing
+	VDevGUID uint64
+	VDevPath string
+	VDevType string
+
+	// Payload holds every top-level field of the record in output order,
+	// with strings unquoted; nested lists such as the detector are left out
+	Payload []EventField
+}
+
+// EventField is a field of an event payload, e.g. "zio_err" = "0x34".
+type EventField struct {
+	Name  string
+	Value string
+}
+
+// Field returns the value of a payload field.
+//
+// Parameters:
+//   - name: The field name, e.g. "vdev_state"
+//
+// Returns:
+//   - string: The value, unquoted if it was a string
+//   - bool: Whether the event has the field
+func (e *Event) Field(name string) (string, bool) {
+	for _, f := range e.Payload {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// eventClassPrefixes are the namespaces of ZFS event classes.
+var eventClassPrefixes = []string{"ereport.fs.zfs.", "sysevent.fs.zfs.", "resource.fs.zfs."}
+
+// ShortClass returns the class without its namespace, e.g. "checksum" for
+// "ereport.fs.zfs.checksum".
+func (e *Event) ShortClass() string {
+	for _, prefix := range eventClassPrefixes {
+		if short, ok := strings.CutPrefix(e.Class, prefix); ok {
+			return short
+		}
+	}
+	return e.Class
+}
+
+// IsError reports whether the event is an error report rather than a
+// notification.
+func (e *Event) IsError() bool {
+	return strings.HasPrefix(e.Class, "ereport.")
+}
+
+// partitionSuffix matches the partition part of a device name: "-part1"
+// of by-id names, "p1" of NVMe namespaces and the digits of sd devices.
+var partitionSuffix = regexp.MustCompile(`^(.+?)(?:-part\d+|(n\d+)p\d+|([a-z])\d+)$`)
+
+// RefersTo reports whether the event is about a VDev. VDevs are matched
+// by GUID where both are known, otherwise by device name: zpool status
+// shows whole disks without their partition, e.g. "sda" for an event
+// about /dev/sda1.
+//
+// Parameters:
+//   - name: The VDev name, as in VDev.Name
+//   - guid: The VDev GUID, 0 if unknown
+//
+// Returns:
+//   - bool: Whether the event names the VDev
+func (e *Event) RefersTo(name string, guid uint64) bool {
+	if e.VDevGUID != 0 && guid != 0 {
+		return e.VDevGUID == guid
+	}
+	if e.VDevPath == "" || name == "" {
+		return false
+	}
+	if name == e.VDevPath {
+		return true // zpool status -P
+	}
+	base := path.Base(e.VDevPath)
+	if m := partitionSuffix.FindStringSubmatch(base); m != nil && name == m[1]+m[2]+m[3] {
+		return true
+	}
+	return name == base
+}
+
+// eventTimeLayout is the time format of zpool events, e.g.
+// "Oct 13 2024 00:24:01.123456789".
+const eventTimeLayout = "Jan _2 2006 15:04:05.000000000"
+
+// ParseEvents parses the output of zpool events -v as it is printed,
+// calling fn for every complete event. It is meant for the never-ending
+// output of zpool events -f: events are passed on as soon as the blank
+// line ending them is read.
+//
+// Parameters:
+//   - r: The output of zpool events -v, with or without -H and -f
+//   - fn: Called with every event in output order
+//
+// Returns:
+//   - error: Error reading r or parsing a record; nil at the end of r
+//
+// Example:
+//
+//	cmd := exec.Command("zpool", "events", "-fvH")
+//	out, _ := cmd.StdoutPipe()
+//	cmd.Start()
+//	err := zfs.ParseEvents(out, func(e *zfs.Event) {
+//	    fmt.Println(e.Time, e.Pool, e.ShortClass())
+//	})
+func ParseEvents(r io.Reader, fn func(*Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event *Event
+	depth := 0 // Nesting level in embedded lists
+	flush := func() {
+		if event != nil {
+			fn(event)
+			event, depth = nil, 0
+		}
+	}
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+
+		case line[0] != ' ' && line[0] != '\t':
+			// A record starts with a line "<time> <class>"
+			flush()
+			fields := strings.Fields(line)
+			if len(fields) < 2 || fields[0] == "TIME" {
+				continue // the header
+			}
+			class := fields[len(fields)-1]
+			t, err := time.ParseInLocation(eventTimeLayout, strings.Join(fields[:len(fields)-1], " "), time.Local)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid event time in %q", lineNo, line)
+			}
+			event = &Event{Time: t, Class: class}
+
+		case event == nil:
+			return fmt.Errorf("line %d: event field outside an event: %q", lineNo, line)
+
+		case strings.HasPrefix(trimmed, "(end "):
+			depth = max(depth-1, 0)
+
+		default:
+			name, value, ok := strings.Cut(trimmed, " = ")
+			if !ok {
+				return fmt.Errorf("line %d: invalid event field %q", lineNo, line)
+			}
+			if value == "(embedded nvlist)" || strings.HasPrefix(value, "(array of embedded nvlists)") {
+				depth++
+				continue
+			}
+			if depth == 0 {
+				event.add(name, value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
+
+// add records a payload field, filling in the typed fields it carries.
+func (e *Event) add(name, value string) {
+	value = strings.TrimSpace(value)
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	e.Payload = append(e.Payload, EventField{Name: name, Value: value})
+
+	switch name {
+	case "class":
+		e.Class = value
+	case "pool":
+		e.Pool = value
+	case "eid":
+		e.EID, _ = strconv.ParseUint(value, 0, 64)
+	case "vdev_guid":
+		e.VDevGUID, _ = strconv.ParseUint(value, 0, 64)
+	case "vdev_path":
+		e.VDevPath = value
+	case "vdev_type":
+		e.VDevType = value
+	case "time":
+		// Seconds and nanoseconds, more precise than the header line
+		var sec, nsec int64
+		if _, err := fmt.Sscanf(value, "%v %v", &sec, &nsec); err == nil && sec > 0 {
+			e.Time = time.Unix(sec, nsec)
+		}
+	}
+}
+
+// GetEvents returns the event log of the mock pools of GetPools.
+// Currently provides mock data for development and testing purposes.
+//
+// Returns:
+//   - []*Event: The events, oldest first
+//   - error: Error if the events cannot be retrieved (currently always nil)
+func GetEvents() ([]*Event, error) {
+	now := time.Now()
+	event := func(ago time.Duration, eid uint64, class, pool, vdev string) *Event {
+		e := &Event{Time: now.Add(-ago), EID: eid, Class: class, Pool: pool}
+		e.Payload = []EventField{{"class", class}, {"pool", pool}}
+		if vdev != "" {
+			e.VDevPath, e.VDevType = "/dev/"+vdev+"1", "disk"
+			e.Payload = append(e.Payload, EventField{"vdev_path", e.VDevPath}, EventField{"vdev_type", e.VDevType})
+		}
+		e.Payload = append(e.Payload, EventField{"eid", fmt.Sprintf("0x%x", eid)})
+		return e
+	}
+
+	return []*Event{
+		event(7*24*time.Hour+3*time.Hour, 1, "sysevent.fs.zfs.scrub_start", "testpool", ""),
+		event(7*24*time.Hour, 2, "sysevent.fs.zfs.scrub_finish", "testpool", ""),
+		event(26*time.Hour, 3, "ereport.fs.zfs.io", "testpool", "sda"),
+		event(26*time.Hour, 4, "ereport.fs.zfs.checksum", "testpool", "sda"),
+		event(25*time.Hour, 5, "ereport.fs.zfs.checksum", "testpool", "sda"),
+		event(2*time.Hour, 6, "sysevent.fs.zfs.config_sync", "fastpool", ""),
+	}, nil
+}
@@ -0,0 +1,303 @@
+package zfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of pool history entries.
+const (
+	// HistoryCommand is a zpool or zfs command that changed the pool,
+	// e.g. "zfs set compression=lz4 tank"
+	HistoryCommand = "command"
+
+	// HistoryInternal is an event logged by ZFS itself (zpool history -i),
+	// e.g. "[txg:12] set tank (54) compression=2"
+	HistoryInternal = "internal"
+
+	// HistoryIoctl is a request of a program to the ZFS module
+	// (zpool history -i), e.g. "ioctl snapshot" with its input
+	HistoryIoctl = "ioctl"
+)
+
+// HistoryEntry is a record of the command history of a pool, as printed
+// by zpool history -il.
+type HistoryEntry struct {
+	// Time is when the entry was logged, to the second
+	Time time.Time
+
+	// Pool is the pool the entry belongs to
+	Pool string
+
+	// Kind is HistoryCommand, HistoryInternal or HistoryIoctl
+	Kind string
+
+	// Text is the command line, or the description of an internal entry
+	// without its transaction group
+	Text string
+
+	// Details are the further lines of an entry, such as the input of an
+	// ioctl, or empty
+	Details string
+
+	// TXG is the transaction group of an internal entry, 0 otherwise
+	TXG uint64
+
+	// User is the name of the user who ran a command, UID its user ID;
+	// -1 and empty if unknown
+	User string
+	UID  int
+
+	// Host is the host the entry was logged on, Zone its zone (e.g.
+	// "linux" on Linux); empty if unknown
+	Host string
+	Zone string
+}
+
+// historyTimeLayout is the time format of zpool history, e.g.
+// "2024-10-01.10:12:53".
+const historyTimeLayout = "2006-01-02.15:04:05"
+
+var (
+	// historyPoolPattern matches the line introducing the history of a pool
+	historyPoolPattern = regexp.MustCompile(`^History for '(.+)':$`)
+
+	// historyEntryPattern matches the first line of an entry: its time and text
+	historyEntryPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}\.\d{2}:\d{2}:\d{2}) (.*)$`)
+
+	// historyLongPattern matches the details zpool history -l appends to
+	// the last line of an entry, e.g. " [user 0 (root) on nas1:linux]" or
+	// " [on nas1]"
+	historyLongPattern = regexp.MustCompile(`\s*\[(?:user (\d+) \(([^)]*)\) )?on ([^:\]]*)(?::([^\]]*))?\]\s*$`)
+
+	// historyTXGPattern matches the transaction group of internal entries
+	historyTXGPattern = regexp.MustCompile(`^\[txg:(\d+)\] ?`)
+)
+
+// ParseHistory parses the output of zpool history, with or without -i and
+// -l, for one or more pools.
+//
+// Parameters:
+//   - out: The output of zpool history -il
+//
+// Returns:
+//   - []*HistoryEntry: The entries in output order, i.e. oldest first per pool
+//   - error: Error if a line is neither a pool heading nor part of an entry
+//
+// Example:
+//
+//	out, _ := exec.Command("zpool", "history", "-il", "tank").Output()
+//	entries, err := zfs.ParseHistory(out)
+//	for _, e := range entries {
+//	    fmt.Println(e.Time, e.User, e.Text)
+//	}
+func ParseHistory(out []byte) ([]*HistoryEntry, error) {
+	var entries []*HistoryEntry
+	var pool string
+	var entry *HistoryEntry
+	var lines []string // Lines of entry, joined when the entry is complete
+
+	finish := func() {
+		if entry != nil {
+			entry.parse(lines)
+			entries = append(entries, entry)
+			entry, lines = nil, nil
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if m := historyPoolPattern.FindStringSubmatch(line); m != nil {
+			finish()
+			pool = m[1]
+			continue
+		}
+		if m := historyEntryPattern.FindStringSubmatch(line); m != nil {
+			finish()
+			t, err := time.ParseInLocation(historyTimeLayout, m[1], time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid time %q", lineNo, m[1])
+			}
+			entry = &HistoryEntry{Time: t, Pool: pool, UID: -1}
+			lines = []string{m[2]}
+			continue
+		}
+		switch {
+		case entry != nil:
+			lines = append(lines, line) // ioctl input and multi-line messages
+		case strings.TrimSpace(line) != "":
+			return nil, fmt.Errorf("line %d: unexpected %q", lineNo, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+	return entries, nil
+}
+
+// parse fills in an entry from its lines: the text of the first line and
+// the details of the others, with the -l suffix of the last line.
+func (e *HistoryEntry) parse(lines []string) {
+	// Blank lines separate pools, not parts of an entry
+	for len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	last := len(lines) - 1
+	if m := historyLongPattern.FindStringSubmatchIndex(lines[last]); m != nil {
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return lines[last][m[2*i]:m[2*i+1]]
+		}
+		if uid := group(1); uid != "" {
+			e.UID, _ = strconv.Atoi(uid)
+		}
+		e.User, e.Host, e.Zone = group(2), group(3), group(4)
+		lines[last] = lines[last][:m[0]]
+	}
+
+	e.Text = strings.TrimSpace(lines[0])
+	if len(lines) > 1 {
+		e.Details = strings.TrimRight(strings.Join(lines[1:], "\n"), " \n")
+	}
+	switch {
+	case historyTXGPattern.MatchString(e.Text):
+		m := historyTXGPattern.FindStringSubmatch(e.Text)
+		e.Kind, e.Text = HistoryInternal, strings.TrimSpace(e.Text[len(m[0]):])
+		e.TXG, _ = strconv.ParseUint(m[1], 10, 64)
+	case strings.HasPrefix(e.Text, "ioctl "):
+		e.Kind = HistoryIoctl
+	default:
+		e.Kind = HistoryCommand
+	}
+}
+
+// Matches reports whether an entry contains every word of a search, in
+// its text, details, user or host, ignoring case.
+//
+// Parameters:
+//   - query: Words separated by spaces, e.g. "set compression"; an empty
+//     query matches every entry
+//
+// Returns:
+//   - bool: Whether the entry matches
+func (e *HistoryEntry) Matches(query string) bool {
+	haystack := strings.ToLower(strings.Join([]string{e.Text, e.Details, e.User, e.Host}, "\n"))
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScanRecord is a scrub or resilver found in the history of a pool.
+type ScanRecord struct {
+	// Function is "scrub" or "resilver"
+	Function string
+
+	// State is "finished", "canceled", or "scanning" if the history ends
+	// before the scan does, as in ScanInfo
+	State string
+
+	// Start and End are when the scan was set up and ended; End is zero
+	// while scanning
+	Start time.Time
+	End   time.Time
+
+	// Errors is the number of errors the scan found, if it logged them
+	Errors uint64
+}
+
+// scanErrorsPattern matches the error count logged when a scan ends.
+var scanErrorsPattern = regexp.MustCompile(`\berrors=(\d+)`)
+
+// ScanHistory finds the scrubs and resilvers of a pool in the internal
+// entries of its history: ZFS logs "scan setup func=N" when a scan starts
+// (func=1 for a scrub, 2 for a resilver) and "scan done" or "scan
+// cancelled" when it ends, with the errors found. A scan aborted to be
+// restarted is recorded as canceled.
+//
+// Parameters:
+//   - entries: The history of a pool as read with zpool history -i,
+//     oldest first
+//
+// Returns:
+//   - []ScanRecord: The scans, oldest first; empty if the history holds
+//     none or was read without internal entries
+func ScanHistory(entries []*HistoryEntry) []ScanRecord {
+	var scans []ScanRecord
+	open := -1 // Index of the scan in progress, -1 for none
+	for _, e := range entries {
+		if e.Kind != HistoryInternal || !strings.HasPrefix(e.Text, "scan ") {
+			continue
+		}
+		switch text := strings.TrimPrefix(e.Text, "scan "); {
+		case strings.HasPrefix(text, "setup"):
+			function := "scrub"
+			if strings.Contains(text, "func=2") {
+				function = "resilver"
+			}
+			scans = append(scans, ScanRecord{Function: function, State: "scanning", Start: e.Time})
+			open = len(scans) - 1
+		case open >= 0 && (strings.HasPrefix(text, "done") || strings.HasPrefix(text, "cancelled") || strings.HasPrefix(text, "aborted")):
+			scan := &scans[open]
+			scan.End, scan.State = e.Time, "finished"
+			if !strings.HasPrefix(text, "done") {
+				scan.State = "canceled"
+			}
+			if m := scanErrorsPattern.FindStringSubmatch(text); m != nil {
+				scan.Errors, _ = strconv.ParseUint(m[1], 10, 64)
+			}
+			open = -1
+		}
+	}
+	return scans
+}
+
+// GetHistory returns the command history of a mock pool of GetPools.
+// Currently provides mock data for development and testing purposes.
+//
+// Parameters:
+//   - pool: The pool name
+//
+// Returns:
+//   - []*HistoryEntry: The entries, oldest first; none for unknown pools
+//   - error: Error if the history cannot be retrieved (currently always nil)
+func GetHistory(pool string) ([]*HistoryEntry, error) {
+	now := time.Now().Truncate(time.Second)
+	var entries []*HistoryEntry
+	add := func(ago time.Duration, kind, text string, txg uint64) {
+		e := &HistoryEntry{Time: now.Add(-ago), Pool: pool, Kind: kind, Text: text, TXG: txg, UID: -1, Host: "mockhost", Zone: "linux"}
+		if kind == HistoryCommand {
+			e.User, e.UID = "root", 0
+		}
+		entries = append(entries, e)
+	}
+
+	switch pool {
+	case "testpool":
+		add(400*24*time.Hour, HistoryInternal, "create pool version 5000; software version zfs-2.2.2-1", 5)
+		add(400*24*time.Hour, HistoryCommand, "zpool create -o ashift=12 testpool mirror /dev/sda /dev/sdb", 0)
+		add(399*24*time.Hour, HistoryInternal, "set testpool (54) compression=15", 40)
+		add(399*24*time.Hour, HistoryCommand, "zfs set compression=lz4 testpool", 0)
+		add(7*24*time.Hour+3*time.Hour, HistoryInternal, "scan setup func=1 mintxg=0 maxtxg=91234", 91234)
+		add(7*24*time.Hour+3*time.Hour, HistoryCommand, "zpool scrub testpool", 0)
+		add(7*24*time.Hour, HistoryInternal, "scan done errors=0", 91300)
+	case "fastpool":
+		add(200*24*time.Hour, HistoryCommand, "zpool create fastpool /dev/nvme0n1", 0)
+		add(150*24*time.Hour, HistoryCommand, "zpool add fastpool cache /dev/nvme1n1", 0)
+		add(150*24*time.Hour, HistoryCommand, "zpool add fastpool log /dev/nvme2n1", 0)
+		add(30*24*time.Hour, HistoryCommand, "zfs set recordsize=1M fastpool", 0)
+	}
+	return entries, nil
+}
@@ -0,0 +1,458 @@
+package zfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file parses the JSON output OpenZFS 2.3 added to zpool status and
+// zfs list. It is preferred over the text output where available, since it
+// does not depend on column widths and indentation. Numbers are accepted
+// both as integers (--json-int) and as the strings printed without it.
+
+// Version is an OpenZFS release, e.g. 2.3.0.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// JSONVersion is the first release printing JSON with -j.
+var JSONVersion = Version{2, 3, 0}
+
+// versionPattern matches the userland line of zfs version, e.g.
+// "zfs-2.3.0-1" or "zfs-2.1.4-FreeBSD_g52bad4f23".
+var versionPattern = regexp.MustCompile(`(?m)^zfs-(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion parses the output of zfs version.
+//
+// Parameters:
+//   - out: The command output; its first line is the userland version
+//
+// Returns:
+//   - Version: The userland version
+//   - error: Error if no version is found
+func ParseVersion(out []byte) (Version, error) {
+	m := versionPattern.FindSubmatch(out)
+	if m == nil {
+		return Version{}, fmt.Errorf("zfs version: no version in %q", firstLine(out))
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(string(m[1]))
+	v.Minor, _ = strconv.Atoi(string(m[2]))
+	v.Patch, _ = strconv.Atoi(string(m[3]))
+	return v, nil
+}
+
+// AtLeast reports whether v is the same release as other or a later one.
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+// String returns the version, e.g. "2.3.0".
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// jsonPool is a pool of zpool status -j.
+type jsonPool struct {
+	Name      string      `json:"name"`
+	State     string      `json:"state"`
+	ScanStats *jsonScan   `json:"scan_stats"`
+	VDevs     jsonVDevMap `json:"vdevs"`
+	Special   jsonVDevMap `json:"special"`
+	Dedup     jsonVDevMap `json:"dedup"`
+	Logs      jsonVDevMap `json:"logs"`
+	L2Cache   jsonVDevMap `json:"l2cache"`
+}
+
+// jsonVDev is a VDev of zpool status -j.
+type jsonVDev struct {
+	Name           string      `json:"name"`
+	VDevType       string      `json:"vdev_type"`
+	GUID           jsonNumber  `json:"guid"`
+	Path           string      `json:"path"`
+	Class          string      `json:"class"`
+	State          string      `json:"state"`
+	AllocSpace     jsonNumber  `json:"alloc_space"`
+	TotalSpace     jsonNumber  `json:"total_space"`
+	ReadErrors     jsonNumber  `json:"read_errors"`
+	WriteErrors    jsonNumber  `json:"write_errors"`
+	ChecksumErrors jsonNumber  `json:"checksum_errors"`
+	VDevs          jsonVDevMap `json:"vdevs"`
+}
+
+// jsonScan is the scan_stats object of a pool.
+type jsonScan struct {
+	Function  string     `json:"function"`
+	State     string     `json:"state"`
+	StartTime jsonTime   `json:"start_time"`
+	EndTime   jsonTime   `json:"end_time"`
+	Examined  jsonNumber `json:"examined"`
+	Issued    jsonNumber `json:"issued"`
+	ToExamine jsonNumber `json:"to_examine"`
+	Errors    jsonNumber `json:"errors"`
+}
+
+// jsonVDevMap is an object of VDevs keyed by name, kept in document order.
+type jsonVDevMap []*jsonVDev
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *jsonVDevMap) UnmarshalJSON(data []byte) error {
+	return eachMember(data, func(name string, value json.RawMessage) error {
+		vdev := &jsonVDev{Name: name}
+		if err := json.Unmarshal(value, vdev); err != nil {
+			return fmt.Errorf("vdev %s: %w", name, err)
+		}
+		*m = append(*m, vdev)
+		return nil
+	})
+}
+
+// ParsePoolStatusJSON parses the output of zpool status -j into the same
+// model as ParsePoolStatus.
+//
+// Parameters:
+//   - out: The output of zpool status -j --json-int for one or more pools
+//
+// Returns:
+//   - []*Pool: The pools in output order
+//   - error: Error if the output is not the JSON of zpool status
+//
+// Example:
+//
+//	out, _ := exec.Command("zpool", "status", "-j", "--json-int").Output()
+//	pools, err := zfs.ParsePoolStatusJSON(out)
+func ParsePoolStatusJSON(out []byte) ([]*Pool, error) {
+	var doc struct {
+		Pools json.RawMessage `json:"pools"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("zpool status -j: %w", err)
+	}
+
+	var pools []*Pool
+	err := eachMember(doc.Pools, func(name string, value json.RawMessage) error {
+		jp := jsonPool{Name: name}
+		if err := json.Unmarshal(value, &jp); err != nil {
+			return fmt.Errorf("zpool status -j: pool %s: %w", name, err)
+		}
+		pool, err := jp.pool()
+		if err != nil {
+			return fmt.Errorf("zpool status -j: pool %s: %w", name, err)
+		}
+		pools = append(pools, pool)
+		return nil
+	})
+	return pools, err
+}
+
+// pool converts a pool of the JSON output. The root VDev holds the data
+// class; special, dedup and log VDevs are grouped as ParsePoolStatus does,
+// whether they are listed in their own object or among the root's
+// children with their class.
+func (jp *jsonPool) pool() (*Pool, error) {
+	if len(jp.VDevs) != 1 {
+		return nil, fmt.Errorf("%d root vdevs, want 1", len(jp.VDevs))
+	}
+	pool := &Pool{Name: jp.Name, Status: ParseState(jp.State)}
+	root := jp.VDevs[0]
+	pool.RootVDev = &VDev{
+		Name:           root.Name,
+		Type:           VDevTypeRoot,
+		Status:         ParseState(root.State),
+		Size:           uint64(root.TotalSpace),
+		Allocated:      uint64(root.AllocSpace),
+		ReadErrors:     uint64(root.ReadErrors),
+		WriteErrors:    uint64(root.WriteErrors),
+		ChecksumErrors: uint64(root.ChecksumErrors),
+	}
+
+	classes := map[string]jsonVDevMap{"special": jp.Special, "dedup": jp.Dedup, "log": jp.Logs}
+	for _, child := range root.VDevs {
+		if _, ok := classes[child.Class]; ok {
+			classes[child.Class] = append(classes[child.Class], child)
+			continue
+		}
+		pool.RootVDev.Children = append(pool.RootVDev.Children, child.vdev())
+	}
+	for _, class := range []string{"special", "dedup"} {
+		if group := newGroup(class, class, classes[class]); group != nil {
+			pool.RootVDev.Children = append(pool.RootVDev.Children, group)
+		}
+	}
+	pool.Slog = newGroup("logs", "log", classes["log"])
+	pool.Cache = newGroup("cache", "cache", jp.L2Cache)
+
+	if jp.ScanStats != nil {
+		pool.Scan = jp.ScanStats.scan()
+	}
+	return pool, nil
+}
+
+// newGroup builds the VDev grouping the VDevs of a class, or returns nil
+// if the class has none. The same VDev listed twice is added once.
+func newGroup(name, typ string, members jsonVDevMap) *VDev {
+	if len(members) == 0 {
+		return nil
+	}
+	group := &VDev{Name: name, Type: typ}
+	seen := make(map[string]bool)
+	for _, m := range members {
+		if !seen[m.Name] {
+			seen[m.Name] = true
+			group.Children = append(group.Children, m.vdev())
+		}
+	}
+	group.Status = worstChild(group)
+	return group
+}
+
+// vdev converts a VDev of the JSON output with its children.
+func (jv *jsonVDev) vdev() *VDev {
+	vdev := &VDev{
+		Name:           jv.Name,
+		Type:           jv.VDevType,
+		Status:         ParseState(jv.State),
+		GUID:           uint64(jv.GUID),
+		Path:           jv.Path,
+		Size:           uint64(jv.TotalSpace),
+		Allocated:      uint64(jv.AllocSpace),
+		ReadErrors:     uint64(jv.ReadErrors),
+		WriteErrors:    uint64(jv.WriteErrors),
+		ChecksumErrors: uint64(jv.ChecksumErrors),
+	}
+	// Use the same type names as the text parser, e.g. "raidz2" rather
+	// than "raidz" for raidz2-1
+	if m := vdevTypeName.FindStringSubmatch(vdev.Name); m != nil {
+		vdev.Type = m[1]
+	} else if vdev.Type == "" {
+		vdev.Type = "disk"
+	}
+	for _, child := range jv.VDevs {
+		vdev.Children = append(vdev.Children, child.vdev())
+	}
+	return vdev
+}
+
+// scan converts the scan statistics, or returns nil if no scan was run.
+func (js *jsonScan) scan() *ScanInfo {
+	state := map[string]string{"SCANNING": "scanning", "FINISHED": "finished", "CANCELED": "canceled"}[strings.ToUpper(js.State)]
+	if state == "" || js.Function == "" {
+		return nil
+	}
+	scan := &ScanInfo{
+		Function:  strings.ToLower(js.Function),
+		State:     state,
+		Start:     time.Time(js.StartTime),
+		Examined:  uint64(js.Issued),
+		ToExamine: uint64(js.ToExamine),
+		Errors:    uint64(js.Errors),
+	}
+	if scan.Examined == 0 {
+		scan.Examined = uint64(js.Examined) // before issued was reported separately
+	}
+	if state != "scanning" {
+		scan.End = time.Time(js.EndTime)
+	}
+	return scan
+}
+
+// jsonDataset is a dataset of zfs list -j.
+type jsonDataset struct {
+	Name       string                  `json:"name"`
+	Type       string                  `json:"type"`
+	Properties map[string]jsonProperty `json:"properties"`
+}
+
+// jsonProperty is a property of zfs list -j.
+type jsonProperty struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// ParseDatasetListJSON parses the output of zfs list -j with
+// DatasetListFields into the same dataset tree as ParseDatasetList.
+//
+// Parameters:
+//   - out: The output of zfs list -j --json-int -p with the DatasetListFields
+//     properties, parents before children as zfs list prints them
+//
+// Returns:
+//   - []*Dataset: The root dataset of each pool, with nested children
+//   - error: Error if the output is not the JSON of zfs list or a value is bad
+func ParseDatasetListJSON(out []byte) ([]*Dataset, error) {
+	var doc struct {
+		Datasets json.RawMessage `json:"datasets"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("zfs list -j: %w", err)
+	}
+
+	tree := newDatasetTree()
+	err := eachMember(doc.Datasets, func(name string, value json.RawMessage) error {
+		jd := jsonDataset{Name: name}
+		if err := json.Unmarshal(value, &jd); err != nil {
+			return fmt.Errorf("zfs list -j: dataset %s: %w", name, err)
+		}
+		ds := &Dataset{
+			Name:        jd.Name,
+			Type:        strings.ToLower(jd.Type),
+			Mountpoint:  jd.text("mountpoint"),
+			Compression: jd.text("compression"),
+		}
+		for _, f := range []struct {
+			name string
+			dst  *uint64
+		}{{"used", &ds.Used}, {"available", &ds.Available}, {"referenced", &ds.Referenced}, {"quota", &ds.Quota}} {
+			var n jsonNumber
+			if value := jd.Properties[f.name].Value; value != nil {
+				if err := json.Unmarshal(value, &n); err != nil {
+					return fmt.Errorf("zfs list -j: dataset %s: %s: %w", name, f.name, err)
+				}
+			}
+			*f.dst = uint64(n)
+		}
+		ratio, err := jd.ratio("compressratio")
+		if err != nil {
+			return fmt.Errorf("zfs list -j: dataset %s: %w", name, err)
+		}
+		ds.CompressRatio = ratio
+		tree.add(normalizeDataset(ds))
+		return nil
+	})
+	return tree.roots, err
+}
+
+// text returns a property as a string, empty if it is missing.
+func (jd *jsonDataset) text(name string) string {
+	var s string
+	if value := jd.Properties[name].Value; value != nil && json.Unmarshal(value, &s) != nil {
+		s = string(value) // a number
+	}
+	return s
+}
+
+// ratio returns a ratio property such as compressratio, printed as
+// "1.50x" or "1.50", or with --json-int as its hundredfold, 150.
+func (jd *jsonDataset) ratio(name string) (float64, error) {
+	value := jd.Properties[name].Value
+	if value == nil {
+		return 0, nil
+	}
+	var n uint64
+	if json.Unmarshal(value, &n) == nil {
+		return float64(n) / 100, nil
+	}
+	s := strings.TrimSuffix(jd.text(name), "x")
+	if s == "-" || s == "" {
+		return 0, nil
+	}
+	ratio, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s", name, value)
+	}
+	return ratio, nil
+}
+
+// jsonNumber is a number that is an integer with --json-int, and a string
+// such as "1234", "1.50T" or "-" without.
+type jsonNumber uint64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *jsonNumber) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var v uint64
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("invalid number %s", data)
+		}
+		*n = jsonNumber(v)
+		return nil
+	}
+	switch s {
+	case "", "-", "none":
+		*n = 0
+		return nil
+	}
+	v, err := ParseSize(s)
+	*n = jsonNumber(v)
+	return err
+}
+
+// jsonTime is a time that is a Unix timestamp with --json-int, and a
+// ctime(3) string such as "Sun Oct 13 00:24:01 2024" without.
+type jsonTime time.Time
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *jsonTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var sec int64
+		if err := json.Unmarshal(data, &sec); err != nil {
+			return fmt.Errorf("invalid time %s", data)
+		}
+		if sec > 0 {
+			*t = jsonTime(time.Unix(sec, 0))
+		}
+		return nil
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" || s == "-" {
+		return nil
+	}
+	parsed, err := time.ParseInLocation(scanTimeLayout, s, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid time %q", s)
+	}
+	*t = jsonTime(parsed)
+	return nil
+}
+
+// eachMember calls fn with the name and value of every member of a JSON
+// object in document order, which a map would lose. A missing or null
+// object has no members.
+func eachMember(data []byte, fn func(name string, value json.RawMessage) error) error {
+	if len(data) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected an object, found %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if err := fn(tok.(string), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstLine returns the first line of a command output.
+func firstLine(out []byte) string {
+	line, _, _ := bytes.Cut(out, []byte("\n"))
+	return string(line)
+}
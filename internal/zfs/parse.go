@@ -0,0 +1,670 @@
+package zfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file parses the text output of the zpool and zfs commands into the
+// model of this package. The commands are expected to run with LC_ALL=C,
+// and with -p where supported so that sizes are exact byte counts; human
+// readable sizes such as "1.5T" are accepted as well.
+
+// PoolListFields are the properties ParsePoolList expects, in order:
+//
+//	zpool list -Hp -o name,size,allocated,free,fragmentation,health
+var PoolListFields = []string{"name", "size", "allocated", "free", "fragmentation", "health"}
+
+// DatasetListFields are the properties ParseDatasetList expects, in order:
+//
+//	zfs list -Hp -t filesystem,volume -o name,type,used,available,referenced,quota,mountpoint,compression,compressratio
+var DatasetListFields = []string{"name", "type", "used", "available", "referenced", "quota", "mountpoint", "compression", "compressratio"}
+
+// SnapshotUsageFields are the properties ParseSnapshotUsage expects, in
+// order, of datasets and their snapshots:
+//
+//	zfs list -Hp -t filesystem,volume,snapshot -o name,usedbysnapshots
+var SnapshotUsageFields = []string{"name", "usedbysnapshots"}
+
+// ParsePoolList parses the output of zpool list with PoolListFields into
+// pools with capacity figures and health, but no VDevs.
+//
+// Parameters:
+//   - out: The command output, one tab-separated line per pool
+//
+// Returns:
+//   - []*Pool: The pools in output order
+//   - error: Error if a line has the wrong number of fields or a bad number
+func ParsePoolList(out []byte) ([]*Pool, error) {
+	var pools []*Pool
+	err := eachLine(out, func(n int, line string) error {
+		fields := strings.Split(line, "\t")
+		if len(fields) != len(PoolListFields) {
+			return fmt.Errorf("zpool list line %d: %d fields, want %d", n, len(fields), len(PoolListFields))
+		}
+		pool := &Pool{Name: fields[0], Status: ParseState(fields[5])}
+		var err error
+		if pool.Size, err = ParseSize(fields[1]); err != nil {
+			return fmt.Errorf("zpool list line %d: size: %w", n, err)
+		}
+		if pool.Allocated, err = ParseSize(fields[2]); err != nil {
+			return fmt.Errorf("zpool list line %d: allocated: %w", n, err)
+		}
+		if pool.Free, err = ParseSize(fields[3]); err != nil {
+			return fmt.Errorf("zpool list line %d: free: %w", n, err)
+		}
+		if frag := strings.TrimSuffix(fields[4], "%"); frag != "-" {
+			if pool.Fragmentation, err = strconv.Atoi(frag); err != nil {
+				return fmt.Errorf("zpool list line %d: invalid fragmentation %q", n, fields[4])
+			}
+		}
+		pools = append(pools, pool)
+		return nil
+	})
+	return pools, err
+}
+
+// ParseDatasetList parses the output of zfs list with DatasetListFields
+// into a dataset tree per pool. Snapshot counts and space are not part of
+// the list and stay 0; they are parsed by ParseSnapshotUsage.
+//
+// Parameters:
+//   - out: The command output, one tab-separated line per dataset,
+//     parents before children as zfs list prints them
+//
+// Returns:
+//   - []*Dataset: The root dataset of each pool, with nested children
+//   - error: Error if a line has the wrong number of fields or a bad number
+func ParseDatasetList(out []byte) ([]*Dataset, error) {
+	tree := newDatasetTree()
+	err := eachLine(out, func(n int, line string) error {
+		fields := strings.Split(line, "\t")
+		if len(fields) != len(DatasetListFields) {
+			return fmt.Errorf("zfs list line %d: %d fields, want %d", n, len(fields), len(DatasetListFields))
+		}
+		ds := &Dataset{Name: fields[0], Type: fields[1], Mountpoint: fields[6], Compression: fields[7]}
+
+		var err error
+		for _, f := range []struct {
+			name  string
+			value string
+			dst   *uint64
+		}{{"used", fields[2], &ds.Used}, {"available", fields[3], &ds.Available}, {"referenced", fields[4], &ds.Referenced}, {"quota", fields[5], &ds.Quota}} {
+			if f.value == "-" || f.value == "none" {
+				continue
+			}
+			if *f.dst, err = ParseSize(f.value); err != nil {
+				return fmt.Errorf("zfs list line %d: %s: %w", n, f.name, err)
+			}
+		}
+		if ratio := strings.TrimSuffix(fields[8], "x"); ratio != "-" {
+			if ds.CompressRatio, err = strconv.ParseFloat(ratio, 64); err != nil {
+				return fmt.Errorf("zfs list line %d: invalid compressratio %q", n, fields[8])
+			}
+		}
+		tree.add(normalizeDataset(ds))
+		return nil
+	})
+	return tree.roots, err
+}
+
+// SnapshotUsage is how many snapshots a dataset has and the space they
+// hold.
+type SnapshotUsage struct {
+	// Count is the number of snapshots of the dataset itself
+	Count int
+
+	// Used is the space that would be freed by destroying them
+	Used uint64
+}
+
+// ParseSnapshotUsage parses the output of zfs list with SnapshotUsageFields
+// into the snapshot usage of every dataset: snapshots ("tank/home@daily")
+// are counted for their dataset, and the usedbysnapshots of the datasets
+// is their space.
+//
+// Parameters:
+//   - out: The command output, one tab-separated line per dataset or
+//     snapshot
+//
+// Returns:
+//   - map[string]SnapshotUsage: Usage by dataset name
+//   - error: Error if a line has the wrong number of fields or a bad number
+func ParseSnapshotUsage(out []byte) (map[string]SnapshotUsage, error) {
+	usage := make(map[string]SnapshotUsage)
+	err := eachLine(out, func(n int, line string) error {
+		fields := strings.Split(line, "\t")
+		if len(fields) != len(SnapshotUsageFields) {
+			return fmt.Errorf("zfs list line %d: %d fields, want %d", n, len(fields), len(SnapshotUsageFields))
+		}
+		if dataset, _, ok := strings.Cut(fields[0], "@"); ok {
+			u := usage[dataset]
+			u.Count++
+			usage[dataset] = u
+			return nil
+		}
+		if fields[1] == "-" {
+			return nil
+		}
+		used, err := ParseSize(fields[1])
+		if err != nil {
+			return fmt.Errorf("zfs list line %d: usedbysnapshots: %w", n, err)
+		}
+		u := usage[fields[0]]
+		u.Used = used
+		usage[fields[0]] = u
+		return nil
+	})
+	return usage, err
+}
+
+// normalizeDataset maps the placeholders zfs list prints for unset values
+// to the zero values of the model: volumes have no type name or
+// mountpoint, and "-", "none" and "off" become empty.
+func normalizeDataset(ds *Dataset) *Dataset {
+	if ds.Type == "volume" {
+		ds.Mountpoint = ""
+	} else {
+		ds.Type = ""
+	}
+	if ds.Mountpoint == "-" || ds.Mountpoint == "none" {
+		ds.Mountpoint = ""
+	}
+	if ds.Compression == "off" || ds.Compression == "-" {
+		ds.Compression = ""
+	}
+	return ds
+}
+
+// datasetTree links datasets to their parents as they are listed.
+type datasetTree struct {
+	roots  []*Dataset
+	byName map[string]*Dataset
+}
+
+// newDatasetTree creates an empty tree.
+func newDatasetTree() *datasetTree {
+	return &datasetTree{byName: make(map[string]*Dataset)}
+}
+
+// add adds a dataset below its parent, or as a root if the parent was not
+// listed before it.
+func (t *datasetTree) add(ds *Dataset) {
+	t.byName[ds.Name] = ds
+	if i := strings.LastIndex(ds.Name, "/"); i >= 0 {
+		if parent, ok := t.byName[ds.Name[:i]]; ok {
+			parent.Children = append(parent.Children, ds)
+			return
+		}
+	}
+	t.roots = append(t.roots, ds)
+}
+
+// ParsePoolStatus parses the output of zpool status into pools with their
+// health, VDev tree, error counters and last scan. Capacity figures are
+// not part of the status; see ParsePoolList.
+//
+// The VDevs of the data class become the children of a VDevTypeRoot
+// RootVDev named after the pool; log and cache devices are grouped below
+// Slog and Cache. Special and dedup classes are added to the root as
+// VDevs of type "special" and "dedup"; spares are left out.
+//
+// Parameters:
+//   - out: The output of zpool status -p for one or more pools
+//
+// Returns:
+//   - []*Pool: The pools in output order
+//   - error: Error if the VDev tree is malformed
+//
+// Example:
+//
+//	out, _ := exec.Command("zpool", "status", "-p").Output()
+//	pools, err := zfs.ParsePoolStatus(out)
+func ParsePoolStatus(out []byte) ([]*Pool, error) {
+	var (
+		pools   []*Pool
+		pool    *Pool
+		section string   // the "key:" section the current line belongs to
+		scan    []string // lines of the scan section
+		stack   []*VDev  // VDevs of the current branch, by depth
+	)
+	finishScan := func() {
+		if pool != nil && len(scan) > 0 {
+			pool.Scan = ParseScan(strings.Join(scan, " "))
+		}
+		scan = nil
+	}
+
+	err := eachLine(out, func(n int, line string) error {
+		trimmed := strings.TrimSpace(line)
+		if key, value, ok := sectionKey(line); ok {
+			finishScan()
+			section = key
+			switch key {
+			case "pool":
+				pool = &Pool{Name: value, Status: VDevStatusOnline}
+				pools = append(pools, pool)
+				stack = nil
+			case "state":
+				if pool != nil {
+					pool.Status = ParseState(value)
+				}
+			case "scan":
+				scan = []string{value}
+			}
+			return nil
+		}
+		if pool == nil {
+			return nil
+		}
+
+		switch section {
+		case "scan":
+			scan = append(scan, trimmed)
+		case "config":
+			fields := strings.Fields(trimmed)
+			if fields[0] == "NAME" && len(fields) > 1 && fields[1] == "STATE" {
+				return nil // column header
+			}
+			depth := indent(line) / 2
+			if depth == 0 {
+				stack = startClass(pool, fields)
+				return nil
+			}
+			if len(stack) == 0 {
+				return nil // below spares, or a class without VDevs
+			}
+			if depth > len(stack) {
+				return fmt.Errorf("zpool status line %d: %s is indented too far", n, fields[0])
+			}
+			vdev := parseVDevLine(fields)
+			parent := stack[depth-1]
+			parent.Children = append(parent.Children, vdev)
+			stack = append(stack[:depth], vdev)
+		}
+		return nil
+	})
+	finishScan()
+
+	for _, p := range pools {
+		for _, group := range append(p.RootVDev.childrenOfType("special", "dedup"), p.Slog, p.Cache) {
+			if group != nil {
+				group.Status = worstChild(group)
+			}
+		}
+	}
+	return pools, err
+}
+
+// startClass handles an unindented line of the config section: the pool
+// itself or the heading of a device class. It returns the new VDev stack:
+// the VDev the following lines belong to, or none for skipped classes.
+func startClass(pool *Pool, fields []string) []*VDev {
+	switch name := fields[0]; {
+	case name == pool.Name:
+		pool.RootVDev = parseVDevLine(fields)
+		pool.RootVDev.Type = VDevTypeRoot
+		return []*VDev{pool.RootVDev}
+	case name == "logs":
+		pool.Slog = &VDev{Name: "logs", Type: "log"}
+		return []*VDev{pool.Slog}
+	case name == "cache":
+		pool.Cache = &VDev{Name: "cache", Type: "cache"}
+		return []*VDev{pool.Cache}
+	case (name == "special" || name == "dedup") && pool.RootVDev != nil:
+		class := &VDev{Name: name, Type: name}
+		pool.RootVDev.Children = append(pool.RootVDev.Children, class)
+		return []*VDev{class}
+	default:
+		return nil // spares, or an unknown class
+	}
+}
+
+// childrenOfType returns the children of a VDev with one of the types.
+func (v *VDev) childrenOfType(types ...string) []*VDev {
+	var found []*VDev
+	if v == nil {
+		return nil
+	}
+	for _, child := range v.Children {
+		for _, t := range types {
+			if child.Type == t {
+				found = append(found, child)
+			}
+		}
+	}
+	return found
+}
+
+// worstChild returns the worst status among the children of a VDev.
+func worstChild(vdev *VDev) VDevStatus {
+	worst := VDevStatusOnline
+	for _, child := range vdev.Children {
+		switch child.Status {
+		case VDevStatusFaulted:
+			return VDevStatusFaulted
+		case VDevStatusDegraded:
+			worst = VDevStatusDegraded
+		}
+	}
+	return worst
+}
+
+// vdevTypeName matches the names ZFS gives interior VDevs, e.g. "mirror-0",
+// "raidz2-1" or "draid2:4d:12c:1s-0", capturing the type.
+var vdevTypeName = regexp.MustCompile(`^(mirror|raidz[123]?|draid[123]?|spare|replacing)(:[0-9a-z:]+)?-[0-9]+$`)
+
+// parseVDevLine builds a VDev from the fields of a config line:
+// name, state, and read, write and checksum errors.
+func parseVDevLine(fields []string) *VDev {
+	vdev := &VDev{Name: fields[0], Type: "disk"}
+	if m := vdevTypeName.FindStringSubmatch(vdev.Name); m != nil {
+		vdev.Type = m[1]
+	} else if strings.HasPrefix(vdev.Name, "/") {
+		vdev.Type = "file"
+	}
+	if len(fields) > 1 {
+		vdev.Status = ParseState(fields[1])
+	}
+	for i, dst := range []*uint64{&vdev.ReadErrors, &vdev.WriteErrors, &vdev.ChecksumErrors} {
+		if len(fields) > 2+i {
+			*dst, _ = ParseSize(fields[2+i]) // counters use the same suffixes
+		}
+	}
+	return vdev
+}
+
+// ParseState maps a ZFS state name to the health states of the model:
+// OFFLINE counts as DEGRADED, and UNAVAIL and REMOVED as FAULTED.
+// States of healthy or unused devices (ONLINE, AVAIL, INUSE) and unknown
+// names map to ONLINE.
+//
+// Parameters:
+//   - state: The state as printed by zpool, e.g. "DEGRADED"
+//
+// Returns:
+//   - VDevStatus: The corresponding health state
+func ParseState(state string) VDevStatus {
+	switch strings.ToUpper(state) {
+	case "DEGRADED", "OFFLINE":
+		return VDevStatusDegraded
+	case "FAULTED", "UNAVAIL", "REMOVED", "SUSPENDED":
+		return VDevStatusFaulted
+	default:
+		return VDevStatusOnline
+	}
+}
+
+// scanTimeLayout is the ctime(3) format zpool status prints times in.
+const scanTimeLayout = "Mon Jan 2 15:04:05 2006"
+
+// Patterns of the scan section, joined into one line with single spaces.
+var (
+	scanFinished   = regexp.MustCompile(`^(scrub repaired|resilvered) \S+ in (?:(\d+) days? )?(\d+):(\d+):(\d+) with (\d+) errors on (.+)$`)
+	scanInProgress = regexp.MustCompile(`^(scrub|resilver) in progress since (\S+ \S+ +\d+ \S+ \d+)(.*)$`)
+	scanCanceled   = regexp.MustCompile(`^(scrub|resilver) canceled on (.+)$`)
+	scanIssuedOf   = regexp.MustCompile(`(\S+) / (\S+) issued`)
+	scanIssued     = regexp.MustCompile(`(\S+) issued(?: at \S+)?, (\S+) total`)
+	scanOutOf      = regexp.MustCompile(`(\S+) scanned out of (\S+)`)
+)
+
+// ParseScan parses the scan section of zpool status, e.g.
+// "scrub repaired 0B in 00:10:12 with 0 errors on Sun Oct 13 00:34:13 2024".
+// Scans in progress report the bytes issued so far out of the total, as
+// "X issued at R, Y total" before OpenZFS 2.2 and "X / Y issued at R"
+// since.
+//
+// Parameters:
+//   - text: The scan section with its lines joined by spaces
+//
+// Returns:
+//   - *ScanInfo: The scan, or nil if none was run or the text is not recognized
+func ParseScan(text string) *ScanInfo {
+	text = strings.Join(strings.Fields(text), " ")
+
+	if m := scanFinished.FindStringSubmatch(text); m != nil {
+		end, err := time.ParseInLocation(scanTimeLayout, m[7], time.Local)
+		if err != nil {
+			return nil
+		}
+		days, _ := strconv.Atoi(m[2])
+		h, _ := strconv.Atoi(m[3])
+		mins, _ := strconv.Atoi(m[4])
+		sec, _ := strconv.Atoi(m[5])
+		took := time.Duration(days)*24*time.Hour + time.Duration(h)*time.Hour +
+			time.Duration(mins)*time.Minute + time.Duration(sec)*time.Second
+		errs, _ := strconv.ParseUint(m[6], 10, 64)
+		function := "scrub"
+		if m[1] == "resilvered" {
+			function = "resilver"
+		}
+		return &ScanInfo{Function: function, State: "finished", Start: end.Add(-took), End: end, Errors: errs}
+	}
+
+	if m := scanInProgress.FindStringSubmatch(text); m != nil {
+		start, err := time.ParseInLocation(scanTimeLayout, m[2], time.Local)
+		if err != nil {
+			return nil
+		}
+		scan := &ScanInfo{Function: m[1], State: "scanning", Start: start}
+		progress := scanIssuedOf.FindStringSubmatch(m[3]) // from OpenZFS 2.2
+		if progress == nil {
+			progress = scanIssued.FindStringSubmatch(m[3])
+		}
+		if progress == nil {
+			progress = scanOutOf.FindStringSubmatch(m[3]) // before OpenZFS 0.8
+		}
+		if progress != nil {
+			scan.Examined, _ = ParseSize(progress[1])
+			scan.ToExamine, _ = ParseSize(progress[2])
+		}
+		return scan
+	}
+
+	if m := scanCanceled.FindStringSubmatch(text); m != nil {
+		end, err := time.ParseInLocation(scanTimeLayout, m[2], time.Local)
+		if err != nil {
+			return nil
+		}
+		return &ScanInfo{Function: m[1], State: "canceled", End: end}
+	}
+	return nil
+}
+
+// ParseARCStats parses the ARC kstats, as read from
+// /proc/spl/kstat/zfs/arcstats on Linux: a "name type data" line per
+// statistic after two header lines.
+//
+// Parameters:
+//   - out: The contents of the kstat file
+//
+// Returns:
+//   - *ARCStats: The ARC size and hit/miss counters
+//   - error: Error if the size statistic is missing or a value is not a number
+func ParseARCStats(out []byte) (*ARCStats, error) {
+	stats := &ARCStats{}
+	fields := map[string]*uint64{
+		"size": &stats.Size, "c": &stats.TargetSize, "c_max": &stats.MaxSize,
+		"hits": &stats.Hits, "misses": &stats.Misses,
+	}
+	found := false
+	err := eachLine(out, func(n int, line string) error {
+		parts := strings.Fields(line)
+		if len(parts) != 3 {
+			return nil
+		}
+		dst, ok := fields[parts[0]]
+		if !ok {
+			return nil
+		}
+		v, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("arcstats line %d: invalid %s %q", n, parts[0], parts[2])
+		}
+		*dst = v
+		found = found || parts[0] == "size"
+		return nil
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("arcstats: no size statistic")
+	}
+	return stats, err
+}
+
+// ParseIOStat parses the I/O rates of every VDev, as printed by
+//
+//	zpool iostat -Hpv <interval> <count>
+//
+// which prints a sample of all pools every interval: a tab-separated line
+// per pool and VDev with the name, allocated and free space, read and
+// write operations and read and write bytes per second. The first sample
+// averages the I/O since the pool was imported, so the last one is used.
+// Lines of device classes such as "logs" and of VDevs without rates ("-")
+// are left out.
+//
+// Parameters:
+//   - out: The command output
+//   - pools: The names of the pools, which start the lines of each pool
+//
+// Returns:
+//   - map[string]map[string]IOStats: The rates of the last sample by pool
+//     and VDev name, the pool itself included, all Sampled
+//   - error: Error if a line has a bad number
+func ParseIOStat(out []byte, pools []string) (map[string]map[string]IOStats, error) {
+	isPool := make(map[string]bool)
+	for _, pool := range pools {
+		isPool[pool] = true
+	}
+	var sample map[string]map[string]IOStats
+	var current map[string]IOStats // VDevs of the pool being read
+	err := eachLine(out, func(n int, line string) error {
+		fields := strings.Split(line, "\t")
+		name := strings.TrimSpace(fields[0])
+		if isPool[name] {
+			if _, seen := sample[name]; seen || sample == nil {
+				sample = make(map[string]map[string]IOStats) // a new sample
+			}
+			current = make(map[string]IOStats)
+			sample[name] = current
+		}
+		if current == nil || len(fields) != 7 || fields[3] == "-" {
+			return nil
+		}
+		io := IOStats{Sampled: true}
+		for i, dst := range []*float64{&io.ReadOps, &io.WriteOps, &io.ReadBytes, &io.WriteBytes} {
+			v, err := strconv.ParseFloat(fields[3+i], 64)
+			if err != nil {
+				return fmt.Errorf("zpool iostat line %d: invalid rate %q", n, fields[3+i])
+			}
+			*dst = v
+		}
+		current[name] = io
+		return nil
+	})
+	return sample, err
+}
+
+// ParseVDevAshift parses the ashift vdev property of every VDev of a pool,
+// as printed by OpenZFS 2.2 and later:
+//
+//	zpool get -Hp -o name,value ashift <pool> all-vdevs
+//
+// Parameters:
+//   - out: The command output, one tab-separated line per VDev
+//
+// Returns:
+//   - map[string]int: The ashift by VDev name (e.g. "mirror-0", "sda"),
+//     leaving out VDevs without one ("-")
+//   - error: Error if a line has the wrong number of fields or a bad number
+func ParseVDevAshift(out []byte) (map[string]int, error) {
+	ashift := make(map[string]int)
+	err := eachLine(out, func(n int, line string) error {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 {
+			return fmt.Errorf("zpool get ashift line %d: %d fields, want 2", n, len(fields))
+		}
+		if fields[1] == "-" {
+			return nil
+		}
+		v, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("zpool get ashift line %d: invalid ashift %q", n, fields[1])
+		}
+		ashift[fields[0]] = v
+		return nil
+	})
+	return ashift, err
+}
+
+// sizeSuffixes are the binary multipliers of human readable sizes.
+const sizeSuffixes = "BKMGTPE"
+
+// ParseSize parses a size as printed by zpool and zfs: an exact byte
+// count with -p, or a human readable size such as "1.5T" or "512K" with
+// binary multipliers.
+//
+// Parameters:
+//   - s: The size to parse
+//
+// Returns:
+//   - uint64: The size in bytes
+//   - error: Error if the size is not a number with an optional suffix
+func ParseSize(s string) (uint64, error) {
+	if v, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return v, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	exp := strings.IndexByte(sizeSuffixes, s[len(s)-1])
+	if exp < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	v, err := strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(v * float64(uint64(1)<<(10*exp))), nil
+}
+
+// sectionKeyPattern matches the "key: value" lines starting a section of
+// zpool status, e.g. "  pool: tank" or " state: ONLINE".
+var sectionKeyPattern = regexp.MustCompile(`^ {0,8}([a-z]+): ?(.*)$`)
+
+// sectionKey returns the key and value of a line starting a section.
+func sectionKey(line string) (string, string, bool) {
+	if strings.HasPrefix(line, "\t") {
+		return "", "", false // config and scan continuation lines
+	}
+	m := sectionKeyPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], strings.TrimSpace(m[2]), true
+}
+
+// indent returns the number of spaces after the leading tab of a config line.
+func indent(line string) int {
+	line = strings.TrimPrefix(line, "\t")
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// eachLine calls fn with the number and text of every non-empty line.
+func eachLine(out []byte, fn func(n int, line string) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := fn(n, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
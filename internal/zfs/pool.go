@@ -1,5 +1,7 @@
 package zfs
 
+import "time"
+
 // GetPools returns a list of ZFS storage pools and their current state.
 // Currently provides mock data for development and testing purposes.
 // TODO: In production, this will be replaced with actual ZFS command execution.
@@ -18,54 +20,124 @@ package zfs
 //	    fmt.Printf("Pool: %s Status: %s\n", pool.Name, pool.Status)
 //	}
 func GetPools() ([]*Pool, error) {
+	now := time.Now()
+
 	// Mock data representing two ZFS pools with different configurations
 	return []*Pool{
 		{
 			// Basic mirrored pool configuration
-			Name:   "testpool",
-			Status: VDevStatusOnline,
+			Name:          "testpool",
+			Status:        VDevStatusOnline,
+			Size:          10 << 40, // 10 TiB
+			Allocated:     7 << 40,
+			Free:          3 << 40,
+			Fragmentation: 18,
+			Scan: &ScanInfo{
+				// Last scrub finished a week ago without finding problems
+				Function:  "scrub",
+				State:     "finished",
+				Start:     now.Add(-7*24*time.Hour - 3*time.Hour),
+				End:       now.Add(-7 * 24 * time.Hour),
+				Examined:  7 << 40,
+				ToExamine: 7 << 40,
+			},
 			RootVDev: &VDev{
 				// Root VDev represents the main storage configuration
-				Name:   "testpool",
-				Type:   "mirror", // Mirror provides 2-way redundancy
-				Status: VDevStatusOnline,
+				Name:      "testpool",
+				Type:      "mirror", // Mirror provides 2-way redundancy
+				Status:    VDevStatusOnline,
+				Size:      10 << 40,
+				Allocated: 7 << 40,
 				Children: []*VDev{
 					{
 						// First disk in mirror is degraded
 						Name:   "sda",
 						Type:   "disk",
 						Status: VDevStatusDegraded,
+						Disk: &DiskIdentity{
+							Device:     "sda",
+							ByID:       []string{"ata-WDC_WD101EFBX-68B0AN0_VCJ4A8KP", "wwn-0x5000cca27ec1d2a1"},
+							ByPath:     []string{"pci-0000:00:17.0-ata-1"},
+							ByVDev:     []string{"A1"},
+							Model:      "WDC WD101EFBX-68B0AN0",
+							Serial:     "VCJ4A8KP",
+							WWN:        "0x5000cca27ec1d2a1",
+							Rotational: true,
+							Size:       10 << 40,
+							Enclosure:  1,
+							Slot:       0,
+						},
+						SMART:          &SMARTInfo{Verdict: SMARTPassed, Temperature: 41, PowerOnHours: 31234},
+						Size:           10 << 40,
+						ReadErrors:     3,
+						ChecksumErrors: 12,
 					},
 					{
 						// Second disk in mirror is healthy
 						Name:   "sdb",
 						Type:   "disk",
 						Status: VDevStatusOnline,
+						Disk: &DiskIdentity{
+							Device:     "sdb",
+							ByID:       []string{"ata-WDC_WD101EFBX-68B0AN0_VCJ4B2ZM", "wwn-0x5000cca27ec1f4b7"},
+							ByPath:     []string{"pci-0000:00:17.0-ata-2"},
+							ByVDev:     []string{"A2"},
+							Model:      "WDC WD101EFBX-68B0AN0",
+							Serial:     "VCJ4B2ZM",
+							WWN:        "0x5000cca27ec1f4b7",
+							Rotational: true,
+							Size:       10 << 40,
+							Enclosure:  1,
+							Slot:       1,
+						},
+						// Still ONLINE, but SMART sees the disk failing
+						SMART: &SMARTInfo{Verdict: SMARTPassed, Temperature: 39, PowerOnHours: 31240, Reallocated: 24, Pending: 3},
+						Size:  10 << 40,
+						IO:    IOStats{Sampled: true, ReadOps: 120, WriteOps: 45, ReadBytes: 15 << 20, WriteBytes: 4 << 20},
 					},
 				},
 			},
 		},
 		{
 			// Advanced pool configuration with cache and log devices
-			Name:   "fastpool",
-			Status: VDevStatusOnline,
+			Name:          "fastpool",
+			Status:        VDevStatusOnline,
+			Size:          2 << 40, // 2 TiB
+			Allocated:     1 << 39,
+			Free:          3 << 39,
+			Fragmentation: 4,
+			Scan: &ScanInfo{
+				// Last scrub was a long time ago
+				Function:  "scrub",
+				State:     "finished",
+				Start:     now.Add(-60*24*time.Hour - time.Hour),
+				End:       now.Add(-60 * 24 * time.Hour),
+				Examined:  1 << 39,
+				ToExamine: 1 << 39,
+			},
 			RootVDev: &VDev{
 				// Main storage configuration using mirrored disks
-				Name:   "fastpool",
-				Type:   "mirror",
-				Status: VDevStatusOnline,
+				Name:      "fastpool",
+				Type:      "mirror",
+				Status:    VDevStatusOnline,
+				Size:      2 << 40,
+				Allocated: 1 << 39,
 				Children: []*VDev{
 					{
 						// First disk partition in mirror
 						Name:   "sda1",
 						Type:   "disk",
 						Status: VDevStatusOnline,
+						Size:   2 << 40,
+						IO:     IOStats{Sampled: true, ReadOps: 850, WriteOps: 310, ReadBytes: 96 << 20, WriteBytes: 38 << 20},
 					},
 					{
 						// Second disk partition in mirror
 						Name:   "sdb1",
 						Type:   "disk",
 						Status: VDevStatusOnline,
+						Size:   2 << 40,
+						IO:     IOStats{Sampled: true, ReadOps: 830, WriteOps: 310, ReadBytes: 94 << 20, WriteBytes: 38 << 20},
 					},
 				},
 			},
@@ -80,6 +152,7 @@ func GetPools() ([]*Pool, error) {
 						Name:   "nvme0n1p1",
 						Type:   "disk",
 						Status: VDevStatusOnline,
+						Size:   400 << 30,
 					},
 				},
 			},
@@ -95,12 +168,14 @@ func GetPools() ([]*Pool, error) {
 						Name:   "nvme1n1p1",
 						Type:   "disk",
 						Status: VDevStatusOnline,
+						Size:   16 << 30,
 					},
 					{
 						// Second NVMe partition for log mirror
 						Name:   "nvme1n2p1",
 						Type:   "disk",
 						Status: VDevStatusOnline,
+						Size:   16 << 30,
 					},
 				},
 			},
@@ -0,0 +1,145 @@
+package zfs
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SMART verdicts of the disk's overall self-assessment.
+const (
+	SMARTPassed = "PASSED"
+	SMARTFailed = "FAILED"
+)
+
+// SMARTInfo is the health a disk reports about itself through SMART, as
+// printed by smartctl -j -a.
+type SMARTInfo struct {
+	// Verdict is SMARTPassed or SMARTFailed, empty if the disk gave none
+	Verdict string
+
+	// Temperature is the current temperature in °C, 0 if unknown
+	Temperature int
+
+	// PowerOnHours is how long the disk has been powered on, 0 if unknown
+	PowerOnHours uint64
+
+	// Reallocated is the number of sectors the disk replaced by spares:
+	// ATA attribute 5, or the grown defect list of SCSI disks
+	Reallocated uint64
+
+	// Pending is the number of unstable sectors waiting to be
+	// reallocated (ATA attribute 197)
+	Pending uint64
+
+	// MediaErrors is the number of unrecovered data errors: the media
+	// errors of NVMe disks, offline uncorrectable sectors of ATA disks
+	// (attribute 198) or uncorrected read errors of SCSI disks
+	MediaErrors uint64
+}
+
+// smartJSON is the part of the smartctl -j -a output ParseSMART reads.
+type smartJSON struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATAAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeLog *struct {
+		MediaErrors uint64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+	SCSIGrownDefects *uint64 `json:"scsi_grown_defect_list"`
+	SCSIErrorLog     struct {
+		Read struct {
+			Uncorrected uint64 `json:"total_uncorrected_errors"`
+		} `json:"read"`
+	} `json:"scsi_error_counter_log"`
+}
+
+// ATA SMART attribute IDs.
+const (
+	ataReallocatedSectors   = 5
+	ataPendingSectors       = 197
+	ataOfflineUncorrectable = 198
+)
+
+// ParseSMART parses the JSON output of smartctl -j -a for ATA, NVMe and
+// SCSI disks.
+//
+// Parameters:
+//   - out: The output of smartctl -j -a <device>
+//
+// Returns:
+//   - *SMARTInfo: The health of the disk
+//   - error: Error if out is not smartctl JSON, or smartctl could not
+//     read the disk, e.g. because it is asleep with -n standby
+//
+// Example:
+//
+//	out, _ := exec.Command("smartctl", "-j", "-a", "/dev/sda").Output()
+//	info, err := zfs.ParseSMART(out)
+//	if err == nil && info.Verdict == zfs.SMARTFailed {
+//	    fmt.Println("replace /dev/sda")
+//	}
+func ParseSMART(out []byte) (*SMARTInfo, error) {
+	var js smartJSON
+	if err := json.Unmarshal(out, &js); err != nil {
+		return nil, fmt.Errorf("invalid smartctl output: %w", err)
+	}
+	// Bits 0 and 1 of the exit status mean the disk was not read; the
+	// others report what was read
+	if js.Smartctl.ExitStatus&3 != 0 {
+		reason := fmt.Sprintf("exit status %d", js.Smartctl.ExitStatus)
+		if len(js.Smartctl.Messages) > 0 {
+			reason = js.Smartctl.Messages[0].String
+		}
+		return nil, fmt.Errorf("smartctl could not read the disk: %s", reason)
+	}
+
+	info := &SMARTInfo{
+		Temperature:  js.Temperature.Current,
+		PowerOnHours: js.PowerOnTime.Hours,
+	}
+	if js.SmartStatus != nil {
+		info.Verdict = SMARTFailed
+		if js.SmartStatus.Passed {
+			info.Verdict = SMARTPassed
+		}
+	}
+	for _, attr := range js.ATAAttributes.Table {
+		switch attr.ID {
+		case ataReallocatedSectors:
+			info.Reallocated = attr.Raw.Value
+		case ataPendingSectors:
+			info.Pending = attr.Raw.Value
+		case ataOfflineUncorrectable:
+			info.MediaErrors = attr.Raw.Value
+		}
+	}
+	if js.NVMeLog != nil {
+		info.MediaErrors = js.NVMeLog.MediaErrors
+	}
+	if js.SCSIGrownDefects != nil {
+		info.Reallocated = *js.SCSIGrownDefects
+		info.MediaErrors = js.SCSIErrorLog.Read.Uncorrected
+	}
+	return info, nil
+}
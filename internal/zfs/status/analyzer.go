@@ -1,11 +1,41 @@
 package status
 
-import "github.com/petecog/vizfsulizer/internal/zfs"
+import (
+	"time"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
 
 // Analyzer provides methods for analyzing ZFS component health states.
 // It implements recursive traversal of VDev trees to determine the overall
 // health status of pools and their components.
-type Analyzer struct{}
+type Analyzer struct {
+	// SMART are the limits from which SMART health predicts a disk
+	// failure; nil for DefaultSMARTLimits
+	SMART *SMARTLimits
+
+	// LintCapacity is the capacity in percent above which LintPool
+	// reports a pool as too full; 0 for DefaultLintCapacity
+	LintCapacity float64
+}
+
+// SMARTLimits are the SMART values from which the analyzer predicts that a
+// disk will fail, whatever ZFS says about it. A limit of 0 is not checked.
+type SMARTLimits struct {
+	// Reallocated, Pending and MediaErrors are the lowest counts of
+	// reallocated sectors, pending sectors and media errors predicting
+	// a failure
+	Reallocated uint64
+	Pending     uint64
+	MediaErrors uint64
+
+	// Temperature is the lowest temperature in °C that is too hot
+	Temperature int
+}
+
+// DefaultSMARTLimits predict a failure from the first bad sector or media
+// error: disks showing any are several times more likely to fail soon.
+var DefaultSMARTLimits = SMARTLimits{Reallocated: 1, Pending: 1, MediaErrors: 1, Temperature: 60}
 
 // GetVDevWorstStatus analyzes a VDev and its children for the worst status.
 // It recursively traverses the VDev tree structure, comparing status values
@@ -36,19 +66,27 @@ func (an *Analyzer) GetVDevWorstStatus(vdev *zfs.VDev) zfs.VDevStatus {
 // GetPoolWorstStatus analyzes all components of a pool for worst status.
 // This includes the root VDev, cache devices (L2ARC), and log devices (ZIL).
 // Each component tree is analyzed separately and the worst status is returned.
+// A pool without a root VDev, e.g. when zpool status printed no config
+// section for it, has the state reported for the pool itself.
 //
 // Parameters:
 //   - pool: The ZFS pool to analyze, including all its components
 //
 // Returns:
-//   - zfs.VDevStatus: The most severe status found anywhere in the pool
+//   - zfs.VDevStatus: The most severe status found anywhere in the pool,
+//     ONLINE if nothing is known about it
 //
 // Example:
 //
 //	analyzer := &Analyzer{}
 //	status := analyzer.GetPoolWorstStatus(myPool)
 func (an *Analyzer) GetPoolWorstStatus(pool *zfs.Pool) zfs.VDevStatus {
-	worst := an.GetVDevWorstStatus(pool.RootVDev)
+	worst := pool.Status
+	if pool.RootVDev != nil {
+		worst = an.GetVDevWorstStatus(pool.RootVDev)
+	} else if worst == "" {
+		worst = zfs.VDevStatusOnline
+	}
 
 	if pool.Cache != nil {
 		if cacheStatus := an.GetVDevWorstStatus(pool.Cache); an.isWorse(cacheStatus, worst) {
@@ -65,6 +103,101 @@ func (an *Analyzer) GetPoolWorstStatus(pool *zfs.Pool) zfs.VDevStatus {
 	return worst
 }
 
+// GetWorseStatus returns the more severe of two statuses, ranking them as
+// GetPoolWorstStatus does, so that the health of several pools or hosts
+// can be summarised the same way as that of a single pool.
+//
+// Parameters:
+//   - a: First status to compare, returned if neither is worse
+//   - b: Second status to compare
+//
+// Returns:
+//   - zfs.VDevStatus: The more severe status
+//
+// Example:
+//
+//	analyzer := &Analyzer{}
+//	health := zfs.VDevStatusOnline
+//	for _, pool := range pools {
+//	    health = analyzer.GetWorseStatus(health, analyzer.GetPoolWorstStatus(pool))
+//	}
+func (an *Analyzer) GetWorseStatus(a, b zfs.VDevStatus) zfs.VDevStatus {
+	if an.isWorse(b, a) {
+		return b
+	}
+	return a
+}
+
+// GetVDevErrorCount sums the read, write and checksum error counters of a VDev
+// and all of its children.
+//
+// Parameters:
+//   - vdev: The VDev to analyze, including all its child devices
+//
+// Returns:
+//   - uint64: The total number of errors reported in the VDev tree
+//
+// Example:
+//
+//	analyzer := &Analyzer{}
+//	errors := analyzer.GetVDevErrorCount(pool.RootVDev)
+func (an *Analyzer) GetVDevErrorCount(vdev *zfs.VDev) uint64 {
+	count := vdev.ReadErrors + vdev.WriteErrors + vdev.ChecksumErrors
+
+	for _, child := range vdev.Children {
+		count += an.GetVDevErrorCount(child)
+	}
+	return count
+}
+
+// GetPoolErrorCount sums the error counters of all components of a pool,
+// including the root VDev, cache devices (L2ARC) and log devices (ZIL).
+//
+// Parameters:
+//   - pool: The ZFS pool to analyze, including all its components
+//
+// Returns:
+//   - uint64: The total number of errors reported anywhere in the pool
+//
+// Example:
+//
+//	analyzer := &Analyzer{}
+//	errors := analyzer.GetPoolErrorCount(myPool)
+func (an *Analyzer) GetPoolErrorCount(pool *zfs.Pool) uint64 {
+	var count uint64
+	for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+		if vdev != nil {
+			count += an.GetVDevErrorCount(vdev)
+		}
+	}
+	return count
+}
+
+// GetScrubAge returns how long ago the pool's last scrub completed.
+// Resilvers and scrubs that are still running or were canceled are ignored.
+//
+// Parameters:
+//   - pool: The ZFS pool to analyze
+//   - now: The reference time to measure the age from
+//
+// Returns:
+//   - time.Duration: Time elapsed since the last completed scrub
+//   - bool: false if the pool has never completed a scrub
+//
+// Example:
+//
+//	analyzer := &Analyzer{}
+//	if age, ok := analyzer.GetScrubAge(myPool, time.Now()); ok {
+//	    fmt.Printf("Last scrubbed %s ago\n", age)
+//	}
+func (an *Analyzer) GetScrubAge(pool *zfs.Pool, now time.Time) (time.Duration, bool) {
+	scan := pool.Scan
+	if scan == nil || scan.Function != "scrub" || scan.State != "finished" || scan.End.IsZero() {
+		return 0, false
+	}
+	return now.Sub(scan.End), true
+}
+
 // isWorse determines if status 'a' represents a worse condition than status 'b'.
 // The severity order from worst to best is:
 //  1. FAULTED  - Device is completely unavailable
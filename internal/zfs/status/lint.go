@@ -0,0 +1,243 @@
+package status
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// Lint checks, named in findings.
+const (
+	CheckMixedTypes    = "mixed-types"
+	CheckMixedWidths   = "mixed-widths"
+	CheckSingleDisk    = "single-disk"
+	CheckMixedAshift   = "mixed-ashift"
+	CheckClassNoRed    = "class-not-redundant"
+	CheckUnmirroredLog = "unmirrored-log"
+	CheckUnstableNames = "unstable-names"
+	CheckCapacity      = "capacity"
+)
+
+// DefaultLintCapacity is the capacity in percent above which a pool is
+// reported as too full: from there on ZFS allocates blocks more slowly and
+// fragments free space.
+const DefaultLintCapacity = 80
+
+// unstableName matches the kernel names of SCSI and SATA disks and their
+// partitions, such as "sda" or "/dev/sdb1".
+var unstableName = regexp.MustCompile(`^(/dev/)?sd[a-z]+[0-9]*$`)
+
+// Finding is a risky or suboptimal part of a pool's layout, found by
+// LintPool. Unlike other warnings it does not go away by itself: it lasts
+// until the pool is reconfigured.
+type Finding struct {
+	Warning
+
+	// Check names the check that found it, e.g. CheckMixedWidths
+	Check string
+
+	// Explanation tells why the layout is a problem and how to fix it
+	Explanation string
+}
+
+// LintPool checks the layout of a pool for configurations that risk data
+// or perform poorly:
+//   - top-level VDevs of different types or widths
+//   - single-disk top-level VDevs alongside redundant ones
+//   - top-level VDevs with different ashift
+//   - special and dedup VDevs without redundancy
+//   - log devices without a mirror
+//   - disks referenced by unstable sdX names
+//   - capacity above LintCapacity
+//
+// Findings are ordered by severity, most severe first.
+//
+// Parameters:
+//   - pool: The ZFS pool to check
+//
+// Returns:
+//   - []Finding: The findings, empty for a sound layout
+//
+// Example:
+//
+//	analyzer := &Analyzer{}
+//	for _, f := range analyzer.LintPool(myPool) {
+//	    fmt.Printf("[%s] %s\n    %s\n", f.Severity, f, f.Explanation)
+//	}
+func (an *Analyzer) LintPool(pool *zfs.Pool) []Finding {
+	var findings []Finding
+	add := func(severity Severity, device, check, message, explanation string) {
+		findings = append(findings, Finding{Warning{severity, pool.Name, device, message}, check, explanation})
+	}
+
+	// The data class, and the special and dedup classes grouped below
+	// the root
+	var data, classes []*zfs.VDev
+	for _, vdev := range zfs.TopLevel(pool.RootVDev) {
+		if vdev.Type == "special" || vdev.Type == "dedup" {
+			classes = append(classes, vdev)
+		} else {
+			data = append(data, vdev)
+		}
+	}
+
+	var redundant, single []*zfs.VDev
+	for _, vdev := range data {
+		if isLeaf(vdev) {
+			single = append(single, vdev)
+		} else {
+			redundant = append(redundant, vdev)
+		}
+	}
+	if len(redundant) > 0 {
+		for _, vdev := range single {
+			add(SeverityCritical, vdev.Name, CheckSingleDisk,
+				"single-disk vdev in a pool of redundant vdevs",
+				"The pool is lost if this disk fails, whatever the redundancy of the other vdevs. "+
+					"Attach a second disk to make it a mirror: zpool attach "+pool.Name+" "+vdev.Name+" <new disk>.")
+		}
+	}
+
+	if types := countBy(redundant, func(v *zfs.VDev) string { return v.Type }); len(types) > 1 {
+		add(SeverityWarning, "", CheckMixedTypes, "mixed vdev types: "+types.String(),
+			"The pool is only as redundant as its weakest vdev, and data is spread by free space, "+
+				"so performance differs from block to block. Keep all data vdevs of one type.")
+	} else if widths := countBy(redundant, func(v *zfs.VDev) string { return fmt.Sprintf("%d-wide", len(v.Children)) }); len(widths) > 1 {
+		add(SeverityInfo, "", CheckMixedWidths, "mixed vdev widths: "+widths.String(),
+			"Vdevs of different widths differ in redundancy, space efficiency and speed, "+
+				"and fill up at different rates. Add vdevs of the width the pool was created with.")
+	}
+
+	ashifts := countBy(append(data, childrenOf(classes)...), func(v *zfs.VDev) string {
+		if v.Ashift == 0 {
+			return ""
+		}
+		return fmt.Sprintf("at ashift %d", v.Ashift)
+	})
+	delete(ashifts, "")
+	if len(ashifts) > 1 {
+		add(SeverityWarning, "", CheckMixedAshift, "mismatched ashift: "+ashifts.String(),
+			"Top-level vdevs cannot be removed from a pool whose vdevs differ in ashift, and a vdev with a smaller "+
+				"ashift than the sectors of its disks rewrites whole sectors for every small write. "+
+				"Give new vdevs the pool's ashift: zpool add -o ashift=N.")
+	}
+
+	for _, class := range classes {
+		holds := "the pool's metadata and small blocks"
+		if class.Type == "dedup" {
+			holds = "the pool's dedup table"
+		}
+		for _, vdev := range class.Children {
+			if isLeaf(vdev) {
+				add(SeverityCritical, vdev.Name, CheckClassNoRed, class.Type+" vdev without redundancy",
+					"The "+class.Type+" class holds "+holds+", so the whole pool is lost if this device fails. "+
+						"Mirror it at least as well as the data vdevs: zpool attach "+pool.Name+" "+vdev.Name+" <new device>.")
+			}
+		}
+	}
+
+	for _, vdev := range logVDevs(pool.Slog) {
+		if isLeaf(vdev) {
+			add(SeverityWarning, vdev.Name, CheckUnmirroredLog, "log device without a mirror",
+				"Synchronous writes of the last seconds are lost if this device fails while the host crashes, "+
+					"and performance drops until it is replaced. Mirror it: zpool attach "+pool.Name+" "+vdev.Name+" <new device>.")
+		}
+	}
+
+	var names []string
+	for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+		walkLeaves(vdev, func(leaf *zfs.VDev) {
+			if unstableName.MatchString(leaf.Name) {
+				names = append(names, leaf.Name)
+			}
+		})
+	}
+	if len(names) > 0 {
+		add(SeverityInfo, "", CheckUnstableNames, fmt.Sprintf("%d disks referenced by sdX names: %s", len(names), strings.Join(names, ", ")),
+			"Kernel names change when disks are added, removed or detected in another order, which can keep the pool "+
+				"from importing or confuse which disk to replace. Import the pool by stable names: "+
+				"zpool export "+pool.Name+" && zpool import -d /dev/disk/by-id "+pool.Name+".")
+	}
+
+	limit := an.LintCapacity
+	if limit == 0 {
+		limit = DefaultLintCapacity
+	}
+	if capacity := pool.CapacityPercent(); capacity > limit {
+		add(SeverityWarning, "", CheckCapacity, fmt.Sprintf("capacity %.0f%% above %.0f%%", capacity, limit),
+			"Above this, ZFS searches longer for free blocks and fragments the free space, slowing down writes "+
+				"for good. Free space or add a vdev like the existing ones before the pool fills up.")
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Severity > findings[j].Severity })
+	return findings
+}
+
+// isLeaf reports whether a VDev is a single device without redundancy.
+func isLeaf(vdev *zfs.VDev) bool {
+	return len(vdev.Children) == 0
+}
+
+// logVDevs returns the top-level VDevs of a pool's Slog: the children of
+// a "log" group, or the Slog itself.
+func logVDevs(slog *zfs.VDev) []*zfs.VDev {
+	if slog == nil {
+		return nil
+	}
+	if slog.Type == "log" {
+		return slog.Children
+	}
+	return []*zfs.VDev{slog}
+}
+
+// childrenOf returns the children of the VDevs.
+func childrenOf(vdevs []*zfs.VDev) []*zfs.VDev {
+	var children []*zfs.VDev
+	for _, vdev := range vdevs {
+		children = append(children, vdev.Children...)
+	}
+	return children
+}
+
+// walkLeaves calls fn for every leaf of a VDev tree.
+func walkLeaves(vdev *zfs.VDev, fn func(*zfs.VDev)) {
+	if vdev == nil {
+		return
+	}
+	if isLeaf(vdev) {
+		fn(vdev)
+		return
+	}
+	for _, child := range vdev.Children {
+		walkLeaves(child, fn)
+	}
+}
+
+// counts counts VDevs by a property, e.g. their type.
+type counts map[string]int
+
+// countBy counts VDevs by the key fn returns.
+func countBy(vdevs []*zfs.VDev, fn func(*zfs.VDev) string) counts {
+	c := make(counts)
+	for _, vdev := range vdevs {
+		c[fn(vdev)]++
+	}
+	return c
+}
+
+// String lists the counts by key, e.g. "2 mirror, 1 raidz2".
+func (c counts) String() string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%d %s", c[key], key)
+	}
+	return strings.Join(parts, ", ")
+}
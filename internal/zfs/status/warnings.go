@@ -0,0 +1,176 @@
+package status
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+// Severity ranks how urgently a warning needs attention.
+type Severity int
+
+// Warning severities from least to most severe.
+const (
+	// SeverityInfo is worth knowing but needs no action
+	SeverityInfo Severity = iota
+
+	// SeverityWarning needs attention soon
+	SeverityWarning
+
+	// SeverityCritical needs immediate attention
+	SeverityCritical
+)
+
+// String returns the lower-case severity name.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "critical"
+	}
+}
+
+// Warning is a problem found by the analyzer.
+type Warning struct {
+	// Severity ranks how urgent the problem is
+	Severity Severity
+
+	// Pool is the name of the affected pool
+	Pool string
+
+	// Device is the affected VDev name, empty for pool-wide warnings
+	Device string
+
+	// Message describes the problem
+	Message string
+}
+
+// String formats the warning as "pool/device: message".
+func (w Warning) String() string {
+	where := w.Pool
+	if w.Device != "" {
+		where += "/" + w.Device
+	}
+	return where + ": " + w.Message
+}
+
+// GetPoolWarnings lists the problems of a pool: devices that are not
+// ONLINE, devices with error counters, disks whose SMART health predicts
+// a failure, errors found by the last scan and scans in progress.
+// Warnings are ordered by their position in the pool.
+//
+// Parameters:
+//   - pool: The ZFS pool to analyze, including all its components
+//
+// Returns:
+//   - []Warning: The problems found, empty for a healthy pool
+//
+// Example:
+//
+//	analyzer := &Analyzer{}
+//	for _, w := range analyzer.GetPoolWarnings(myPool) {
+//	    fmt.Printf("[%s] %s\n", w.Severity, w)
+//	}
+func (an *Analyzer) GetPoolWarnings(pool *zfs.Pool) []Warning {
+	var warnings []Warning
+	for _, vdev := range []*zfs.VDev{pool.RootVDev, pool.Cache, pool.Slog} {
+		if vdev != nil {
+			warnings = an.appendVDevWarnings(warnings, pool.Name, vdev)
+		}
+	}
+
+	if scan := pool.Scan; scan != nil {
+		if scan.Errors > 0 {
+			warnings = append(warnings, Warning{
+				Severity: SeverityCritical,
+				Pool:     pool.Name,
+				Message:  fmt.Sprintf("last %s found %d errors", scan.Function, scan.Errors),
+			})
+		}
+		if scan.State == "scanning" {
+			msg := scan.Function + " in progress"
+			if scan.ToExamine > 0 {
+				msg += fmt.Sprintf(" (%.1f%%)", float64(scan.Examined)/float64(scan.ToExamine)*100)
+			}
+			warnings = append(warnings, Warning{Severity: SeverityInfo, Pool: pool.Name, Message: msg})
+		}
+	}
+	return warnings
+}
+
+// appendVDevWarnings appends the warnings of a VDev and its children.
+func (an *Analyzer) appendVDevWarnings(warnings []Warning, pool string, vdev *zfs.VDev) []Warning {
+	switch vdev.Status {
+	case zfs.VDevStatusOnline:
+	case zfs.VDevStatusDegraded:
+		warnings = append(warnings, Warning{SeverityWarning, pool, vdev.Name, "device is DEGRADED"})
+	case zfs.VDevStatusFaulted:
+		warnings = append(warnings, Warning{SeverityCritical, pool, vdev.Name, "device is FAULTED"})
+	default:
+		warnings = append(warnings, Warning{SeverityWarning, pool, vdev.Name, "device is " + string(vdev.Status)})
+	}
+
+	if vdev.ReadErrors+vdev.WriteErrors+vdev.ChecksumErrors > 0 {
+		var counts []string
+		for _, c := range []struct {
+			n    uint64
+			kind string
+		}{{vdev.ReadErrors, "read"}, {vdev.WriteErrors, "write"}, {vdev.ChecksumErrors, "checksum"}} {
+			if c.n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", c.n, c.kind))
+			}
+		}
+		warnings = append(warnings, Warning{SeverityWarning, pool, vdev.Name,
+			strings.Join(counts, ", ") + " errors"})
+	}
+
+	if vdev.SMART != nil {
+		warnings = an.appendSMARTWarnings(warnings, pool, vdev)
+	}
+
+	for _, child := range vdev.Children {
+		warnings = an.appendVDevWarnings(warnings, pool, child)
+	}
+	return warnings
+}
+
+// appendSMARTWarnings appends a "predicted failure" warning if the SMART
+// health of a disk crosses the limits, critical if the disk itself
+// predicts its failure.
+func (an *Analyzer) appendSMARTWarnings(warnings []Warning, pool string, vdev *zfs.VDev) []Warning {
+	limits := DefaultSMARTLimits
+	if an.SMART != nil {
+		limits = *an.SMART
+	}
+	smart := vdev.SMART
+
+	var reasons []string
+	severity := SeverityWarning
+	if smart.Verdict == zfs.SMARTFailed {
+		reasons = append(reasons, "SMART self-assessment FAILED")
+		severity = SeverityCritical
+	}
+	for _, c := range []struct {
+		n, limit uint64
+		kind     string
+	}{
+		{smart.Reallocated, limits.Reallocated, "reallocated sectors"},
+		{smart.Pending, limits.Pending, "pending sectors"},
+		{smart.MediaErrors, limits.MediaErrors, "media errors"},
+	} {
+		if c.limit > 0 && c.n >= c.limit {
+			reasons = append(reasons, fmt.Sprintf("%d %s", c.n, c.kind))
+		}
+	}
+	if limits.Temperature > 0 && smart.Temperature >= limits.Temperature {
+		reasons = append(reasons, fmt.Sprintf("%d°C", smart.Temperature))
+	}
+	if len(reasons) == 0 {
+		return warnings
+	}
+	return append(warnings, Warning{severity, pool, vdev.Name, "predicted failure: " + strings.Join(reasons, ", ")})
+}
@@ -0,0 +1,109 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/petecog/vizfsulizer/internal/zfs"
+)
+
+func TestSMARTWarnings(t *testing.T) {
+	disk := func(name string, smart *zfs.SMARTInfo) *zfs.VDev {
+		return &zfs.VDev{Name: name, Type: "disk", Status: zfs.VDevStatusOnline, SMART: smart}
+	}
+	pool := &zfs.Pool{Name: "tank", Status: zfs.VDevStatusOnline, RootVDev: &zfs.VDev{
+		Name: "mirror-0", Type: "mirror", Status: zfs.VDevStatusOnline, Children: []*zfs.VDev{
+			disk("sda", &zfs.SMARTInfo{Verdict: zfs.SMARTPassed, Temperature: 38}),
+			disk("sdb", &zfs.SMARTInfo{Verdict: zfs.SMARTPassed, Temperature: 61, Reallocated: 24, Pending: 3}),
+			disk("sdc", &zfs.SMARTInfo{Verdict: zfs.SMARTFailed}),
+			disk("sdd", nil),
+		},
+	}}
+
+	warnings := (&Analyzer{}).GetPoolWarnings(pool)
+	want := []Warning{
+		{SeverityWarning, "tank", "sdb", "predicted failure: 24 reallocated sectors, 3 pending sectors, 61°C"},
+		{SeverityCritical, "tank", "sdc", "predicted failure: SMART self-assessment FAILED"},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings %+v, want %+v", warnings, want)
+	}
+	for i := range want {
+		if warnings[i] != want[i] {
+			t.Errorf("warning %d = %+v, want %+v", i, warnings[i], want[i])
+		}
+	}
+
+	// Custom limits tolerate a few reallocated sectors
+	lenient := &Analyzer{SMART: &SMARTLimits{Reallocated: 100, Pending: 1}}
+	if got := lenient.GetPoolWarnings(pool)[0].Message; got != "predicted failure: 3 pending sectors" {
+		t.Errorf("lenient warning %q", got)
+	}
+}
+
+func TestLintPool(t *testing.T) {
+	disk := func(name string, ashift int) *zfs.VDev {
+		return &zfs.VDev{Name: name, Type: "disk", Status: zfs.VDevStatusOnline, Ashift: ashift}
+	}
+	group := func(name, typ string, ashift int, children ...*zfs.VDev) *zfs.VDev {
+		return &zfs.VDev{Name: name, Type: typ, Status: zfs.VDevStatusOnline, Ashift: ashift, Children: children}
+	}
+	pool := &zfs.Pool{Name: "tank", Status: zfs.VDevStatusOnline, Size: 100, Allocated: 85,
+		RootVDev: group("tank", zfs.VDevTypeRoot, 0,
+			group("raidz2-0", "raidz2", 12, disk("ata-A", 12), disk("ata-B", 12), disk("ata-C", 12), disk("ata-D", 12)),
+			group("mirror-1", "mirror", 9, disk("sde", 9), disk("sdf", 9)),
+			disk("ata-G", 12),
+			group("special", "special", 0, disk("nvme-H", 12)),
+		),
+		Slog: group("logs", "log", 0, disk("nvme-I", 12)),
+	}
+
+	findings := (&Analyzer{}).LintPool(pool)
+	want := []struct {
+		severity Severity
+		check    string
+		device   string
+		message  string
+	}{
+		{SeverityCritical, CheckSingleDisk, "ata-G", "single-disk vdev in a pool of redundant vdevs"},
+		{SeverityCritical, CheckClassNoRed, "nvme-H", "special vdev without redundancy"},
+		{SeverityWarning, CheckMixedTypes, "", "mixed vdev types: 1 mirror, 1 raidz2"},
+		{SeverityWarning, CheckMixedAshift, "", "mismatched ashift: 3 at ashift 12, 1 at ashift 9"},
+		{SeverityWarning, CheckUnmirroredLog, "nvme-I", "log device without a mirror"},
+		{SeverityWarning, CheckCapacity, "", "capacity 85% above 80%"},
+		{SeverityInfo, CheckUnstableNames, "", "2 disks referenced by sdX names: sde, sdf"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("findings %+v", findings)
+	}
+	for i, w := range want {
+		f := findings[i]
+		if f.Severity != w.severity || f.Check != w.check || f.Device != w.device || f.Message != w.message || f.Explanation == "" {
+			t.Errorf("finding %d = %+v, want %+v", i, f, w)
+		}
+	}
+
+	// Vdevs of one type but different widths
+	pool = &zfs.Pool{Name: "tank", Status: zfs.VDevStatusOnline, RootVDev: group("tank", zfs.VDevTypeRoot, 0,
+		group("mirror-0", "mirror", 12, disk("ata-A", 12), disk("ata-B", 12)),
+		group("mirror-1", "mirror", 12, disk("ata-C", 12), disk("ata-D", 12), disk("ata-E", 12)),
+	)}
+	findings = (&Analyzer{LintCapacity: 90}).LintPool(pool)
+	if len(findings) != 1 || findings[0].Check != CheckMixedWidths || findings[0].Message != "mixed vdev widths: 1 2-wide, 1 3-wide" {
+		t.Errorf("findings %+v", findings)
+	}
+}
+
+func TestPoolWithoutVDevs(t *testing.T) {
+	// zpool status prints no config section for some unavailable pools
+	an := &Analyzer{}
+	pool := &zfs.Pool{Name: "tank", Status: zfs.VDevStatusFaulted, Cache: &zfs.VDev{Name: "cache", Status: zfs.VDevStatusOnline}}
+	if got := an.GetPoolWorstStatus(pool); got != zfs.VDevStatusFaulted {
+		t.Errorf("status %s, want the pool's FAULTED", got)
+	}
+	if got := an.GetPoolWorstStatus(&zfs.Pool{Name: "new"}); got != zfs.VDevStatusOnline {
+		t.Errorf("status of an unknown pool %q", got)
+	}
+	if an.GetPoolErrorCount(pool) != 0 || len(an.GetPoolWarnings(pool)) != 0 || len(an.LintPool(pool)) != 0 {
+		t.Error("problems found without vdevs")
+	}
+}
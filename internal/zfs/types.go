@@ -1,5 +1,10 @@
 package zfs
 
+import (
+	"fmt"
+	"time"
+)
+
 // VDevStatus represents the health status of a ZFS virtual device (VDev).
 // It is implemented as a string type to represent different operational states.
 type VDevStatus string
@@ -32,11 +37,220 @@ type VDev struct {
 	// Status represents the current health state of this VDev
 	Status VDevStatus
 
+	// GUID is the unique identifier ZFS assigned to this VDev, or 0 if
+	// unknown; events refer to VDevs by it
+	GUID uint64
+
+	// Path is the device node of a leaf VDev (e.g., "/dev/sda1"), or
+	// empty if unknown
+	Path string
+
+	// Disk identifies the physical disk of a leaf VDev, or is nil if it
+	// was not resolved
+	Disk *DiskIdentity
+
+	// SMART is the health the disk of a leaf VDev reports about itself,
+	// or nil if it was not read
+	SMART *SMARTInfo
+
+	// Size is the raw size of the device in bytes, or 0 if unknown
+	Size uint64
+
+	// Ashift is the base 2 logarithm of the smallest block ZFS writes to
+	// the device (12 for 4 KiB), fixed when a top-level VDev is created;
+	// 0 if unknown
+	Ashift int
+
+	// Allocated is the space allocated on a top-level VDev in bytes,
+	// or 0 if unknown or not applicable (leaf disks, cache devices)
+	Allocated uint64
+
+	// ReadErrors, WriteErrors and ChecksumErrors are the error counters
+	// reported by ZFS for this VDev since the pool was imported or cleared
+	ReadErrors     uint64
+	WriteErrors    uint64
+	ChecksumErrors uint64
+
+	// IO holds the current I/O rates of this VDev, if its source samples
+	// them
+	IO IOStats
+
 	// Children contains any child VDevs for logical devices
 	// For example, a mirror VDev would have multiple disk VDevs as children
 	Children []*VDev
 }
 
+// DiskIdentity describes the physical disk behind a leaf VDev, as found
+// in /dev/disk and sysfs, so that the disk can be told apart from its
+// neighbours when it has to be pulled.
+type DiskIdentity struct {
+	// Device is the kernel name of the whole disk (e.g., "sda", "nvme0n1")
+	Device string
+
+	// ByID, ByPath and ByVDev are the names of the disk's links in
+	// /dev/disk/by-id, by-path and by-vdev (vdev_id.conf aliases such as
+	// "A14"); by-id names of the model and serial come before WWN names
+	ByID   []string
+	ByPath []string
+	ByVDev []string
+
+	// Model, Serial and WWN are as reported by the disk; empty if unknown
+	Model  string
+	Serial string
+	WWN    string
+
+	// Rotational is true for spinning disks, false for SSDs
+	Rotational bool
+
+	// Size is the capacity of the whole disk in bytes, or 0 if unknown
+	Size uint64
+
+	// Enclosure is the Number of the enclosure the disk sits in, and Slot
+	// its slot there; Enclosure is 0 if the disk is in none
+	Enclosure int
+	Slot      int
+}
+
+// Location describes where the disk sits for whoever has to pull it.
+//
+// Returns:
+//   - string: E.g. "enclosure 2, slot 14", or empty if unknown
+func (d *DiskIdentity) Location() string {
+	if d.Enclosure == 0 {
+		return ""
+	}
+	return fmt.Sprintf("enclosure %d, slot %d", d.Enclosure, d.Slot)
+}
+
+// Enclosure is a disk enclosure managed through SES, such as the backplane
+// of a server or a disk shelf, as found in /sys/class/enclosure.
+type Enclosure struct {
+	// Number counts the enclosures of a host from 1, in the order of
+	// their SCSI addresses
+	Number int
+
+	// ID is the SCSI address of the enclosure (e.g. "0:0:20:0"), and
+	// LogicalID its SAS address (e.g. "0x5003048001a2b37f") if known
+	ID        string
+	LogicalID string
+
+	// Vendor and Model name the enclosure, empty if unknown
+	Vendor string
+	Model  string
+
+	// Slots are the disk slots, ordered by slot number
+	Slots []*EnclosureSlot
+}
+
+// EnclosureSlot is a disk slot of an enclosure.
+type EnclosureSlot struct {
+	// Number is the slot number, as printed on the chassis by most vendors
+	Number int
+
+	// Name is the name the enclosure gives the slot, e.g. "Slot 14"
+	Name string
+
+	// Device is the kernel name of the disk in the slot (e.g. "sdq"),
+	// empty for an empty slot
+	Device string
+
+	// Status is the SES status of the slot, e.g. "OK" or "Not Installed"
+	Status string
+
+	// Fault and Locate tell whether the slot's fault and locate LEDs are on
+	Fault  bool
+	Locate bool
+}
+
+// DiskLabels are the identities a leaf VDev can be labelled by, see
+// DiskIdentity.Label. "name" is the VDev name as shown by zpool status.
+var DiskLabels = []string{"name", "by-id", "by-path", "by-vdev", "serial", "wwn"}
+
+// Label returns the identity of the disk of a kind of DiskLabels.
+//
+// Parameters:
+//   - kind: The kind of identity, e.g. "serial"
+//
+// Returns:
+//   - string: The first name of the kind, or empty if the disk has none
+//     or kind is "name"
+func (d *DiskIdentity) Label(kind string) string {
+	first := func(names []string) string {
+		if len(names) == 0 {
+			return ""
+		}
+		return names[0]
+	}
+	switch kind {
+	case "by-id":
+		return first(d.ByID)
+	case "by-path":
+		return first(d.ByPath)
+	case "by-vdev":
+		return first(d.ByVDev)
+	case "serial":
+		return d.Serial
+	case "wwn":
+		return d.WWN
+	}
+	return ""
+}
+
+// VDevTypeRoot is the type of a VDev that only groups the top-level VDevs
+// of a pool. Pools with a single top-level VDev may use it directly as
+// their RootVDev instead.
+const VDevTypeRoot = "root"
+
+// TopLevel returns the top-level VDevs of a VDev tree such as Pool.RootVDev.
+// The children of a VDevTypeRoot VDev are returned; any other VDev is
+// itself the only top-level VDev. A nil VDev yields nil.
+func TopLevel(vdev *VDev) []*VDev {
+	if vdev == nil {
+		return nil
+	}
+	if vdev.Type == VDevTypeRoot {
+		return vdev.Children
+	}
+	return []*VDev{vdev}
+}
+
+// IOStats holds I/O rates of a VDev, averaged over a sampling interval.
+// Sources that do not sample I/O leave it unset: Sampled is false and the
+// rates are unknown rather than zero.
+type IOStats struct {
+	// Sampled is true if the rates were measured
+	Sampled bool
+
+	// ReadOps and WriteOps are operations per second
+	ReadOps  float64
+	WriteOps float64
+
+	// ReadBytes and WriteBytes are bytes per second
+	ReadBytes  float64
+	WriteBytes float64
+}
+
+// ScanInfo describes the most recent scrub or resilver of a pool.
+type ScanInfo struct {
+	// Function is the kind of scan, either "scrub" or "resilver"
+	Function string
+
+	// State is the scan state (e.g., "scanning", "finished", "canceled")
+	State string
+
+	// Start and End are the times the scan started and finished.
+	// End is the zero time while a scan is still in progress
+	Start time.Time
+	End   time.Time
+
+	// Examined and ToExamine are the bytes scanned so far and in total
+	Examined  uint64
+	ToExamine uint64
+
+	// Errors is the number of errors found by the scan
+	Errors uint64
+}
+
 // Pool represents a ZFS storage pool, which is the top-level container
 // for data storage. A pool consists of one or more VDevs arranged in
 // a specific configuration for redundancy and performance.
@@ -58,4 +272,77 @@ type Pool struct {
 	// Slog is an optional separate intent log device (ZIL)
 	// Used to improve synchronous write performance
 	Slog *VDev
+
+	// Size, Allocated and Free are the pool's raw capacity figures in bytes
+	Size      uint64
+	Allocated uint64
+	Free      uint64
+
+	// Fragmentation is the free space fragmentation as a percentage
+	Fragmentation int
+
+	// Scan is the most recent scrub or resilver, or nil if none has run
+	Scan *ScanInfo
+}
+
+// CapacityPercent returns the percentage of the pool's size that is allocated.
+// It returns 0 for pools with an unknown (zero) size.
+func (p *Pool) CapacityPercent() float64 {
+	if p.Size == 0 {
+		return 0
+	}
+	return float64(p.Allocated) / float64(p.Size) * 100
+}
+
+// ARCStats holds the state of the Adaptive Replacement Cache, the in-memory
+// read cache shared by all pools on a host.
+type ARCStats struct {
+	// Size is the current size of the ARC in bytes
+	Size uint64
+
+	// TargetSize is the size the ARC is currently adapting towards (c)
+	TargetSize uint64
+
+	// MaxSize is the upper limit of the ARC size (c_max)
+	MaxSize uint64
+
+	// Hits and Misses are cumulative lookup counters since boot
+	Hits   uint64
+	Misses uint64
+}
+
+// Dataset represents a ZFS dataset (filesystem or volume). Datasets form a
+// tree per pool, mirroring the slash-separated dataset names.
+type Dataset struct {
+	// Name is the full dataset name (e.g., "tank/home/alice")
+	Name string
+
+	// Type is "filesystem" or "volume"; empty means filesystem
+	Type string
+
+	// Used, Available and Referenced are the space accounting properties in bytes
+	Used       uint64
+	Available  uint64
+	Referenced uint64
+
+	// Quota is the quota in bytes, or 0 if none is set
+	Quota uint64
+
+	// Mountpoint is where a filesystem is mounted; empty for volumes
+	Mountpoint string
+
+	// Compression is the compression algorithm, empty if compression is off
+	Compression string
+
+	// CompressRatio is the achieved compression ratio (e.g., 1.42)
+	CompressRatio float64
+
+	// Snapshots is the number of snapshots of this dataset
+	Snapshots int
+
+	// SnapshotsUsed is the space used by snapshots of this dataset in bytes
+	SnapshotsUsed uint64
+
+	// Children contains the datasets nested below this one
+	Children []*Dataset
 }